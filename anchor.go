@@ -0,0 +1,179 @@
+package hashring
+
+// Anchor maps keys to nodes by AnchorHash (Mendelson et al.): nodes take
+// buckets of a fixed capacity, the anchor, and a key of a removed bucket is
+// rehashed among the buckets left when it was removed. Lookups take about
+// constant time, without allocating, memory is a few ints per bucket of the
+// anchor, and a change moves only the keys it must. Nodes have no weights.
+// Anchor implements NodeLocator.
+//
+// Keys are hashed as by a HashRing of the same options, e.g. WithHasher.
+// AddNode and RemoveNode work on a copy of the anchor and return it as a new
+// Anchor, so an Anchor never changes once created.
+type Anchor struct {
+	a []int32 // bucket to the working set size when removed, 0 if working.
+	w []int32 // working set, bucket at each place.
+	l []int32 // bucket to its place in w.
+	k []int32 // bucket to its successor among the buckets left on removal.
+	r []int32 // removed buckets, the last removed last.
+	n int     // size of the working set.
+
+	buckets []string       // node of each bucket, "" if none.
+	nodes   map[string]int // node to its bucket.
+	hash    *HashRing      // hashes keys, without nodes.
+}
+
+// NewAnchor creates an Anchor of capacity buckets with nodes, in order, on
+// the first buckets. Capacity bounds the number of nodes on it at once; it
+// is raised to the number of nodes if lower.
+func NewAnchor(capacity int, nodes []string, opts ...Option) *Anchor {
+	unique := sortedNodes(nodes)
+	if len(unique) < len(nodes) {
+		nodes = unique
+	}
+	if capacity < len(nodes) {
+		capacity = len(nodes)
+	}
+	x := &Anchor{
+		a:       make([]int32, capacity),
+		w:       make([]int32, capacity),
+		l:       make([]int32, capacity),
+		k:       make([]int32, capacity),
+		n:       len(nodes),
+		buckets: make([]string, capacity),
+		nodes:   make(map[string]int, len(nodes)),
+		hash:    &HashRing{config: newConfig(opts)},
+	}
+	for b := range x.a {
+		x.w[b], x.l[b], x.k[b] = int32(b), int32(b), int32(b)
+	}
+	for b := capacity - 1; b >= len(nodes); b-- {
+		x.r = append(x.r, int32(b))
+		x.a[b] = int32(b)
+	}
+	for b, node := range nodes {
+		x.buckets[b] = node
+		x.nodes[node] = b
+	}
+	return x
+}
+
+// clone returns a copy of x to change.
+func (x *Anchor) clone() *Anchor {
+	next := &Anchor{
+		a:       append([]int32{}, x.a...),
+		w:       append([]int32{}, x.w...),
+		l:       append([]int32{}, x.l...),
+		k:       append([]int32{}, x.k...),
+		r:       append([]int32{}, x.r...),
+		n:       x.n,
+		buckets: append([]string{}, x.buckets...),
+		nodes:   make(map[string]int, len(x.nodes)+1),
+		hash:    x.hash,
+	}
+	for node, b := range x.nodes {
+		next.nodes[node] = b
+	}
+	return next
+}
+
+// Size returns the number of nodes of x.
+func (x *Anchor) Size() int {
+	if x == nil {
+		return 0
+	}
+	return x.n
+}
+
+// Capacity returns the number of nodes x has room for, see NewAnchor.
+func (x *Anchor) Capacity() int {
+	if x == nil {
+		return 0
+	}
+	return len(x.a)
+}
+
+// Nodes returns the nodes of x, sorted.
+func (x *Anchor) Nodes() []string {
+	if x == nil {
+		return nil
+	}
+	nodes := make([]string, 0, len(x.nodes))
+	for node := range x.nodes {
+		nodes = append(nodes, node)
+	}
+	return sortedNodes(nodes)
+}
+
+// AddNode adds node on the bucket removed last, and returns the new Anchor.
+// Only keys moving to node move; adding back the nodes removed, in the
+// reverse order, restores the keys of each. x is left as is if node is on it
+// or it is at capacity.
+func (x *Anchor) AddNode(node string) *Anchor {
+	if x == nil {
+		return NewAnchor(1, []string{node})
+	}
+	if _, ok := x.nodes[node]; ok || len(x.r) == 0 {
+		return x
+	}
+	next := x.clone()
+	b := next.r[len(next.r)-1]
+	next.r = next.r[:len(next.r)-1]
+	next.a[b] = 0
+	next.l[next.w[next.n]] = int32(next.n)
+	next.w[next.l[b]] = b
+	next.k[b] = b
+	next.n++
+	next.buckets[b] = node
+	next.nodes[node] = int(b)
+	return next
+}
+
+// RemoveNode removes node, and returns the new Anchor. Only the keys of node
+// move.
+func (x *Anchor) RemoveNode(node string) *Anchor {
+	if x == nil {
+		return x
+	}
+	bucket, ok := x.nodes[node]
+	if !ok {
+		return x
+	}
+	next := x.clone()
+	b := int32(bucket)
+	next.r = append(next.r, b)
+	next.n--
+	next.a[b] = int32(next.n)
+	next.w[next.l[b]] = next.w[next.n]
+	next.l[next.w[next.n]] = next.l[b]
+	next.k[b] = next.w[next.n]
+	next.buckets[b] = ""
+	delete(next.nodes, node)
+	return next
+}
+
+// GetNode returns the node stringKey belongs to. It implements NodeLocator.
+func (x *Anchor) GetNode(stringKey string) (node string, ok bool) {
+	if x == nil || x.n == 0 {
+		return "", false
+	}
+	key := uint64(x.hash.GenKey64(stringKey))
+	b := int32(key % uint64(len(x.a)))
+	for x.a[b] > 0 {
+		h := int32(anchorRehash(key, b) % uint64(x.a[b]))
+		for x.a[h] >= x.a[b] {
+			h = x.k[h]
+		}
+		b = h
+	}
+	return x.buckets[b], true
+}
+
+// anchorRehash returns the hash of key for removed bucket b, by the
+// splitmix64 finalizer.
+func anchorRehash(key uint64, b int32) uint64 {
+	z := key + uint64(b+1)*0x9e3779b97f4a7c15
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return z ^ z>>31
+}
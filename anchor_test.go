@@ -0,0 +1,96 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnchor(t *testing.T) {
+	nodes := make([]string, 10)
+	for i := range nodes {
+		nodes[i] = "node-" + strconv.Itoa(i)
+	}
+	x := NewAnchor(16, nodes)
+	assert.Equal(t, 10, x.Size())
+	assert.Equal(t, sortedNodes(nodes), x.Nodes())
+
+	counts := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		node, ok := x.GetNode(strconv.Itoa(i))
+		assert.True(t, ok)
+		counts[node]++
+	}
+	assert.Len(t, counts, 10)
+	for _, count := range counts {
+		assert.InDelta(t, 1000, count, 150)
+	}
+
+	// Removing nodes moves their keys only; adding them back in reverse
+	// order restores all keys.
+	rings := []*Anchor{x}
+	for _, node := range []string{"node-3", "node-7", "node-0"} {
+		prev := rings[len(rings)-1]
+		next := prev.RemoveNode(node)
+		rings = append(rings, next)
+		for i := 0; i < 2000; i++ {
+			key := strconv.Itoa(i)
+			before, _ := prev.GetNode(key)
+			after, _ := next.GetNode(key)
+			assert.NotEqual(t, node, after)
+			if before != node {
+				assert.Equal(t, before, after)
+			}
+		}
+	}
+	restored := rings[len(rings)-1].AddNode("node-0").AddNode("node-7").AddNode("node-3")
+	for i := 0; i < 2000; i++ {
+		key := strconv.Itoa(i)
+		before, _ := x.GetNode(key)
+		after, _ := restored.GetNode(key)
+		assert.Equal(t, before, after)
+	}
+
+	// Adding a new node takes keys from the others only.
+	added := x.AddNode("node-10")
+	for i := 0; i < 2000; i++ {
+		key := strconv.Itoa(i)
+		before, _ := x.GetNode(key)
+		if after, _ := added.GetNode(key); after != before {
+			assert.Equal(t, "node-10", after)
+		}
+	}
+	assert.Same(t, x, x.AddNode("node-1"))
+	assert.Same(t, x, x.RemoveNode("node-10"))
+}
+
+func TestAnchorCapacity(t *testing.T) {
+	x := NewAnchor(0, []string{"a", "b", "b"})
+	assert.Equal(t, 2, x.Size())
+	assert.Same(t, x, x.AddNode("c"))
+	_, ok := x.RemoveNode("a").RemoveNode("b").GetNode("test")
+	assert.False(t, ok)
+
+	var nilAnchor *Anchor
+	_, ok = nilAnchor.GetNode("test")
+	assert.False(t, ok)
+	node, _ := nilAnchor.AddNode("a").GetNode("test")
+	assert.Equal(t, "a", node)
+}
+
+func BenchmarkAnchor(b *testing.B) {
+	nodes := make([]string, 300)
+	for i := range nodes {
+		nodes[i] = "node-" + strconv.Itoa(i)
+	}
+	x := NewAnchor(1000, nodes)
+	for i := 0; i < 100; i++ {
+		x = x.RemoveNode(nodes[i*3])
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x.GetNode("test")
+	}
+}
@@ -0,0 +1,49 @@
+package hashring
+
+// Ring arithmetic on hash values, wrapping around the end of the keyspace.
+// Positions increase clockwise, and a key belongs to the first point
+// clockwise from it, see HashRing.
+
+// DistanceBetween returns the clockwise distance from a to b: how many
+// positions to move from a to reach b, wrapping around. It is 0 if a == b.
+func DistanceBetween(a, b HashKey) HashKey {
+	return b - a
+}
+
+// Contains reports whether k is on the clockwise arc from rangeStart
+// (inclusive) to rangeEnd (exclusive), wrapping around. An arc with
+// rangeStart == rangeEnd is the whole ring, as owned by a ring's only point.
+//
+// Under BoundaryAfter, the keys a point owns are the arc from the previous
+// point up to itself. Under BoundaryAtOrAfter, where the ends are the other
+// way around, use Contains(start+1, end+1, k).
+func Contains(rangeStart, rangeEnd, k HashKey) bool {
+	return DistanceBetween(rangeStart, k) < DistanceBetween(rangeStart, rangeEnd) || rangeStart == rangeEnd
+}
+
+// Midpoint returns the position halfway along the clockwise arc from a to b,
+// rounding towards a. The arc from a to a is the whole ring.
+func Midpoint(a, b HashKey) HashKey {
+	if a == b {
+		return a + 1<<31
+	}
+	return a + DistanceBetween(a, b)/2
+}
+
+// DistanceBetween64 is DistanceBetween for 64-bit positions, see With64BitKeys.
+func DistanceBetween64(a, b HashKey64) HashKey64 {
+	return b - a
+}
+
+// Contains64 is Contains for 64-bit positions, see With64BitKeys.
+func Contains64(rangeStart, rangeEnd, k HashKey64) bool {
+	return DistanceBetween64(rangeStart, k) < DistanceBetween64(rangeStart, rangeEnd) || rangeStart == rangeEnd
+}
+
+// Midpoint64 is Midpoint for 64-bit positions, see With64BitKeys.
+func Midpoint64(a, b HashKey64) HashKey64 {
+	if a == b {
+		return a + 1<<63
+	}
+	return a + DistanceBetween64(a, b)/2
+}
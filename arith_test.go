@@ -0,0 +1,59 @@
+package hashring
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistanceBetween(t *testing.T) {
+	assert.Equal(t, HashKey(10), DistanceBetween(5, 15))
+	assert.Equal(t, HashKey(0), DistanceBetween(7, 7))
+	assert.Equal(t, HashKey(math.MaxUint32-9), DistanceBetween(15, 5))
+	assert.Equal(t, HashKey(2), DistanceBetween(math.MaxUint32, 1))
+	assert.Equal(t, HashKey64(2), DistanceBetween64(math.MaxUint64, 1))
+}
+
+func TestContains(t *testing.T) {
+	tt := []struct {
+		start, end, k HashKey
+		contains      bool
+	}{
+		{10, 20, 10, true},
+		{10, 20, 15, true},
+		{10, 20, 20, false},
+		{10, 20, 5, false},
+		{math.MaxUint32 - 5, 5, math.MaxUint32, true},
+		{math.MaxUint32 - 5, 5, 0, true},
+		{math.MaxUint32 - 5, 5, 4, true},
+		{math.MaxUint32 - 5, 5, 5, false},
+		{math.MaxUint32 - 5, 5, 100, false},
+		{42, 42, 0, true},
+		{42, 42, 42, true},
+	}
+	for _, tc := range tt {
+		assert.Equal(t, tc.contains, Contains(tc.start, tc.end, tc.k), "%d in [%d, %d)", tc.k, tc.start, tc.end)
+		assert.Equal(t, tc.contains, Contains64(HashKey64(tc.start)<<32, HashKey64(tc.end)<<32, HashKey64(tc.k)<<32))
+	}
+}
+
+func TestContainsOwnedArcs(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"})
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		pos, _ := hashRing.GetNodePos(key)
+		end := HashKey(hashRing.sortedKeys[pos])
+		start := HashKey(hashRing.sortedKeys[(pos+len(hashRing.sortedKeys)-1)%len(hashRing.sortedKeys)])
+		assert.True(t, Contains(start, end, hashRing.GenKey(key)), key)
+	}
+}
+
+func TestMidpoint(t *testing.T) {
+	assert.Equal(t, HashKey(15), Midpoint(10, 20))
+	assert.Equal(t, HashKey(0), Midpoint(math.MaxUint32-4, 6))
+	assert.Equal(t, HashKey(10+1<<31), Midpoint(10, 10))
+	assert.Equal(t, HashKey64(0), Midpoint64(math.MaxUint64-4, 6))
+	assert.Equal(t, HashKey64(1<<63), Midpoint64(0, 0))
+}
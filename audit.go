@@ -0,0 +1,168 @@
+package hashring
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Audit describes every input that places the points of a ring and routes
+// keys to them. Rings with equal audits route every key identically by
+// GetNode, GetNodes, GetNodeUint64 and GetNodeForTenant, however and wherever
+// they were built, see Collisions for points several nodes place. The audit
+// does not cover the loads GetNodeBounded balances, the clients GetReadNode
+// spreads over, or interceptors, which may change any lookup.
+type Audit struct {
+	// Placement is "ketama" with WithKetama, "nginx" with
+	// WithNginxConsistent, "hashring" otherwise.
+	Placement string
+	// Hasher is "md5", "xxhash64", "murmur3", "crc32", or "custom:<hex>" for
+	// another Hasher, with the digest of a fixed probe so different custom
+	// Hashers tell apart.
+	Hasher   string
+	Seed     uint64
+	KeyBits  int
+	Boundary string
+	Replicas int
+	// PointsPerDigest is the number of points of a virtual node, see
+	// WithPointsPerDigest.
+	PointsPerDigest int
+	// Mixer is "custom:<hex>" with WithUint64Mixer, with the mixes of fixed
+	// probes, empty otherwise.
+	Mixer string
+	// BoundedLoad is the factor of WithBoundedLoad, 0 without it.
+	BoundedLoad float64
+	// Nodes are sorted by name.
+	Nodes []AuditNode
+	// Tenants are the weights of WithTenantWeights, sorted by tenant and
+	// node.
+	Tenants []AuditTenantWeight
+}
+
+// AuditNode is a node of an Audit.
+type AuditNode struct {
+	Name         string
+	Weight       int
+	VirtualNodes int
+	// PlacedAs is the name the node places its virtual nodes by if it was
+	// renamed, see RenameNode, empty otherwise.
+	PlacedAs string
+}
+
+// AuditTenantWeight is the weight of a node for a tenant, see
+// WithTenantWeights.
+type AuditTenantWeight struct {
+	Tenant string
+	Node   string
+	Weight int
+}
+
+// auditProbe is hashed to tell custom Hashers apart.
+const auditProbe = "hashring audit probe"
+
+// auditMixerProbes are mixed to tell mixers apart.
+var auditMixerProbes = []uint64{0, 1, 1 << 32, 1<<64 - 1}
+
+// Audit returns the audit of h.
+func (h *HashRing) Audit() Audit {
+	h = h.orEmpty()
+	a := Audit{
+		Placement:       "hashring",
+		Hasher:          "md5",
+		Seed:            h.config.seed,
+		KeyBits:         32,
+		Boundary:        "after",
+		Replicas:        h.replicas,
+		PointsPerDigest: h.pointsPerDigest(),
+	}
+	switch h.config.placement {
+	case placementKetama:
+		a.Placement = "ketama"
+	case placementNginx:
+		a.Placement = "nginx"
+	}
+	switch hasher := h.config.hasher.(type) {
+	case nil:
+	case xxHash64:
+		a.Hasher = "xxhash64"
+	case crc32Hasher:
+		a.Hasher = "crc32"
+	case murmur3:
+		a.Hasher = "murmur3"
+	default:
+		a.Hasher = "custom:" + hex.EncodeToString(hasher.Hash([]byte(auditProbe)))
+	}
+	if h.config.wide {
+		a.KeyBits = 64
+	}
+	if h.config.boundary == BoundaryAtOrAfter {
+		a.Boundary = "at-or-after"
+	}
+	for _, node := range sortedNodes(h.nodes) {
+		a.Nodes = append(a.Nodes, AuditNode{Name: node, Weight: h.weights[node], VirtualNodes: h.factors[node], PlacedAs: h.aliases[node]})
+	}
+	if mix := h.config.uint64Mixer; mix != nil {
+		var mixes []byte
+		for _, k := range auditMixerProbes {
+			mixes = binary.BigEndian.AppendUint32(mixes, uint32(mix(k)))
+		}
+		a.Mixer = "custom:" + hex.EncodeToString(mixes)
+	}
+	if h.config.bounded != nil {
+		a.BoundedLoad = h.config.bounded.c
+	}
+	for tenant, weights := range h.config.tenants {
+		for node, weight := range weights {
+			a.Tenants = append(a.Tenants, AuditTenantWeight{Tenant: tenant, Node: node, Weight: weight})
+		}
+	}
+	sort.Slice(a.Tenants, func(i, j int) bool {
+		if a.Tenants[i].Tenant != a.Tenants[j].Tenant {
+			return a.Tenants[i].Tenant < a.Tenants[j].Tenant
+		}
+		return a.Tenants[i].Node < a.Tenants[j].Node
+	})
+	return a
+}
+
+// String returns the canonical text of a, one "key value" per line. Node
+// and tenant names are quoted, so any name is unambiguous. The mixer, bounded
+// load and tenants only have lines when set, so rings without them keep their
+// fingerprints.
+func (a Audit) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "placement %s\n", a.Placement)
+	fmt.Fprintf(&b, "hasher %s\n", a.Hasher)
+	fmt.Fprintf(&b, "seed %d\n", a.Seed)
+	fmt.Fprintf(&b, "keybits %d\n", a.KeyBits)
+	fmt.Fprintf(&b, "boundary %s\n", a.Boundary)
+	fmt.Fprintf(&b, "replicas %d\n", a.Replicas)
+	fmt.Fprintf(&b, "points-per-digest %d\n", a.PointsPerDigest)
+	for _, node := range a.Nodes {
+		fmt.Fprintf(&b, "node %q %d %d", node.Name, node.Weight, node.VirtualNodes)
+		if node.PlacedAs != "" {
+			fmt.Fprintf(&b, " as %q", node.PlacedAs)
+		}
+		b.WriteByte('\n')
+	}
+	if a.Mixer != "" {
+		fmt.Fprintf(&b, "mixer %s\n", a.Mixer)
+	}
+	if a.BoundedLoad != 0 {
+		fmt.Fprintf(&b, "bounded-load %g\n", a.BoundedLoad)
+	}
+	for _, w := range a.Tenants {
+		fmt.Fprintf(&b, "tenant %q %q %d\n", w.Tenant, w.Node, w.Weight)
+	}
+	return b.String()
+}
+
+// Fingerprint returns the hex SHA-256 of the canonical text of a, short
+// enough to compare across teams or log on startup.
+func (a Audit) Fingerprint() string {
+	sum := sha256.Sum256([]byte(a.String()))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,63 @@
+package hashring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAudit(t *testing.T) {
+	weights := map[string]int{"b": 2, "a": 1}
+	audit := NewWithWeights(weights).Audit()
+	assert.Equal(t, Audit{
+		Placement:       "hashring",
+		Hasher:          "md5",
+		KeyBits:         32,
+		Boundary:        "after",
+		Replicas:        defaultReplicas,
+		PointsPerDigest: 3,
+		Nodes: []AuditNode{
+			{Name: "a", Weight: 1, VirtualNodes: 27},
+			{Name: "b", Weight: 2, VirtualNodes: 54},
+		},
+	}, audit)
+	assert.Equal(t, "placement hashring\nhasher md5\nseed 0\nkeybits 32\nboundary after\nreplicas 40\npoints-per-digest 3\nnode \"a\" 1 27\nnode \"b\" 2 54\n", audit.String())
+	assert.Len(t, audit.Fingerprint(), 64)
+
+	// Built another way, the same ring has the same audit.
+	assert.Equal(t, audit.Fingerprint(), New([]string{"a"}).AddWeightedNode("b", 2).Audit().Fingerprint())
+
+	// Every input changes it.
+	for _, other := range []*HashRing{
+		NewWithWeights(map[string]int{"b": 2, "a": 1, "c": 1}),
+		NewWithWeights(map[string]int{"b": 3, "a": 1}),
+		NewWithWeights(weights, WithSeed(1)),
+		NewWithWeights(weights, With64BitKeys()),
+		NewWithWeights(weights, WithBoundary(BoundaryAtOrAfter)),
+		NewWithWeights(weights, WithHasher(XXHash64)),
+		NewWithWeights(weights, WithHasher(Murmur3)),
+		NewWithWeights(weights, WithHasher(sha256Hasher)),
+		NewWithWeights(weights, WithTargetImbalance(0.01)),
+		NewWithWeights(weights, WithKetama()),
+		NewWithWeights(weights, WithPointsPerDigest(4)),
+		NewWithWeights(weights, WithUint64Mixer(Mix64)),
+		NewWithWeights(weights, WithBoundedLoad(1.25)),
+		NewWithWeights(weights, WithTenantWeights("x", map[string]int{"a": 1})),
+		NewWithWeights(weights, WithTenantWeights("x", map[string]int{"a": 2})),
+	} {
+		assert.NotEqual(t, audit.Fingerprint(), other.Audit().Fingerprint(), other.Audit().String())
+	}
+	assert.Contains(t, NewWithWeights(weights, WithHasher(sha256Hasher)).Audit().Hasher, "custom:")
+
+	tenants := NewWithWeights(weights, WithUint64Mixer(Mix64), WithBoundedLoad(1.25),
+		WithTenantWeights("y", map[string]int{"b": 1}), WithTenantWeights("x", map[string]int{"b": 1, "a": 3}))
+	assert.Equal(t, []AuditTenantWeight{{"x", "a", 3}, {"x", "b", 1}, {"y", "b", 1}}, tenants.Audit().Tenants)
+	assert.Contains(t, tenants.Audit().String(), "bounded-load 1.25\ntenant \"x\" \"a\" 3\n")
+	assert.Equal(t, tenants.Audit().Fingerprint(), NewWithWeights(weights, WithUint64Mixer(Mix64), WithBoundedLoad(1.25)).
+		UpdateTenantWeights("x", map[string]int{"a": 3, "b": 1}).UpdateTenantWeights("y", map[string]int{"b": 1}).Audit().Fingerprint())
+	assert.NotEqual(t, tenants.Audit().Mixer, NewWithWeights(weights, WithUint64Mixer(func(k uint64) HashKey { return HashKey(k) })).Audit().Mixer)
+
+	// Options that do not route keys leave it as is.
+	assert.Equal(t, audit, NewWithWeights(weights, WithName("cache"), WithReadSpread(2)).Audit())
+	assert.Equal(t, Audit{Placement: "hashring", Hasher: "md5", KeyBits: 32, Boundary: "after", PointsPerDigest: 3}, (*HashRing)(nil).Audit())
+}
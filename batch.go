@@ -0,0 +1,100 @@
+package hashring
+
+import "context"
+
+// RingTx gathers the changes of Apply. Its methods behave like the HashRing
+// methods of the same name.
+type RingTx struct {
+	nodes   []string
+	weights map[string]int
+	unit    int
+	changed bool
+}
+
+// Apply calls fn to make several changes, and returns the new HashRing with
+// all of them, placed at once. Making them one after the other would place
+// the ring once per change.
+//
+//	ring = ring.Apply(func(tx *hashring.RingTx) {
+//		tx.RemoveNode("a")
+//		tx.AddWeightedNode("d", 2)
+//		tx.UpdateWeightedNode("b", 3)
+//	})
+//
+// Apply panics on a ring with limits, see ApplyChecked.
+func (h *HashRing) Apply(fn func(tx *RingTx)) *HashRing {
+	h = h.orEmpty()
+	h.config.mustBeUnlimited("Apply")
+	next, _ := h.apply(fn)
+	return next
+}
+
+// apply is Apply, returning a LimitError if the changes take h over its
+// limits.
+func (h *HashRing) apply(fn func(tx *RingTx)) (*HashRing, error) {
+	tx := &RingTx{
+		nodes:   make([]string, len(h.nodes)),
+		weights: make(map[string]int, len(h.weights)),
+		unit:    h.config.weightUnit(),
+	}
+	copy(tx.nodes, h.nodes)
+	for node, weight := range h.weights {
+		tx.weights[node] = weight
+	}
+	fn(tx)
+
+	if !tx.changed {
+		return h, nil
+	}
+	if err := checkLimits(tx.nodes, tx.weights, h.config); err != nil {
+		return h, err
+	}
+	next, _ := newHashRingFrom(context.Background(), h, tx.nodes, tx.weights)
+	return h.derive(next), nil
+}
+
+// AddNode adds node, see HashRing.AddNode.
+func (tx *RingTx) AddNode(node string) {
+	tx.AddWeightedNode(node, tx.unit)
+}
+
+// AddWeightedNode adds node with weight, see HashRing.AddWeightedNode.
+func (tx *RingTx) AddWeightedNode(node string, weight int) {
+	if weight < 0 {
+		return
+	}
+	if _, ok := tx.weights[node]; ok {
+		return
+	}
+	tx.nodes = append(tx.nodes, node)
+	tx.weights[node] = weight
+	tx.changed = true
+}
+
+// UpdateWeightedNode updates node with weight, see HashRing.UpdateWeightedNode.
+func (tx *RingTx) UpdateWeightedNode(node string, weight int) {
+	if weight < 0 {
+		return
+	}
+	if oldWeight, ok := tx.weights[node]; !ok || oldWeight == weight {
+		return
+	}
+	tx.weights[node] = weight
+	tx.changed = true
+}
+
+// RemoveNode removes node, see HashRing.RemoveNode.
+func (tx *RingTx) RemoveNode(node string) {
+	if _, ok := tx.weights[node]; !ok {
+		return
+	}
+	nodes := make([]string, 0, len(tx.nodes))
+	for _, eNode := range tx.nodes {
+		if eNode != node {
+			nodes = append(nodes, eNode)
+		}
+	}
+	tx.nodes = nodes
+	delete(tx.weights, node)
+	tx.changed = true
+}
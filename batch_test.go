@@ -0,0 +1,76 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApply(t *testing.T) {
+	sink := &fakeSink{}
+	hashRing := NewWithWeights(map[string]int{"a": 1, "b": 2, "c": 1}, WithMetrics(sink))
+
+	applied := hashRing.Apply(func(tx *RingTx) {
+		tx.RemoveNode("a")
+		tx.AddWeightedNode("d", 2)
+		tx.UpdateWeightedNode("b", 3)
+		tx.AddNode("e")
+		tx.RemoveNode("e")
+	})
+	assert.Len(t, sink.rebuilds, 2)
+	assert.Equal(t, map[string]int{"b": 3, "c": 1, "d": 2}, applied.weights)
+	expectSameCircle(t, applied, hashRing.RemoveNode("a").AddWeightedNode("d", 2).UpdateWeightedNode("b", 3))
+	expectSameCircle(t, applied, NewWithWeights(map[string]int{"b": 3, "c": 1, "d": 2}))
+	assert.Equal(t, hashRing, applied.Previous())
+
+	// Changes that do nothing leave the ring as is.
+	assert.Same(t, hashRing, hashRing.Apply(func(tx *RingTx) {
+		tx.AddNode("a")
+		tx.AddWeightedNode("x", -1)
+		tx.UpdateWeightedNode("b", 2)
+		tx.UpdateWeightedNode("x", 2)
+		tx.RemoveNode("x")
+	}))
+
+	var empty *HashRing
+	expectSameCircle(t, empty.Apply(func(tx *RingTx) { tx.AddNode("a") }), New([]string{"a"}))
+}
+
+func TestApplyLimits(t *testing.T) {
+	hashRing, err := NewChecked([]string{"a", "b"}, WithMaxNodes(3))
+	assert.NoError(t, err)
+	same, err := hashRing.ApplyChecked(func(tx *RingTx) {
+		tx.AddNode("c")
+		tx.AddNode("d")
+	})
+	var limitErr *LimitError
+	assert.ErrorAs(t, err, &limitErr)
+	assert.Same(t, hashRing, same)
+
+	// Only the result counts against the limits.
+	applied, err := hashRing.ApplyChecked(func(tx *RingTx) {
+		tx.AddNode("c")
+		tx.AddNode("d")
+		tx.RemoveNode("a")
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "c", "d"}, applied.nodes)
+}
+
+func BenchmarkApply(b *testing.B) {
+	nodes := make([]string, 300)
+	for i := range nodes {
+		nodes[i] = "node-" + strconv.Itoa(i)
+	}
+	hashRing := New(nodes)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hashRing.Apply(func(tx *RingTx) {
+			for _, node := range nodes[:20] {
+				tx.RemoveNode(node)
+				tx.AddNode(node + "-new")
+			}
+		})
+	}
+}
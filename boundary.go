@@ -0,0 +1,22 @@
+package hashring
+
+// Boundary selects the point a key belongs to when the key's HashKey is equal
+// to a point's. Keys after the last point wrap around to the first point
+// under either boundary.
+type Boundary int
+
+const (
+	// BoundaryAfter assigns a key to the first point strictly greater than its
+	// HashKey, as hash_ring does. It is the default.
+	BoundaryAfter Boundary = iota
+	// BoundaryAtOrAfter assigns a key to the first point greater than or equal
+	// to its HashKey, as groupcache's consistenthash does.
+	BoundaryAtOrAfter
+)
+
+// WithBoundary sets the boundary semantics of lookups, BoundaryAfter by default.
+func WithBoundary(b Boundary) Option {
+	return func(c *config) {
+		c.boundary = b
+	}
+}
@@ -0,0 +1,46 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoundary(t *testing.T) {
+	identity := WithUint64Mixer(func(k uint64) HashKey { return HashKey(k) })
+	after := New([]string{"a", "b", "c"}, identity)
+	atOrAfter := New([]string{"a", "b", "c"}, identity, WithBoundary(BoundaryAtOrAfter))
+
+	for i, point := range after.sortedKeys {
+		next := after.ownerAt((i + 1) % len(after.sortedKeys))
+
+		node, _ := after.GetNodeUint64(uint64(point))
+		assert.Equal(t, next, node)
+		node, _ = atOrAfter.GetNodeUint64(uint64(point))
+		assert.Equal(t, after.ownerAt(i), node)
+	}
+
+	// Keys that are not on a point are unaffected.
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		if _, onPoint := after.pointOwner(after.GenKey64(key)); onPoint {
+			continue
+		}
+		expected, _ := after.GetNode(key)
+		node, _ := atOrAfter.GetNode(key)
+		assert.Equal(t, expected, node)
+	}
+
+	// Past the last point both wrap to the first.
+	last := after.sortedKeys[len(after.sortedKeys)-1]
+	node, _ := atOrAfter.GetNodeUint64(uint64(last) + 1)
+	assert.Equal(t, after.ownerAt(0), node)
+
+	// The option is kept by derived rings.
+	added := atOrAfter.AddNode("d")
+	node, _ = added.GetNodeUint64(uint64(added.sortedKeys[0]))
+	assert.Equal(t, added.ownerAt(0), node)
+
+	assert.InDelta(t, 0, Diff(after, atOrAfter).Churn, 1e-6)
+}
@@ -0,0 +1,104 @@
+package hashring
+
+import (
+	"math"
+	"sync"
+)
+
+// WithBoundedLoad enables GetNodeBounded: no node is given more than c times
+// its share of the keys in use, c > 1, e.g. 1.25. The lower c, the more keys
+// skip their node to the next one on the ring when it is full.
+//
+// Rings derived by AddNode, RemoveNode and friends share the loads.
+func WithBoundedLoad(c float64) Option {
+	return func(cfg *config) {
+		cfg.bounded = &loadBound{c: c, loads: make(map[string]int)}
+	}
+}
+
+// loadBound gathers the loads of WithBoundedLoad.
+type loadBound struct {
+	c float64
+
+	mu    sync.Mutex
+	loads map[string]int
+	total int
+}
+
+// GetNodeBounded returns the node stringKey belongs to, like GetNode,
+// unless that node is at its capacity: then the next node on the ring below
+// its capacity. The capacity of a node is its share of the weight of the
+// ring times the c of WithBoundedLoad times the number of keys in use, that
+// one included, rounded up, so a node is always below it.
+//
+// The node returned counts stringKey in use until ReleaseNode is called with
+// it, e.g. when the request or connection assigned to it is done.
+//
+// It returns false if h is empty or was not created WithBoundedLoad.
+func (h *HashRing) GetNodeBounded(stringKey string) (node string, ok bool) {
+	if h == nil || h.config.bounded == nil {
+		return "", false
+	}
+	pos, ok := h.GetNodePos(stringKey)
+	if !ok {
+		return "", false
+	}
+
+	b := h.config.bounded
+	w := h.weighting()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	limit := b.c * float64(b.total+1) / float64(w.total)
+	seen := make(map[string]bool)
+	skips := len(h.skips) == len(h.sortedKeys)
+	for i, step := pos, 1; i < pos+len(h.sortedKeys); i += step {
+		j := i % len(h.sortedKeys)
+		if skips {
+			step = int(h.skips[j])
+		}
+		n := h.ownerAt(j)
+		if seen[n] {
+			continue
+		}
+		seen[n] = true
+		if float64(b.loads[n]+1) <= math.Ceil(limit*float64(h.weights[n])) {
+			b.loads[n]++
+			b.total++
+			return n, true
+		}
+	}
+	return "", false
+}
+
+// ReleaseNode ends a use of node returned by GetNodeBounded.
+func (h *HashRing) ReleaseNode(node string) {
+	if h == nil || h.config.bounded == nil {
+		return
+	}
+	b := h.config.bounded
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.loads[node] == 0 {
+		return
+	}
+	b.loads[node]--
+	b.total--
+	if b.loads[node] == 0 {
+		delete(b.loads, node)
+	}
+}
+
+// Loads returns the number of uses of each node in use, see GetNodeBounded.
+func (h *HashRing) Loads() map[string]int {
+	loads := make(map[string]int)
+	if h == nil || h.config.bounded == nil {
+		return loads
+	}
+	b := h.config.bounded
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for node, load := range b.loads {
+		loads[node] = load
+	}
+	return loads
+}
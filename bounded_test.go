@@ -0,0 +1,69 @@
+package hashring
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNodeBounded(t *testing.T) {
+	hashRing := NewWithWeights(map[string]int{"a": 1, "b": 1, "c": 2}, WithBoundedLoad(1.25))
+
+	// Without load, keys go where GetNode puts them.
+	node, ok := hashRing.GetNodeBounded("test")
+	assert.True(t, ok)
+	expectNode(t, hashRing, "test", node)
+	hashRing.ReleaseNode(node)
+	assert.Empty(t, hashRing.Loads())
+
+	// A hot key fills its node, then the next ones.
+	for i := 1; i <= 100; i++ {
+		_, ok := hashRing.GetNodeBounded("hot")
+		assert.True(t, ok)
+		for n, load := range hashRing.Loads() {
+			capacity := math.Ceil(1.25 * float64(i) * float64(hashRing.weights[n]) / 4)
+			assert.LessOrEqual(t, float64(load), capacity, n)
+		}
+	}
+	loads := hashRing.Loads()
+	assert.Equal(t, 100, loads["a"]+loads["b"]+loads["c"])
+	owner, _ := hashRing.GetNode("hot")
+	assert.InDelta(t, 1.25*100*float64(hashRing.weights[owner])/4, loads[owner], 1)
+
+	// Derived rings share the loads.
+	derived := hashRing.AddNode("d")
+	assert.Equal(t, loads, derived.Loads())
+	for n, load := range loads {
+		for i := 0; i < load; i++ {
+			derived.ReleaseNode(n)
+		}
+	}
+	assert.Empty(t, hashRing.Loads())
+	hashRing.ReleaseNode("a")
+	assert.Empty(t, hashRing.Loads())
+}
+
+func TestGetNodeBoundedSpread(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c", "d", "e"}, WithBoundedLoad(1.1))
+	for i := 0; i < 1000; i++ {
+		_, ok := hashRing.GetNodeBounded(strconv.Itoa(i))
+		assert.True(t, ok)
+	}
+	for n, load := range hashRing.Loads() {
+		assert.LessOrEqual(t, load, 220, n)
+	}
+}
+
+func TestGetNodeBoundedDisabled(t *testing.T) {
+	_, ok := New([]string{"a"}).GetNodeBounded("test")
+	assert.False(t, ok)
+	_, ok = New(nil, WithBoundedLoad(1.25)).GetNodeBounded("test")
+	assert.False(t, ok)
+	var nilRing *HashRing
+	_, ok = nilRing.GetNodeBounded("test")
+	assert.False(t, ok)
+	nilRing.ReleaseNode("a")
+	assert.Empty(t, nilRing.Loads())
+}
@@ -0,0 +1,43 @@
+package hashring
+
+// GetNodeByKey returns the node that a key of HashKey key belongs to, see
+// GenKey, without hashing, e.g. when the digest of a request is computed once
+// for several lookups. With 64-bit keys, key is taken as the upper 32 bits of
+// the position and the lower 32 are zero, so keys within a point's lower half
+// may be routed to its neighbour; use GetNodeByKey64 with GenKey64 instead.
+func (h *HashRing) GetNodeByKey(key HashKey) (node string, ok bool) {
+	return h.GetNodeByKey64(h.widen(key))
+}
+
+// GetNodesByKey returns size nodes for a key of HashKey key, see
+// GetNodeByKey and GetNodes.
+func (h *HashRing) GetNodesByKey(key HashKey, size int) (nodes []string, ok bool) {
+	return h.GetNodesByKey64(h.widen(key), size)
+}
+
+// GetNodeByKey64 returns the node that the position key belongs to, see
+// GenKey64. It routes like GetNode with or without 64-bit keys.
+func (h *HashRing) GetNodeByKey64(key HashKey64) (node string, ok bool) {
+	if h.single() {
+		return h.lookupAt(0), true
+	}
+	pos, ok := h.keyPos(key)
+	if !ok {
+		return "", false
+	}
+	return h.lookupAt(pos), true
+}
+
+// GetNodesByKey64 returns size nodes for the position key, see
+// GetNodeByKey64 and GetNodes.
+func (h *HashRing) GetNodesByKey64(key HashKey64, size int) (nodes []string, ok bool) {
+	if size > h.Size() || size <= 0 {
+		return nil, false
+	}
+
+	pos, ok := h.keyPos(key)
+	if !ok {
+		return nil, false
+	}
+	return h.nodesAt(pos, size)
+}
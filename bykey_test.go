@@ -0,0 +1,52 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNodeByKey(t *testing.T) {
+	hashRing := NewWithWeights(map[string]int{"a": 1, "b": 2, "c": 1})
+	for i := 0; i < 300; i++ {
+		key := strconv.Itoa(i)
+		node, _ := hashRing.GetNode(key)
+		byKey, ok := hashRing.GetNodeByKey(hashRing.GenKey(key))
+		assert.True(t, ok)
+		assert.Equal(t, node, byKey, key)
+		nodes, _ := hashRing.GetNodes(key, 2)
+		byKeys, ok := hashRing.GetNodesByKey(hashRing.GenKey(key), 2)
+		assert.True(t, ok)
+		assert.Equal(t, nodes, byKeys, key)
+	}
+	_, ok := hashRing.GetNodesByKey(0, 4)
+	assert.False(t, ok)
+	_, ok = New(nil).GetNodeByKey(0)
+	assert.False(t, ok)
+	node, ok := New([]string{"a"}).GetNodeByKey(0)
+	assert.True(t, ok)
+	assert.Equal(t, "a", node)
+
+	allocs := testing.AllocsPerRun(100, func() { hashRing.GetNodeByKey(42) })
+	assert.Equal(t, 0.0, allocs)
+}
+
+func TestGetNodeByKey64(t *testing.T) {
+	for _, opts := range [][]Option{nil, {With64BitKeys()}} {
+		hashRing := NewWithWeights(map[string]int{"a": 1, "b": 2, "c": 1}, opts...)
+		for i := 0; i < 300; i++ {
+			key := strconv.Itoa(i)
+			node, _ := hashRing.GetNode(key)
+			byKey, ok := hashRing.GetNodeByKey64(hashRing.GenKey64(key))
+			assert.True(t, ok)
+			assert.Equal(t, node, byKey, key)
+			nodes, _ := hashRing.GetNodes(key, 2)
+			byKeys, ok := hashRing.GetNodesByKey64(hashRing.GenKey64(key), 2)
+			assert.True(t, ok)
+			assert.Equal(t, nodes, byKeys, key)
+		}
+	}
+	_, ok := New(nil, With64BitKeys()).GetNodeByKey64(0)
+	assert.False(t, ok)
+}
@@ -0,0 +1,44 @@
+package hashring
+
+// GenKeyBytes generates HashKey of key, like GenKey(string(key)) without
+// the copy.
+func (h *HashRing) GenKeyBytes(key []byte) HashKey {
+	return h.narrow(h.keyBytes(key))
+}
+
+// keyBytes returns the position of key on ring.
+func (h *HashRing) keyBytes(key []byte) HashKey64 {
+	if h != nil && h.config.hasher != nil {
+		if _, ok := h.config.hasher.(xxHash64); ok {
+			return h.sumPosition(xxh64(key))
+		}
+		return h.position(h.config.hasher.Hash(key))
+	}
+	bKey := hashDigestBytes(key)
+	return h.position(bKey[:])
+}
+
+// GetNodeBytes returns the node that key belongs to, like GetNode.
+func (h *HashRing) GetNodeBytes(key []byte) (node string, ok bool) {
+	if h.single() {
+		return h.lookupAt(0), true
+	}
+	pos, ok := h.keyPos(h.keyBytes(key))
+	if !ok {
+		return "", false
+	}
+	return h.lookupAt(pos), true
+}
+
+// GetNodesBytes returns size nodes for key, see GetNodes.
+func (h *HashRing) GetNodesBytes(key []byte, size int) (nodes []string, ok bool) {
+	if size > h.Size() || size <= 0 {
+		return nil, false
+	}
+
+	pos, ok := h.keyPos(h.keyBytes(key))
+	if !ok {
+		return nil, false
+	}
+	return h.nodesAt(pos, size)
+}
@@ -0,0 +1,45 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNodeBytes(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithHasher(XXHash64)}, {WithHasher(sha256Hasher)}, {With64BitKeys()}} {
+		hashRing := New([]string{"a", "b", "c", "d"}, opts...)
+		for i := 0; i < 100; i++ {
+			key := "key-" + strconv.Itoa(i)
+			assert.Equal(t, hashRing.GenKey(key), hashRing.GenKeyBytes([]byte(key)))
+
+			expected, _ := hashRing.GetNode(key)
+			node, ok := hashRing.GetNodeBytes([]byte(key))
+			assert.True(t, ok)
+			assert.Equal(t, expected, node)
+
+			nodes, _ := hashRing.GetNodes(key, 3)
+			bytesNodes, ok := hashRing.GetNodesBytes([]byte(key), 3)
+			assert.True(t, ok)
+			assert.Equal(t, nodes, bytesNodes)
+		}
+	}
+
+	hashRing := New([]string{"a"})
+	_, ok := hashRing.GetNodesBytes([]byte("test"), 2)
+	assert.False(t, ok)
+	_, ok = New([]string{}).GetNodeBytes([]byte("test"))
+	assert.False(t, ok)
+	_, ok = (*HashRing)(nil).GetNodeBytes([]byte("test"))
+	assert.False(t, ok)
+}
+
+func TestGetNodeBytesAllocs(t *testing.T) {
+	key := []byte("some-binary-key")
+	for _, opts := range [][]Option{nil, {WithHasher(XXHash64)}} {
+		hashRing := New([]string{"a", "b", "c"}, opts...)
+		allocs := testing.AllocsPerRun(100, func() { hashRing.GetNodeBytes(key) })
+		assert.Equal(t, 0.0, allocs)
+	}
+}
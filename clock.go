@@ -0,0 +1,104 @@
+package hashring
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the source of time of the features that wait or expire, such as
+// RemoveNodeSoft and Migrator, so that tests can simulate time with a
+// ManualClock instead of sleeping. Lookup latencies and rebuild durations
+// are always measured by the system clock.
+type Clock interface {
+	Now() time.Time
+	// After returns a channel receiving the time once d has elapsed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is the Clock of the time package, used by default.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// WithClock sets the clock of the ring, SystemClock by default, see Clock.
+func WithClock(clock Clock) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
+
+// clockOrSystem returns the clock of c, SystemClock by default.
+func (c config) clockOrSystem() Clock {
+	if c.clock != nil {
+		return c.clock
+	}
+	return SystemClock
+}
+
+// ManualClock is a Clock whose time only moves by Advance, for tests.
+type ManualClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []manualWaiter
+}
+
+type manualWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewManualClock creates a ManualClock at now.
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+// Now implements Clock.
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After implements Clock. The channel receives once Advance reaches d from
+// now, right away if d is not positive.
+func (c *ManualClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, manualWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the time of c forward by d, firing the channels of After due
+// by then, earliest first.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	sort.SliceStable(c.waiters, func(i, j int) bool { return c.waiters[i].at.Before(c.waiters[j].at) })
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		w.ch <- w.at
+	}
+	c.waiters = waiters
+}
+
+// Waiters returns the number of channels of After not yet fired, e.g. to
+// wait until the code under test is waiting before calling Advance.
+func (c *ManualClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
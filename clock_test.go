@@ -0,0 +1,75 @@
+package hashring
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManualClock(t *testing.T) {
+	start := time.Unix(100, 0)
+	clock := NewManualClock(start)
+	assert.Equal(t, start, clock.Now())
+
+	now := clock.After(0)
+	later := clock.After(2 * time.Second)
+	soon := clock.After(time.Second)
+	assert.Equal(t, start, <-now)
+	assert.Equal(t, 2, clock.Waiters())
+
+	clock.Advance(time.Second)
+	assert.Equal(t, start.Add(time.Second), <-soon)
+	assert.Len(t, later, 0)
+	clock.Advance(5 * time.Second)
+	assert.Equal(t, start.Add(2*time.Second), <-later)
+	assert.Equal(t, start.Add(6*time.Second), clock.Now())
+	assert.Equal(t, 0, clock.Waiters())
+}
+
+func TestWithClock(t *testing.T) {
+	assert.Equal(t, SystemClock, New([]string{"a"}).config.clockOrSystem())
+
+	clock := NewManualClock(time.Unix(0, 0))
+	hashRing := New([]string{"a", "b"}, WithClock(clock))
+	assert.Equal(t, clock.Now(), NewDashboard(hashRing).now())
+	assert.Equal(t, Clock(clock), hashRing.AddNode("c").config.clockOrSystem())
+}
+
+// advanceWhileWaiting advances clock by step whenever something waits on it,
+// until done is closed.
+func advanceWhileWaiting(clock *ManualClock, step time.Duration, done <-chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		if clock.Waiters() > 0 {
+			clock.Advance(step)
+		} else {
+			runtime.Gosched()
+		}
+	}
+}
+
+func TestMigratorClock(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := NewManualClock(start)
+	var recorder moveRecorder
+	m := &Migrator{From: New([]string{"a"}), To: New([]string{"b"}), Move: recorder.move,
+		Rate: 10, Clock: clock}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		checkpoint, err := m.Run(context.Background(), keyStream(5), Checkpoint{})
+		assert.NoError(t, err)
+		assert.Equal(t, 5, checkpoint.Moved)
+	}()
+	advanceWhileWaiting(clock, 100*time.Millisecond, done)
+	// The first key goes right away, the others one interval apart.
+	assert.Equal(t, 400*time.Millisecond, clock.Now().Sub(start))
+}
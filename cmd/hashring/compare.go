@@ -0,0 +1,268 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/liuchang1437/hashring"
+)
+
+// backend is a way of mapping keys to nodes compared by compare-algos.
+type backend struct {
+	name  string
+	build func(weights map[string]int) hashring.NodeLocator
+	// change derives the locator of changed from l, built of weights, the
+	// way the backend is meant to change, and false if it cannot make the
+	// change. If nil, changed is built anew.
+	change func(l hashring.NodeLocator, weights, changed map[string]int) (hashring.NodeLocator, bool)
+}
+
+// backends are the backends compare-algos compares, in the order reported.
+var backends = []backend{
+	{"ring", func(weights map[string]int) hashring.NodeLocator {
+		return hashring.NewWithWeights(weights)
+	}, nil},
+	{"ring-xxhash", func(weights map[string]int) hashring.NodeLocator {
+		return hashring.NewWithWeights(weights, hashring.WithHasher(hashring.XXHash64))
+	}, nil},
+	{"ring-64bit", func(weights map[string]int) hashring.NodeLocator {
+		return hashring.NewWithWeights(weights, hashring.With64BitKeys())
+	}, nil},
+	{"rendezvous", func(weights map[string]int) hashring.NodeLocator {
+		return hashring.NewRendezvousWithWeights(weights)
+	}, nil},
+	{"maglev", func(weights map[string]int) hashring.NodeLocator {
+		return hashring.NewMaglevWithWeights(weights)
+	}, nil},
+	{"anchor", func(weights map[string]int) hashring.NodeLocator {
+		// Anchor has no weights; it has room for twice the nodes.
+		return hashring.NewAnchor(2*len(weights), sortedKeys(weights))
+	}, changeAnchor},
+	{"jump", func(weights map[string]int) hashring.NodeLocator {
+		// Jump has no weights; the nodes are its buckets in name order.
+		return hashring.NewJump(sortedKeys(weights))
+	}, changeJump},
+}
+
+// changeAnchor removes the nodes of l missing from changed and adds the new
+// ones, rather than building an Anchor anew, whose buckets would be assigned
+// afresh. It cannot add more nodes than the capacity of l allows.
+func changeAnchor(l hashring.NodeLocator, weights, changed map[string]int) (hashring.NodeLocator, bool) {
+	anchor := l.(*hashring.Anchor)
+	for _, node := range sortedKeys(weights) {
+		if _, ok := changed[node]; !ok {
+			anchor = anchor.RemoveNode(node)
+		}
+	}
+	for _, node := range sortedKeys(changed) {
+		if _, ok := weights[node]; !ok {
+			anchor = anchor.AddNode(node)
+		}
+	}
+	return anchor, anchor.Size() == len(changed)
+}
+
+// changeJump removes the last buckets of l and appends the added nodes, the
+// only changes Jump makes without renumbering buckets.
+func changeJump(l hashring.NodeLocator, weights, changed map[string]int) (hashring.NodeLocator, bool) {
+	jump := l.(*hashring.Jump)
+	nodes := jump.Nodes()
+	kept := len(nodes)
+	for kept > 0 {
+		if _, ok := changed[nodes[kept-1]]; ok {
+			break
+		}
+		kept--
+	}
+	for _, node := range nodes[:kept] {
+		if _, ok := changed[node]; !ok {
+			return nil, false
+		}
+	}
+	for range nodes[kept:] {
+		jump = jump.RemoveLastNode()
+	}
+	for _, node := range sortedKeys(changed) {
+		if _, ok := weights[node]; !ok {
+			jump = jump.AddNode(node)
+		}
+	}
+	return jump, true
+}
+
+// comparison is what compare-algos measures of a backend.
+type comparison struct {
+	nsPerLookup float64
+	memory      uint64
+	imbalance   float64
+	churn       float64
+	comparable  bool // whether the backend could make the change.
+}
+
+func compareAlgos(args []string, stdout io.Writer) error {
+	const usage = "usage: hashring compare-algos [-keys n] topology.json [changed.json]"
+	flags := flag.NewFlagSet("compare-algos", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	keys := flags.Int("keys", 100000, "number of sample keys")
+	if err := flags.Parse(args); err != nil || flags.NArg() < 1 || flags.NArg() > 2 || *keys <= 0 {
+		return fmt.Errorf(usage)
+	}
+
+	weights, err := readTopology(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if len(weights) == 0 {
+		return fmt.Errorf("%s: no nodes", flags.Arg(0))
+	}
+	var changed map[string]int
+	var change string
+	if flags.NArg() == 2 {
+		if changed, err = readTopology(flags.Arg(1)); err != nil {
+			return err
+		}
+		change = flags.Arg(0) + " -> " + flags.Arg(1)
+	} else {
+		// Without a changed topology, the change is removing the first node.
+		removed := sortedKeys(weights)[0]
+		changed = make(map[string]int, len(weights))
+		for node, weight := range weights {
+			if node != removed {
+				changed[node] = weight
+			}
+		}
+		change = "remove " + removed
+	}
+
+	sample := make([]string, *keys)
+	for i := range sample {
+		sample[i] = "key-" + strconv.Itoa(i)
+	}
+
+	fmt.Fprintf(stdout, "topology: %d nodes, %d keys\n", len(weights), len(sample))
+	fmt.Fprintf(stdout, "change: %s, minimal churn %.2f%%\n", change, minimalChurn(weights, changed)*100)
+	fmt.Fprintln(stdout)
+	fmt.Fprintf(stdout, "%-16s %10s %10s %10s %8s\n", "backend", "ns/lookup", "memory", "imbalance", "churn")
+	for _, b := range backends {
+		c := compare(b, weights, changed, sample)
+		churn := "not comparable"
+		if c.comparable {
+			churn = fmt.Sprintf("%7.2f%%", c.churn*100)
+		}
+		fmt.Fprintf(stdout, "%-16s %10.1f %10s %9.2f%% %s\n", b.name, c.nsPerLookup, formatBytes(c.memory), c.imbalance*100, churn)
+	}
+	return nil
+}
+
+// compare measures backend b on weights, with the churn of changing to changed.
+func compare(b backend, weights, changed map[string]int, sample []string) comparison {
+	var c comparison
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	locator := b.build(weights)
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	if after.HeapAlloc > before.HeapAlloc {
+		c.memory = after.HeapAlloc - before.HeapAlloc
+	}
+
+	owners := make([]string, len(sample))
+	start := time.Now()
+	for i, key := range sample {
+		owners[i], _ = locator.GetNode(key)
+	}
+	c.nsPerLookup = float64(time.Since(start).Nanoseconds()) / float64(len(sample))
+
+	counts := make(map[string]int, len(weights))
+	for _, node := range owners {
+		counts[node]++
+	}
+	c.imbalance = imbalance(weights, counts, len(sample))
+
+	next, ok := b.build(changed), true
+	if b.change != nil {
+		next, ok = b.change(locator, weights, changed)
+	}
+	if !ok {
+		runtime.KeepAlive(locator)
+		return c
+	}
+	c.comparable = true
+	moved := 0
+	for i, key := range sample {
+		if node, _ := next.GetNode(key); node != owners[i] {
+			moved++
+		}
+	}
+	c.churn = float64(moved) / float64(len(sample))
+	runtime.KeepAlive(locator)
+	return c
+}
+
+// imbalance returns the standard deviation of the nodes' relative load error
+// over the sample, like HashRing.Imbalance does for the keyspace.
+func imbalance(weights, counts map[string]int, keys int) float64 {
+	total := 0
+	for _, weight := range weights {
+		total += weight
+	}
+	sum := 0.0
+	for node, weight := range weights {
+		expected := float64(weight) / float64(total)
+		deviation := float64(counts[node])/float64(keys)/expected - 1
+		sum += deviation * deviation
+	}
+	return math.Sqrt(sum / float64(len(weights)))
+}
+
+// minimalChurn returns the share of keys that must change owner between the
+// weights old and new: the weight share lost by the nodes that shrink.
+func minimalChurn(old, new map[string]int) float64 {
+	oldShares, newShares := shares(old), shares(new)
+	churn := 0.0
+	for node, share := range oldShares {
+		if lost := share - newShares[node]; lost > 0 {
+			churn += lost
+		}
+	}
+	return churn
+}
+
+func shares(weights map[string]int) map[string]float64 {
+	total := 0
+	for _, weight := range weights {
+		total += weight
+	}
+	shares := make(map[string]float64, len(weights))
+	for node, weight := range weights {
+		shares[node] = float64(weight) / float64(total)
+	}
+	return shares
+}
+
+func sortedKeys(weights map[string]int) []string {
+	nodes := make([]string, 0, len(weights))
+	for node := range weights {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+func formatBytes(n uint64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
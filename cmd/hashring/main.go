@@ -0,0 +1,141 @@
+// Command hashring inspects ring topologies.
+//
+// A topology is a JSON file mapping nodes to weights, {"a": 1, "b": 2}, or a
+// JSON list of nodes of weight 1, ["a", "b"].
+//
+// Usage:
+//
+//	hashring diff old.json new.json
+//	hashring compare-algos [-keys n] topology.json [changed.json]
+//	hashring stub [-go package] topology.json
+//
+// diff prints the added and removed nodes, weight changes, the keyspace share
+// of every node before and after, and the share of keys that change owner.
+//
+// compare-algos builds the topology with each backend and prints, side by
+// side, the lookup latency, the memory held, the imbalance of a sample of
+// keys, and the share of them that change owner when the topology changes to
+// changed.json, or loses its first node.
+//
+// stub prints the lookup table of the topology as JSON, see
+// hashring.LookupTable, or with -go, Go source of the package routing keys
+// by it, for clients that cannot depend on this package.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/liuchang1437/hashring"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "hashring:", err)
+		os.Exit(2)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: hashring diff old.json new.json\n       hashring compare-algos [-keys n] topology.json [changed.json]\n       hashring stub [-go package] topology.json")
+	}
+
+	switch args[0] {
+	case "diff":
+		return diff(args[1:], stdout)
+	case "compare-algos":
+		return compareAlgos(args[1:], stdout)
+	case "stub":
+		return stub(args[1:], stdout)
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+func diff(args []string, stdout io.Writer) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: hashring diff old.json new.json")
+	}
+	old, err := readTopology(args[0])
+	if err != nil {
+		return err
+	}
+	new, err := readTopology(args[1])
+	if err != nil {
+		return err
+	}
+
+	d := hashring.Diff(hashring.NewWithWeights(old), hashring.NewWithWeights(new))
+
+	fmt.Fprintf(stdout, "nodes: %d -> %d\n", len(old), len(new))
+	for _, node := range d.Added {
+		fmt.Fprintf(stdout, "added:   %s (weight %d)\n", node, new[node])
+	}
+	for _, node := range d.Removed {
+		fmt.Fprintf(stdout, "removed: %s (weight %d)\n", node, old[node])
+	}
+	for _, c := range d.WeightChanges {
+		fmt.Fprintf(stdout, "weight:  %s %d -> %d\n", c.Node, c.Old, c.New)
+	}
+
+	fmt.Fprintln(stdout)
+	fmt.Fprintf(stdout, "%-24s %8s %8s %8s\n", "ownership", "old", "new", "delta")
+	for _, o := range d.Ownership {
+		fmt.Fprintf(stdout, "%-24s %7.2f%% %7.2f%% %+7.2f%%\n", o.Node, o.Old*100, o.New*100, (o.New-o.Old)*100)
+	}
+
+	fmt.Fprintln(stdout)
+	fmt.Fprintf(stdout, "churn: %.2f%% of keys change owner\n", d.Churn*100)
+	return nil
+}
+
+func stub(args []string, stdout io.Writer) error {
+	const usage = "usage: hashring stub [-go package] topology.json"
+	flags := flag.NewFlagSet("stub", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	pkg := flags.String("go", "", "package of the Go source to print instead of JSON")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return fmt.Errorf(usage)
+	}
+	weights, err := readTopology(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	ring := hashring.NewWithWeights(weights)
+	if *pkg != "" {
+		return ring.WriteGoStub(stdout, *pkg)
+	}
+	data, err := json.Marshal(ring.LookupTable())
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(stdout, "%s\n", data)
+	return err
+}
+
+// readTopology reads a topology file, see the package documentation.
+func readTopology(path string) (map[string]int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var weights map[string]int
+	if err := json.Unmarshal(data, &weights); err == nil {
+		return weights, nil
+	}
+	var nodes []string
+	if err := json.Unmarshal(data, &nodes); err != nil {
+		return nil, fmt.Errorf("%s: not a topology, expected {\"node\": weight, ...} or [\"node\", ...]", path)
+	}
+	weights = make(map[string]int, len(nodes))
+	for _, node := range nodes {
+		weights[node] = 1
+	}
+	return weights, nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/liuchang1437/hashring"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTopology(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDiff(t *testing.T) {
+	dir := t.TempDir()
+	old := writeTopology(t, dir, "old.json", `["a", "b", "c"]`)
+	new := writeTopology(t, dir, "new.json", `{"a": 1, "b": 2, "d": 1}`)
+
+	var out strings.Builder
+	assert.NoError(t, run([]string{"diff", old, new}, &out))
+
+	report := out.String()
+	assert.Contains(t, report, "nodes: 3 -> 3\n")
+	assert.Contains(t, report, "added:   d (weight 1)\n")
+	assert.Contains(t, report, "removed: c (weight 1)\n")
+	assert.Contains(t, report, "weight:  b 1 -> 2\n")
+	assert.Contains(t, report, "\nc ")
+	assert.Regexp(t, `churn: \d+\.\d\d% of keys change owner`, report)
+}
+
+func TestDiffSame(t *testing.T) {
+	dir := t.TempDir()
+	old := writeTopology(t, dir, "old.json", `["a", "b"]`)
+
+	var out strings.Builder
+	assert.NoError(t, run([]string{"diff", old, old}, &out))
+	assert.Contains(t, out.String(), "churn: 0.00% of keys change owner")
+}
+
+func TestRunErrors(t *testing.T) {
+	dir := t.TempDir()
+	bad := writeTopology(t, dir, "bad.json", `{"a": "x"}`)
+
+	var out strings.Builder
+	assert.Error(t, run(nil, &out))
+	assert.Error(t, run([]string{"frobnicate"}, &out))
+	assert.Error(t, run([]string{"diff", bad}, &out))
+	assert.Error(t, run([]string{"diff", bad, bad}, &out))
+	assert.Error(t, run([]string{"diff", filepath.Join(dir, "missing.json"), bad}, &out))
+}
+
+func TestCompareAlgos(t *testing.T) {
+	dir := t.TempDir()
+	topology := writeTopology(t, dir, "topology.json", `{"a": 1, "b": 1, "c": 1, "d": 1}`)
+	changed := writeTopology(t, dir, "changed.json", `{"a": 1, "b": 1, "c": 1, "d": 1, "e": 1}`)
+
+	var out strings.Builder
+	assert.NoError(t, run([]string{"compare-algos", "-keys", "10000", topology}, &out))
+	report := out.String()
+	assert.Contains(t, report, "topology: 4 nodes, 10000 keys\n")
+	assert.Contains(t, report, "change: remove a, minimal churn 25.00%\n")
+	for _, b := range backends {
+		if b.name == "jump" {
+			// Jump cannot remove its first bucket.
+			assert.Regexp(t, `\njump +\d+\.\d +\d+(\.\d)? (B|KiB|MiB) +\d+\.\d\d% not comparable\n`, report)
+			continue
+		}
+		assert.Regexp(t, `\n`+b.name+` +\d+\.\d +\d+(\.\d)? (B|KiB|MiB) +\d+\.\d\d% +\d+\.\d\d%\n`, report)
+	}
+	assert.Less(t, churnOf(t, report, "anchor"), 30.0)
+
+	out.Reset()
+	assert.NoError(t, run([]string{"compare-algos", "-keys", "10000", topology, changed}, &out))
+	report = out.String()
+	assert.Contains(t, report, "change: "+topology+" -> "+changed+", minimal churn 20.00%\n")
+	assert.Less(t, churnOf(t, report, "jump"), 25.0)
+	assert.Less(t, churnOf(t, report, "anchor"), 25.0)
+
+	assert.Error(t, run([]string{"compare-algos"}, &out))
+	assert.Error(t, run([]string{"compare-algos", "-keys", "0", topology}, &out))
+	assert.Error(t, run([]string{"compare-algos", writeTopology(t, dir, "empty.json", `[]`)}, &out))
+}
+
+// churnOf returns the churn compare-algos reported for backend, in percent.
+func churnOf(t *testing.T, report, backend string) float64 {
+	match := regexp.MustCompile(`\n` + backend + ` .* (\d+\.\d\d)%\n`).FindStringSubmatch(report)
+	if match == nil {
+		t.Fatalf("no churn for %s in %q", backend, report)
+	}
+	churn, _ := strconv.ParseFloat(match[1], 64)
+	return churn
+}
+
+func TestStub(t *testing.T) {
+	dir := t.TempDir()
+	topology := writeTopology(t, dir, "topology.json", `{"a": 1, "b": 2}`)
+
+	var out strings.Builder
+	assert.NoError(t, run([]string{"stub", topology}, &out))
+	var table hashring.LookupTable
+	assert.NoError(t, json.Unmarshal([]byte(out.String()), &table))
+	assert.Equal(t, []string{"a", "b"}, table.Nodes)
+	node, _ := table.GetNode("test")
+	want, _ := hashring.NewWithWeights(map[string]int{"a": 1, "b": 2}).GetNode("test")
+	assert.Equal(t, want, node)
+
+	out.Reset()
+	assert.NoError(t, run([]string{"stub", "-go", "routes", topology}, &out))
+	assert.Contains(t, out.String(), "package routes\n")
+
+	assert.Error(t, run([]string{"stub"}, &out))
+}
@@ -0,0 +1,23 @@
+package hashring
+
+// Collisions returns the number of virtual node points placed on a point
+// that was already on the ring. Such a point belongs to one node only, the
+// one whose name sorts first, so the owner does not depend on the order of
+// the nodes.
+//
+// With 32-bit keys, collisions become likely from tens of thousands of
+// points on, see With64BitKeys.
+func (h *HashRing) Collisions() int {
+	if h == nil {
+		return 0
+	}
+	return h.collisions
+}
+
+// winsCollision reports whether node takes a point both it and owner place.
+// Renamed nodes compare by the name they place their points by, so renaming
+// does not change the winner, see RenameNode.
+func (h *HashRing) winsCollision(node, owner string) bool {
+	a, b := h.identity(node), h.identity(owner)
+	return a < b || a == b && node < owner
+}
@@ -0,0 +1,50 @@
+package hashring
+
+import (
+	"crypto/md5"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// narrowHasher keeps 8 bits of each 32-bit point, so points collide.
+var narrowHasher = HasherFunc(func(key []byte) []byte {
+	sum := md5.Sum(key)
+	for i := range sum {
+		if i%4 != 0 {
+			sum[i] = 0
+		}
+	}
+	return sum[:]
+})
+
+func TestCollisions(t *testing.T) {
+	assert.Equal(t, 0, New([]string{"a", "b", "c"}).Collisions())
+	var empty *HashRing
+	assert.Equal(t, 0, empty.Collisions())
+
+	hashRing := New([]string{"c", "a", "b"}, WithHasher(narrowHasher))
+	assert.Equal(t, 360-len(hashRing.sortedKeys), hashRing.Collisions())
+	assert.Equal(t, 360, hashRing.Collisions()+len(hashRing.sortedKeys))
+
+	// The owner of a collided point does not depend on the order of nodes.
+	for _, nodes := range [][]string{{"a", "b", "c"}, {"b", "c", "a"}} {
+		expectSameCircle(t, New(nodes, WithHasher(narrowHasher)), hashRing)
+	}
+	for _, key := range New([]string{"a"}, WithHasher(narrowHasher)).sortedKeys {
+		owner, _ := hashRing.pointOwner(key)
+		assert.Equal(t, "a", owner)
+	}
+
+	// Collisions survive renames and updates.
+	renamed := hashRing.RenameNode("a", "z")
+	assert.Equal(t, hashRing.Collisions(), renamed.Collisions())
+	for key, node := range pointsOf(hashRing) {
+		if node == "a" {
+			owner, _ := renamed.pointOwner(key)
+			assert.Equal(t, "z", owner)
+		}
+	}
+	updated := hashRing.UpdateWeightedNode("a", 2)
+	assert.Equal(t, NewWithWeights(map[string]int{"a": 2, "b": 1, "c": 1}, WithHasher(narrowHasher)).Collisions(), updated.Collisions())
+}
@@ -0,0 +1,111 @@
+package hashring
+
+import (
+	"fmt"
+	"hash"
+	"io"
+	"strconv"
+)
+
+// KeySchema builds the lookup keys of records made of several fields,
+// hashing only some of them, so the granularity of placement is decided in
+// one place: hashing tenant and table but not the row ID puts all the rows
+// of a table on one node.
+//
+//	schema, _ := hashring.NewKeySchema([]string{"tenant", "table", "row"}, "tenant", "table")
+//	node, _ := ring.GetNode(schema.Key("acme", "orders", "42"))
+//
+// The hashed fields are written in schema order, each prefixed with its
+// length, so no value can pass for another split of the fields.
+type KeySchema struct {
+	fields []string
+	hashed []bool
+}
+
+// NewKeySchema creates a KeySchema of fields, in the order values are given
+// to Key, hashing the fields of hashed, or all of them if none is given.
+func NewKeySchema(fields []string, hashed ...string) (KeySchema, error) {
+	s := KeySchema{fields: append([]string{}, fields...), hashed: make([]bool, len(fields))}
+	index := make(map[string]int, len(fields))
+	for i, field := range fields {
+		if _, ok := index[field]; ok {
+			return KeySchema{}, fmt.Errorf("hashring: duplicate key field %q", field)
+		}
+		index[field] = i
+		s.hashed[i] = len(hashed) == 0
+	}
+	for _, field := range hashed {
+		i, ok := index[field]
+		if !ok {
+			return KeySchema{}, fmt.Errorf("hashring: hashed field %q is not a key field", field)
+		}
+		s.hashed[i] = true
+	}
+	return s, nil
+}
+
+// Fields returns the fields of s, in order.
+func (s KeySchema) Fields() []string {
+	return append([]string{}, s.fields...)
+}
+
+// Hashed returns the fields of s that are hashed, in order.
+func (s KeySchema) Hashed() []string {
+	var hashed []string
+	for i, field := range s.fields {
+		if s.hashed[i] {
+			hashed = append(hashed, field)
+		}
+	}
+	return hashed
+}
+
+// Key returns the lookup key of a record whose fields have values, in the
+// order of Fields. Missing values are empty, extra values are ignored.
+func (s KeySchema) Key(values ...string) string {
+	return string(s.appendKey(nil, values))
+}
+
+// Hashable returns the key of values as a Hashable, placed where Key places
+// it without building the string, see GetNodeFor.
+func (s KeySchema) Hashable(values ...string) Hashable {
+	return compositeKey{s, values}
+}
+
+// appendKey appends the key of values to b.
+func (s KeySchema) appendKey(b []byte, values []string) []byte {
+	for i := range s.fields {
+		if !s.hashed[i] {
+			continue
+		}
+		var value string
+		if i < len(values) {
+			value = values[i]
+		}
+		b = strconv.AppendInt(b, int64(len(value)), 10)
+		b = append(b, ':')
+		b = append(b, value...)
+	}
+	return b
+}
+
+// compositeKey is the Hashable of KeySchema.Hashable.
+type compositeKey struct {
+	schema KeySchema
+	values []string
+}
+
+func (k compositeKey) HashKeyInto(h hash.Hash) {
+	var length [20]byte
+	for i := range k.schema.fields {
+		if !k.schema.hashed[i] {
+			continue
+		}
+		var value string
+		if i < len(k.values) {
+			value = k.values[i]
+		}
+		h.Write(append(strconv.AppendInt(length[:0], int64(len(value)), 10), ':'))
+		io.WriteString(h, value)
+	}
+}
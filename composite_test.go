@@ -0,0 +1,40 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeySchema(t *testing.T) {
+	schema, err := NewKeySchema([]string{"tenant", "table", "row"}, "table", "tenant")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tenant", "table", "row"}, schema.Fields())
+	assert.Equal(t, []string{"tenant", "table"}, schema.Hashed())
+	assert.Equal(t, "4:acme6:orders", schema.Key("acme", "orders", "42"))
+
+	// The row is not hashed: all rows of a table go to one node.
+	hashRing := New([]string{"a", "b", "c", "d"})
+	node, _ := hashRing.GetNode(schema.Key("acme", "orders", "0"))
+	for i := 0; i < 50; i++ {
+		key := schema.Key("acme", "orders", strconv.Itoa(i))
+		expectNode(t, hashRing, key, node)
+		forKey, _ := hashRing.GetNodeFor(schema.Hashable("acme", "orders", strconv.Itoa(i)))
+		assert.Equal(t, node, forKey)
+	}
+
+	// Values cannot shift across fields; missing values are empty.
+	assert.NotEqual(t, schema.Key("ac", "meorders"), schema.Key("acme", "orders"))
+	assert.Equal(t, "4:acme0:", schema.Key("acme"))
+	assert.Equal(t, hashRing.GenKey(schema.Key("acme")), hashRing.GenKeyFor(schema.Hashable("acme")))
+
+	all, err := NewKeySchema([]string{"tenant", "row"})
+	assert.NoError(t, err)
+	assert.Equal(t, "4:acme2:42", all.Key("acme", "42", "extra"))
+
+	_, err = NewKeySchema([]string{"tenant", "tenant"})
+	assert.Error(t, err)
+	_, err = NewKeySchema([]string{"tenant"}, "row")
+	assert.Error(t, err)
+}
@@ -0,0 +1,161 @@
+package hashring
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// ConformanceProfiles are the placements conformance vectors are pinned for,
+// see ConformanceCase:
+//
+//   - "python" places nodes and keys as the Python hash_ring library this
+//     package is a port of;
+//   - "ketama" as libmemcached's weighted ketama, see WithKetama;
+//   - "default" as a ring without options.
+//
+// "python" and "default" place alike today. They are pinned separately so
+// that a change of the defaults shows up as a "default" failure while the
+// "python" vectors keep passing.
+//
+// The vectors are generated by this package, not by the libraries the
+// profiles follow: they pin its placement against regressions and let ports
+// replay it, but only prove compatibility as far as the package's own tests
+// check it against those libraries.
+var ConformanceProfiles = []string{"python", "ketama", "default"}
+
+// ConformanceOptions returns the options of profile, see ConformanceProfiles.
+func ConformanceOptions(profile string) ([]Option, error) {
+	switch profile {
+	case "python":
+		return []Option{WithReplicaFactor(defaultReplicas), WithBoundary(BoundaryAfter)}, nil
+	case "ketama":
+		return []Option{WithKetama()}, nil
+	case "default":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("hashring: unknown conformance profile %q", profile)
+}
+
+// ConformanceCase is a set of pinned lookups of a ring, so that ports in
+// other languages, and changes to the placement here, can prove they route
+// keys alike. Its JSON encoding is the format of the fixtures:
+//
+//	{"name": "abc", "profile": "python", "weights": {"a": 1, "b": 1, "c": 1},
+//	 "size": 2, "vectors": [{"key": "test", "node": "a", "nodes": ["a", "b"]}]}
+type ConformanceCase struct {
+	Name    string         `json:"name"`
+	Profile string         `json:"profile"`
+	Weights map[string]int `json:"weights"`
+	// Size is the size of the GetNodes lookups of Vectors.
+	Size    int                 `json:"size"`
+	Vectors []ConformanceVector `json:"vectors"`
+}
+
+// ConformanceVector is the result of GetNode and GetNodes of Key.
+type ConformanceVector struct {
+	Key   string   `json:"key"`
+	Node  string   `json:"node"`
+	Nodes []string `json:"nodes"`
+}
+
+// ConformanceMismatch is a vector a ring routes differently, Want as
+// pinned and Got as routed.
+type ConformanceMismatch struct {
+	Case      string
+	Want, Got ConformanceVector
+}
+
+func (m ConformanceMismatch) String() string {
+	return fmt.Sprintf("%s: key %q: want %s %v, got %s %v",
+		m.Case, m.Want.Key, m.Want.Node, m.Want.Nodes, m.Got.Node, m.Got.Nodes)
+}
+
+// NewConformanceCase returns the vectors of keys on a ring of profile with
+// weights, looking up size nodes with GetNodes.
+func NewConformanceCase(name, profile string, weights map[string]int, keys []string, size int) (ConformanceCase, error) {
+	c := ConformanceCase{Name: name, Profile: profile, Weights: weights, Size: size}
+	ring, err := c.ring()
+	if err != nil {
+		return ConformanceCase{}, err
+	}
+	c.Vectors = make([]ConformanceVector, 0, len(keys))
+	for _, key := range keys {
+		c.Vectors = append(c.Vectors, lookupVector(ring, key, size))
+	}
+	return c, nil
+}
+
+// Check routes the vectors of c on a ring built by this package, and returns
+// those routed differently.
+func (c ConformanceCase) Check() ([]ConformanceMismatch, error) {
+	ring, err := c.ring()
+	if err != nil {
+		return nil, err
+	}
+	var mismatches []ConformanceMismatch
+	for _, want := range c.Vectors {
+		got := lookupVector(ring, want.Key, c.Size)
+		if !reflect.DeepEqual(want, got) {
+			mismatches = append(mismatches, ConformanceMismatch{Case: c.Name, Want: want, Got: got})
+		}
+	}
+	return mismatches, nil
+}
+
+// ring builds the ring of c.
+func (c ConformanceCase) ring() (*HashRing, error) {
+	opts, err := ConformanceOptions(c.Profile)
+	if err != nil {
+		return nil, err
+	}
+	for node, weight := range c.Weights {
+		if weight <= 0 {
+			return nil, fmt.Errorf("hashring: conformance case %q: node %q has weight %d", c.Name, node, weight)
+		}
+	}
+	return NewWithWeights(c.Weights, opts...), nil
+}
+
+// lookupVector returns the vector of key on ring.
+func lookupVector(ring *HashRing, key string, size int) ConformanceVector {
+	v := ConformanceVector{Key: key}
+	v.Node, _ = ring.GetNode(key)
+	v.Nodes, _ = ring.GetNodes(key, size)
+	return v
+}
+
+// ReadConformance decodes the cases of a fixture written by WriteConformance.
+func ReadConformance(r io.Reader) ([]ConformanceCase, error) {
+	var cases []ConformanceCase
+	if err := json.NewDecoder(r).Decode(&cases); err != nil {
+		return nil, fmt.Errorf("hashring: conformance fixture: %w", err)
+	}
+	return cases, nil
+}
+
+// WriteConformance encodes cases as a fixture, indented for review.
+func WriteConformance(w io.Writer, cases []ConformanceCase) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(cases)
+}
+
+// CheckConformance checks every case of the fixture r, see
+// ConformanceCase.Check.
+func CheckConformance(r io.Reader) ([]ConformanceMismatch, error) {
+	cases, err := ReadConformance(r)
+	if err != nil {
+		return nil, err
+	}
+	var mismatches []ConformanceMismatch
+	for _, c := range cases {
+		m, err := c.Check()
+		if err != nil {
+			return nil, err
+		}
+		mismatches = append(mismatches, m...)
+	}
+	return mismatches, nil
+}
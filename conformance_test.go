@@ -0,0 +1,93 @@
+package hashring
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var updateConformance = flag.Bool("update-conformance", false, "rewrite testdata/conformance.json")
+
+const conformanceFixture = "testdata/conformance.json"
+
+// conformanceCases builds the pinned cases from the current placement.
+func conformanceCases(t *testing.T) []ConformanceCase {
+	keys := []string{"test", "test1", "test2", "test3", "test4", "test5", "aaaa", "bbbb"}
+	for i := 0; i < 24; i++ {
+		keys = append(keys, "key-"+strconv.Itoa(i))
+	}
+	topologies := []struct {
+		name    string
+		weights map[string]int
+		size    int
+	}{
+		{"abc", map[string]int{"a": 1, "b": 1, "c": 1}, 2},
+		{"weighted", map[string]int{"10.0.0.1:11211": 1, "10.0.0.2:11211": 2, "10.0.0.3:11211": 1, "10.0.0.4:11211": 3}, 3},
+		{"single", map[string]int{"a": 1}, 1},
+	}
+
+	var cases []ConformanceCase
+	for _, profile := range ConformanceProfiles {
+		for _, topology := range topologies {
+			c, err := NewConformanceCase(profile+"/"+topology.name, profile, topology.weights, keys, topology.size)
+			assert.NoError(t, err)
+			cases = append(cases, c)
+		}
+	}
+	return cases
+}
+
+func TestConformance(t *testing.T) {
+	if *updateConformance {
+		var buf bytes.Buffer
+		assert.NoError(t, WriteConformance(&buf, conformanceCases(t)))
+		assert.NoError(t, os.WriteFile(conformanceFixture, buf.Bytes(), 0o644))
+	}
+
+	f, err := os.Open(conformanceFixture)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+	mismatches, err := CheckConformance(f)
+	assert.NoError(t, err)
+	for _, m := range mismatches {
+		t.Error(m)
+	}
+
+	// The python vectors are those of the hash_ring test case.
+	cases := conformanceCases(t)
+	assert.Equal(t, "python/abc", cases[0].Name)
+	want := map[string]string{"test": "a", "test1": "b", "test2": "b", "test3": "c",
+		"test4": "c", "test5": "a", "aaaa": "b", "bbbb": "a"}
+	for _, v := range cases[0].Vectors[:len(want)] {
+		assert.Equal(t, want[v.Key], v.Node, v.Key)
+	}
+}
+
+func TestConformanceMismatch(t *testing.T) {
+	c, err := NewConformanceCase("abc", "ketama", map[string]int{"a": 1, "b": 1, "c": 1}, []string{"x", "y"}, 2)
+	assert.NoError(t, err)
+	mismatches, err := c.Check()
+	assert.NoError(t, err)
+	assert.Empty(t, mismatches)
+
+	want := c.Vectors[1]
+	c.Vectors[1].Node, c.Vectors[1].Nodes = "z", []string{"z", "a"}
+	mismatches, err = c.Check()
+	assert.NoError(t, err)
+	assert.Equal(t, []ConformanceMismatch{{Case: "abc", Want: c.Vectors[1], Got: want}}, mismatches)
+	assert.Contains(t, mismatches[0].String(), `abc: key "y": want z [z a]`)
+
+	_, err = NewConformanceCase("abc", "unknown", map[string]int{"a": 1}, nil, 1)
+	assert.Error(t, err)
+	c.Weights = map[string]int{"a": 0}
+	_, err = c.Check()
+	assert.Error(t, err)
+	_, err = CheckConformance(bytes.NewBufferString("{"))
+	assert.Error(t, err)
+}
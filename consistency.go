@@ -0,0 +1,111 @@
+package hashring
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// WithConsistencyCheck checks every ring derived by AddNode, RemoveNode and
+// friends against a full rebuild of the same nodes and weights, see
+// CheckConsistency, and calls onDrift with a WarnDrift warning if they
+// differ. Meant for soak tests of the incremental updates: each change costs
+// a full rebuild more; see ConsistencyWatcher to check a registered ring in
+// the background instead.
+func WithConsistencyCheck(onDrift func(HealthWarning)) Option {
+	return func(c *config) {
+		c.onDrift = onDrift
+	}
+}
+
+// CheckConsistency rebuilds h from its nodes and weights, and returns an
+// error if the points or the audit of the rebuild differ from those of h,
+// i.e. if the changes h was derived by drifted from a full placement.
+//
+// Rings imported by ImportCSV do not follow a placement, so they differ.
+func (h *HashRing) CheckConsistency() error {
+	if h.Size() == 0 {
+		return nil
+	}
+	c := h.config
+	c.metrics, c.latency, c.onOwnershipLoss, c.onDrift, c.interceptors = nil, nil, nil, nil, nil
+	fresh := &HashRing{
+		nodes:   append([]string{}, h.nodes...),
+		weights: make(map[string]int, len(h.weights)),
+		config:  c,
+		aliases: h.aliases,
+	}
+	for node, weight := range h.weights {
+		fresh.weights[node] = weight
+	}
+	fresh.generateCircle()
+
+	points := make(map[HashKey64]string, len(fresh.sortedKeys))
+	for pos, key := range fresh.sortedKeys {
+		points[key] = fresh.ownerAt(pos)
+	}
+	differ := len(fresh.sortedKeys) - len(h.sortedKeys)
+	if differ < 0 {
+		differ = -differ
+	}
+	for pos, key := range h.sortedKeys {
+		if node, ok := points[key]; !ok || node != h.ownerAt(pos) {
+			differ++
+		}
+	}
+	if differ > 0 {
+		return fmt.Errorf("hashring: %d points differ from a full rebuild", differ)
+	}
+	if fresh.Audit().Fingerprint() != h.Audit().Fingerprint() {
+		return fmt.Errorf("hashring: audit differs from a full rebuild:\n%s", h.Audit())
+	}
+	return nil
+}
+
+// checkDrift reports to the hook of WithConsistencyCheck if h drifted.
+func (h *HashRing) checkDrift() {
+	if h.config.onDrift == nil {
+		return
+	}
+	if err := h.CheckConsistency(); err != nil {
+		h.config.onDrift(HealthWarning{Kind: WarnDrift, Message: err.Error()})
+	}
+}
+
+// ConsistencyWatcher checks the ring registered under Name every Interval,
+// see CheckConsistency and Register, and calls OnDrift with a WarnDrift
+// warning each time it differs from a full rebuild. Meant for soak tests of
+// the incremental updates in the background, unlike WithConsistencyCheck it
+// costs no rebuild on changes, only one per Interval.
+type ConsistencyWatcher struct {
+	Name     string
+	Interval time.Duration // a minute if zero.
+	OnDrift  func(HealthWarning)
+	// Clock paces the checks, SystemClock if nil.
+	Clock Clock
+}
+
+// Run checks the ring at once and then every Interval until ctx is done, and
+// returns ctx.Err(). A name with no ring registered is skipped.
+func (w *ConsistencyWatcher) Run(ctx context.Context) error {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	clock := w.Clock
+	if clock == nil {
+		clock = SystemClock
+	}
+	for {
+		if ring, ok := Get(w.Name); ok {
+			if err := ring.CheckConsistency(); err != nil {
+				w.OnDrift(HealthWarning{Kind: WarnDrift, Message: err.Error()})
+			}
+		}
+		select {
+		case <-clock.After(interval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package hashring
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckConsistency(t *testing.T) {
+	hashRing := NewWithWeights(map[string]int{"a": 1, "b": 2, "c": 1})
+	assert.NoError(t, hashRing.CheckConsistency())
+	derived := hashRing.AddWeightedNode("d", 3).RemoveNode("a").UpdateWeightedNode("b", 1).RenameNode("c", "e")
+	assert.NoError(t, derived.CheckConsistency())
+	assert.NoError(t, New([]string{"a", "b"}, WithTargetImbalance(0.05)).AddNode("c").CheckConsistency())
+	assert.NoError(t, (*HashRing)(nil).CheckConsistency())
+
+	// Move a point to another node.
+	drifted := hashRing.AddNode("d")
+	drifted.owners = append([]int32{}, drifted.owners...)
+	drifted.owners[0] = (drifted.owners[0] + 1) % int32(len(drifted.names))
+	assert.EqualError(t, drifted.CheckConsistency(), "hashring: 1 points differ from a full rebuild")
+
+	drifted = hashRing.AddNode("d")
+	drifted.replicas++
+	assert.True(t, strings.HasPrefix(drifted.CheckConsistency().Error(), "hashring: audit differs from a full rebuild:\n"))
+}
+
+func TestWithConsistencyCheck(t *testing.T) {
+	var warnings []HealthWarning
+	sink := &fakeSink{}
+	hashRing := New([]string{"a", "b", "c"}, WithMetrics(sink), WithConsistencyCheck(func(w HealthWarning) {
+		warnings = append(warnings, w)
+	}))
+	hashRing.AddNode("d").RemoveNode("a").UpdateWeightedNode("b", 3)
+	assert.Empty(t, warnings)
+	// The rebuilds of the checks are not reported.
+	assert.Len(t, sink.rebuilds, 4)
+
+	// A ring whose points do not follow the placement drifts.
+	hashRing.owners = append([]int32{}, hashRing.owners...)
+	hashRing.owners[0] = (hashRing.owners[0] + 1) % 3
+	hashRing.UpdateWeightedNode("b", 2)
+	if assert.Len(t, warnings, 1) {
+		assert.Equal(t, WarnDrift, warnings[0].Kind)
+	}
+}
+
+func TestConsistencyWatcher(t *testing.T) {
+	defer Unregister("test-watched")
+
+	clock := NewManualClock(time.Unix(0, 0))
+	warnings := make(chan HealthWarning, 1)
+	w := &ConsistencyWatcher{Name: "test-watched", Interval: time.Hour, Clock: clock, OnDrift: func(warning HealthWarning) {
+		warnings <- warning
+	}}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+	waitFor := func() {
+		for clock.Waiters() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	waitFor() // nothing registered yet.
+	Register("test-watched", New([]string{"a", "b", "c"}).AddNode("d"))
+	clock.Advance(time.Hour)
+	waitFor()
+	assert.Empty(t, warnings)
+
+	drifted := New([]string{"a", "b", "c"}).AddNode("d")
+	drifted.owners = append([]int32{}, drifted.owners...)
+	drifted.owners[0] = (drifted.owners[0] + 1) % int32(len(drifted.names))
+	Register("test-watched", drifted)
+	clock.Advance(time.Hour)
+	warning := <-warnings
+	assert.Equal(t, WarnDrift, warning.Kind)
+	assert.Equal(t, "hashring: 1 points differ from a full rebuild", warning.Message)
+
+	waitFor()
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
@@ -0,0 +1,132 @@
+package hashring
+
+// Labels are key/value attributes of a node, such as {"zone": "us-east-1a", "disk": "ssd"}.
+type Labels map[string]string
+
+// Constraint restricts which nodes GetNodesWithConstraints selects as replicas.
+type Constraint interface {
+	// Allow reports whether candidate may join selected, with slots replicas
+	// still to select including candidate. labels returns a node's labels.
+	Allow(selected []string, candidate string, slots int, labels func(node string) Labels) bool
+}
+
+// ConstraintFunc adapts a function to Constraint.
+type ConstraintFunc func(selected []string, candidate string, slots int, labels func(node string) Labels) bool
+
+// Allow calls f.
+func (f ConstraintFunc) Allow(selected []string, candidate string, slots int, labels func(node string) Labels) bool {
+	return f(selected, candidate, slots, labels)
+}
+
+// MaxPerLabel allows at most max replicas sharing the same value of label key,
+// e.g. MaxPerLabel("zone", 1) puts every replica in a distinct zone.
+// Nodes without the label are not restricted.
+func MaxPerLabel(key string, max int) Constraint {
+	return ConstraintFunc(func(selected []string, candidate string, slots int, labels func(string) Labels) bool {
+		value, ok := labels(candidate)[key]
+		if !ok {
+			return true
+		}
+		count := 0
+		for _, node := range selected {
+			if v, ok := labels(node)[key]; ok && v == value {
+				count++
+			}
+		}
+		return count < max
+	})
+}
+
+// MinWithLabel requires at least min replicas whose label key equals value,
+// e.g. MinWithLabel("disk", "ssd", 1).
+func MinWithLabel(key, value string, min int) Constraint {
+	return ConstraintFunc(func(selected []string, candidate string, slots int, labels func(string) Labels) bool {
+		if labels(candidate)[key] == value {
+			return true
+		}
+		count := 0
+		for _, node := range selected {
+			if labels(node)[key] == value {
+				count++
+			}
+		}
+		// Keep enough slots for the replicas still required.
+		return count+slots-1 >= min
+	})
+}
+
+// GetNodesWithConstraints returns size nodes like GetNodes, skipping nodes on
+// the ring walk that would violate any of constraints.
+//
+// Nodes are taken in ring order. When an earlier choice leaves no way to meet
+// the constraints, the walk backtracks and tries the next node instead, so the
+// result is the first valid selection in ring order.
+// ok is false if no selection of size nodes satisfies constraints, or if
+// none is found within constraintChecks checks of the constraints: when they
+// cannot be met, e.g. more replicas than zones under MaxPerLabel, trying
+// every selection of a large ring would take exponential time.
+//
+// Unlike GetNodes, the lookup is not reported to metrics or interceptors.
+func (h *HashRing) GetNodesWithConstraints(stringKey string, size int, constraints ...Constraint) (nodes []string, ok bool) {
+	serving := h.serving()
+	if size > serving || size <= 0 {
+		return nil, false
+	}
+
+	pos, ok := h.GetNodePos(stringKey)
+	if !ok {
+		return nil, false
+	}
+	// Every node owning points, in ring order; standbys own none.
+	candidates, ok := h.walk(pos, serving)
+	if !ok {
+		return nil, false
+	}
+
+	resultSlice := make([]string, 0, size)
+	checks := 0
+	var selectFrom func(start int) bool
+	selectFrom = func(start int) bool {
+		if len(resultSlice) == size {
+			return true
+		}
+		// Leave enough candidates for the remaining slots.
+		for i := start; i <= len(candidates)-(size-len(resultSlice)); i++ {
+			if checks++; checks > constraintChecks {
+				return false
+			}
+			if !h.allow(constraints, resultSlice, candidates[i], size-len(resultSlice)) {
+				continue
+			}
+			resultSlice = append(resultSlice, candidates[i])
+			if selectFrom(i + 1) {
+				return true
+			}
+			resultSlice = resultSlice[:len(resultSlice)-1]
+		}
+		return false
+	}
+
+	if !selectFrom(0) {
+		return nil, false
+	}
+	return resultSlice, true
+}
+
+// constraintChecks bounds the candidates GetNodesWithConstraints checks
+// against the constraints for a lookup.
+const constraintChecks = 1 << 16
+
+func (h *HashRing) allow(constraints []Constraint, selected []string, candidate string, slots int) bool {
+	for _, c := range constraints {
+		if !c.Allow(selected, candidate, slots, h.nodeLabels) {
+			return false
+		}
+	}
+	return true
+}
+
+// nodeLabels returns the labels of node, nil if it has none.
+func (h *HashRing) nodeLabels(node string) Labels {
+	return h.config.labels[node]
+}
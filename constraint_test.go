@@ -0,0 +1,120 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newZonedRing() *HashRing {
+	labels := map[string]Labels{
+		"a": {"zone": "z1", "disk": "hdd"},
+		"b": {"zone": "z1", "disk": "hdd"},
+		"c": {"zone": "z2", "disk": "hdd"},
+		"d": {"zone": "z2", "disk": "hdd"},
+		"e": {"zone": "z3", "disk": "hdd"},
+		"f": {"zone": "z3", "disk": "ssd"},
+	}
+	return New([]string{"a", "b", "c", "d", "e", "f"}, WithNodeLabels(labels))
+}
+
+// isSubsequence reports whether nodes appear in walk in the same order.
+func isSubsequence(nodes, walk []string) bool {
+	i := 0
+	for _, node := range walk {
+		if i < len(nodes) && nodes[i] == node {
+			i++
+		}
+	}
+	return i == len(nodes)
+}
+
+func TestGetNodesWithConstraintsNone(t *testing.T) {
+	hashRing := newZonedRing()
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		expected, _ := hashRing.GetNodes(key, 3)
+		nodes, ok := hashRing.GetNodesWithConstraints(key, 3)
+		assert.True(t, ok)
+		assert.Equal(t, expected, nodes)
+	}
+}
+
+func TestGetNodesWithConstraintsMaxPerLabel(t *testing.T) {
+	hashRing := newZonedRing()
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		nodes, ok := hashRing.GetNodesWithConstraints(key, 3, MaxPerLabel("zone", 1))
+		assert.True(t, ok)
+
+		zones := make(map[string]bool)
+		for _, node := range nodes {
+			zones[hashRing.nodeLabels(node)["zone"]] = true
+		}
+		assert.Len(t, zones, 3, "key %s got %v", key, nodes)
+
+		owner, _ := hashRing.GetNode(key)
+		assert.Equal(t, owner, nodes[0])
+		walk, _ := hashRing.GetNodes(key, 6)
+		assert.True(t, isSubsequence(nodes, walk))
+	}
+
+	nodes, ok := hashRing.GetNodesWithConstraints("test", 4, MaxPerLabel("zone", 1))
+	assert.False(t, ok)
+	assert.Empty(t, nodes)
+}
+
+func TestGetNodesWithConstraintsMinWithLabel(t *testing.T) {
+	hashRing := newZonedRing()
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		nodes, ok := hashRing.GetNodesWithConstraints(key, 2, MinWithLabel("disk", "ssd", 1), MaxPerLabel("zone", 1))
+		assert.True(t, ok)
+		assert.Contains(t, nodes, "f")
+		assert.NotEqual(t, hashRing.nodeLabels(nodes[0])["zone"], hashRing.nodeLabels(nodes[1])["zone"])
+	}
+
+	_, ok := hashRing.GetNodesWithConstraints("test", 2, MinWithLabel("disk", "ssd", 2))
+	assert.False(t, ok)
+}
+
+func TestGetNodesWithConstraintsDerived(t *testing.T) {
+	hashRing := newZonedRing().RemoveNode("a")
+	assert.Equal(t, "z1", hashRing.nodeLabels("b")["zone"])
+}
+
+func TestGetNodesWithConstraintsUnsatisfiable(t *testing.T) {
+	labels := make(map[string]Labels)
+	nodes := make([]string, 120)
+	for i := range nodes {
+		nodes[i] = "node-" + strconv.Itoa(i)
+		labels[nodes[i]] = Labels{"zone": "z" + strconv.Itoa(i%10)}
+	}
+	hashRing := New(nodes, WithNodeLabels(labels))
+
+	// 10 zones of at most 3 replicas cannot hold 40: the search gives up
+	// within its bound instead of trying every selection.
+	checks := 0
+	counted := ConstraintFunc(func([]string, string, int, func(string) Labels) bool {
+		checks++
+		return true
+	})
+	_, ok := hashRing.GetNodesWithConstraints("key", 40, counted, MaxPerLabel("zone", 3))
+	assert.False(t, ok)
+	assert.LessOrEqual(t, checks, constraintChecks)
+
+	selected, ok := hashRing.GetNodesWithConstraints("key", 30, MaxPerLabel("zone", 3))
+	assert.True(t, ok)
+	assert.Len(t, selected, 30)
+}
+
+func TestGetNodesWithConstraintsNotReported(t *testing.T) {
+	lookups := 0
+	hashRing := New([]string{"a", "b", "c"}, WithInterceptors(Interceptor{
+		BeforeLookup: func(string) { lookups++ },
+	}))
+	_, ok := hashRing.GetNodesWithConstraints("key", 2, MaxPerLabel("zone", 1))
+	assert.True(t, ok)
+	assert.Zero(t, lookups)
+}
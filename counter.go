@@ -0,0 +1,157 @@
+package hashring
+
+import (
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// shardedCounter counts by key in stripes of atomic counts, so concurrent
+// increments seldom contend: each key has one count per stripe, about one
+// stripe per P, and reads sum the stripes. Go does not tell a goroutine its
+// P, so an increment picks a stripe with the runtime's per-thread random
+// source, which takes no lock.
+//
+// Keys get a slot when first counted, in an index copied on write, so its
+// reads take no lock either. The counts of a slot are never moved.
+type shardedCounter[K comparable] struct {
+	mask  uint32 // stripes - 1, stripes a power of 2.
+	index atomic.Pointer[counterIndex[K]]
+	mu    sync.Mutex // serializes new slots.
+}
+
+type counterIndex[K comparable] struct {
+	slots  map[K]int
+	blocks []*counterBlock // the counts of slots, counterBlockKeys per block.
+}
+
+// counterBlockKeys is the number of keys of a counterBlock, whose counts fill
+// a cache line in each stripe.
+const counterBlockKeys = 8
+
+// counterBlock holds the counts of counterBlockKeys slots, a row per stripe.
+type counterBlock struct {
+	rows []counterRow
+}
+
+// counterRow is padded to two cache lines, so the counts of two stripes
+// never share a line, however the rows are aligned.
+type counterRow struct {
+	counts [counterBlockKeys]atomic.Int64
+	_      [counterBlockKeys]int64
+}
+
+func newShardedCounter[K comparable]() *shardedCounter[K] {
+	stripes := 1
+	for stripes < runtime.GOMAXPROCS(0) {
+		stripes *= 2
+	}
+	c := &shardedCounter[K]{mask: uint32(stripes - 1)}
+	c.index.Store(&counterIndex[K]{slots: make(map[K]int)})
+	return c
+}
+
+// add adds n to the count of key.
+func (c *shardedCounter[K]) add(key K, n int64) {
+	index := c.index.Load()
+	slot, ok := index.slots[key]
+	if !ok {
+		index, slot = c.insert(key)
+	}
+	row := &index.blocks[slot/counterBlockKeys].rows[rand.Uint32()&c.mask]
+	row.counts[slot%counterBlockKeys].Add(n)
+}
+
+// insert gives key a slot, unless a concurrent insert did, and returns the
+// index with it.
+func (c *shardedCounter[K]) insert(key K) (*counterIndex[K], int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	index := c.index.Load()
+	if slot, ok := index.slots[key]; ok {
+		return index, slot
+	}
+
+	slot := len(index.slots)
+	next := &counterIndex[K]{slots: make(map[K]int, slot+1), blocks: index.blocks}
+	for k, s := range index.slots {
+		next.slots[k] = s
+	}
+	next.slots[key] = slot
+	if slot%counterBlockKeys == 0 {
+		block := &counterBlock{rows: make([]counterRow, c.mask+1)}
+		next.blocks = append(index.blocks[:len(index.blocks):len(index.blocks)], block)
+	}
+	c.index.Store(next)
+	return next, slot
+}
+
+// snapshot returns the counts of the keys counted, summed over the stripes,
+// and resets them if reset. Keys whose count is 0 are left out.
+func (c *shardedCounter[K]) snapshot(reset bool) map[K]int64 {
+	index := c.index.Load()
+	counts := make(map[K]int64, len(index.slots))
+	for key, slot := range index.slots {
+		block := index.blocks[slot/counterBlockKeys]
+		var n int64
+		for i := range block.rows {
+			count := &block.rows[i].counts[slot%counterBlockKeys]
+			if reset {
+				n += count.Swap(0)
+			} else {
+				n += count.Load()
+			}
+		}
+		if n != 0 {
+			counts[key] = n
+		}
+	}
+	return counts
+}
+
+// LookupCounter is a MetricsSink counting the lookups of each node in
+// memory, e.g. to show on a dashboard or export to a metrics system that
+// pulls. Counts are striped, see shardedCounter, so concurrent lookups
+// seldom wait on each other to count; reading them sums the stripes.
+type LookupCounter struct {
+	lookups  *shardedCounter[string]
+	mu       sync.Mutex
+	rebuilds int
+}
+
+// NewLookupCounter creates an empty LookupCounter.
+func NewLookupCounter() *LookupCounter {
+	return &LookupCounter{lookups: newShardedCounter[string]()}
+}
+
+// Lookup implements MetricsSink.
+func (c *LookupCounter) Lookup(node string) {
+	c.lookups.add(node, 1)
+}
+
+// Rebuild implements MetricsSink.
+func (c *LookupCounter) Rebuild(time.Duration, int) {
+	c.mu.Lock()
+	c.rebuilds++
+	c.mu.Unlock()
+}
+
+// Counts returns the number of lookups of each node so far.
+func (c *LookupCounter) Counts() map[string]int64 {
+	return c.lookups.snapshot(false)
+}
+
+// Reset returns the number of lookups of each node since the last Reset, and
+// starts counting anew.
+func (c *LookupCounter) Reset() map[string]int64 {
+	return c.lookups.snapshot(true)
+}
+
+// Rebuilds returns the number of rebuilds so far.
+func (c *LookupCounter) Rebuilds() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rebuilds
+}
@@ -0,0 +1,93 @@
+package hashring
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupCounter(t *testing.T) {
+	counter := NewLookupCounter()
+	hashRing := New([]string{"a", "b", "c"}, WithMetrics(counter))
+	assert.Equal(t, 1, counter.Rebuilds())
+
+	expected := map[string]int64{}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				node, _ := hashRing.GetNode(strconv.Itoa(g*1000 + i))
+				mu.Lock()
+				expected[node]++
+				mu.Unlock()
+			}
+		}(g)
+	}
+	wg.Wait()
+	assert.Equal(t, expected, counter.Counts())
+
+	assert.Equal(t, expected, counter.Reset())
+	assert.Empty(t, counter.Counts())
+	hashRing.GetNode("test")
+	node, _ := hashRing.GetNode("test")
+	assert.Equal(t, map[string]int64{node: 2}, counter.Counts())
+}
+
+func TestShardedCounter(t *testing.T) {
+	// A row of counts spans two cache lines.
+	assert.EqualValues(t, 128, unsafe.Sizeof(counterRow{}))
+
+	// Keys added while others count keep all their counts.
+	c := newShardedCounter[int]()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				c.add(i, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	counts := c.snapshot(true)
+	assert.Len(t, counts, 100)
+	for i := 0; i < 100; i++ {
+		assert.EqualValues(t, 8, counts[i], i)
+	}
+	assert.Empty(t, c.snapshot(false))
+}
+
+func BenchmarkLookupCounterParallel(b *testing.B) {
+	counter := NewLookupCounter()
+	nodes := []string{"a", "b", "c", "d"}
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			counter.Lookup(nodes[i%len(nodes)])
+			i++
+		}
+	})
+}
+
+// BenchmarkSingleAtomicParallel is the baseline of
+// BenchmarkLookupCounterParallel: a single atomic per node. Compare them with
+// -cpu=1,8,32.
+func BenchmarkSingleAtomicParallel(b *testing.B) {
+	counts := map[string]*atomic.Int64{"a": {}, "b": {}, "c": {}, "d": {}}
+	nodes := []string{"a", "b", "c", "d"}
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			counts[nodes[i%len(nodes)]].Add(1)
+			i++
+		}
+	})
+}
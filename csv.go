@@ -0,0 +1,97 @@
+package hashring
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// ExportCSV writes the continuum of h as CSV rows of a point's position and
+// its node, in ring order, after a "hash,node" header.
+func (h *HashRing) ExportCSV(w io.Writer) error {
+	h = h.orEmpty()
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"hash", "node"})
+	for pos, key := range h.sortedKeys {
+		cw.Write([]string{strconv.FormatUint(uint64(key), 10), h.ownerAt(pos)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ImportCSV creates a HashRing whose continuum is the rows of r, in the format
+// of ExportCSV; the header is optional and rows may come in any order.
+// Positions must be unique, and fit in 32 bits unless opts include
+// With64BitKeys.
+//
+// The ring routes keys by the imported points only. Its nodes have weight 1,
+// and rings derived from it, by AddNode and friends, place their points anew.
+// It returns a LimitError if the nodes or the imported points exceed
+// WithMaxNodes or WithMaxPoints.
+func ImportCSV(r io.Reader, opts ...Option) (*HashRing, error) {
+	h := &HashRing{
+		weights: make(map[string]int),
+		config:  newConfig(opts),
+		factors: make(map[string]int),
+	}
+	h.replicas = h.config.baseReplicas()
+	max := uint64(math.MaxUint32)
+	if h.config.wide {
+		max = math.MaxUint64
+	}
+
+	points := make(map[HashKey64]string)
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 2
+	for line := 1; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("hashring: %v", err)
+		}
+		if line == 1 && record[0] == "hash" {
+			continue
+		}
+
+		hash, err := strconv.ParseUint(record[0], 10, 64)
+		if err != nil || hash > max {
+			return nil, fmt.Errorf("hashring: line %d: invalid hash %q", line, record[0])
+		}
+		key, node := HashKey64(hash), record[1]
+		if node == "" {
+			return nil, fmt.Errorf("hashring: line %d: empty node", line)
+		}
+		if other, ok := points[key]; ok {
+			return nil, fmt.Errorf("hashring: line %d: hash %d of %q is also %q's", line, hash, node, other)
+		}
+		points[key] = node
+		h.sortedKeys = append(h.sortedKeys, key)
+		if _, ok := h.weights[node]; !ok {
+			h.nodes = append(h.nodes, node)
+			h.weights[node] = 1
+		}
+		h.factors[node]++ // each point counts as a virtual node.
+	}
+
+	limits := h.config
+	limits.maxPoints = 0 // the imported points count, not the ones placed anew.
+	if err := checkLimits(h.nodes, h.weights, limits); err != nil {
+		return nil, err
+	}
+	if max := h.config.maxPoints; max > 0 && len(h.sortedKeys) > max {
+		return nil, &LimitError{Limit: "points", Max: max, Actual: len(h.sortedKeys)}
+	}
+
+	sortKeys(h.sortedKeys)
+	index := h.setNames(sortedNodes(h.nodes))
+	h.owners = make([]int32, len(h.sortedKeys))
+	for pos, key := range h.sortedKeys {
+		h.owners[pos] = index[points[key]]
+	}
+	h.skips = skipsOf(h.owners)
+	return h, nil
+}
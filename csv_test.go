@@ -0,0 +1,83 @@
+package hashring
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportCSV(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, (&HashRing{
+		sortedKeys: []HashKey64{7, 42},
+		owners:     []int32{0, 1},
+		names:      []string{"a", "b,c"},
+	}).ExportCSV(&buf))
+	assert.Equal(t, "hash,node\n7,a\n42,\"b,c\"\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, (*HashRing)(nil).ExportCSV(&buf))
+	assert.Equal(t, "hash,node\n", buf.String())
+}
+
+func TestImportCSV(t *testing.T) {
+	for _, opts := range [][]Option{nil, {With64BitKeys()}} {
+		hashRing := NewWithWeights(map[string]int{"a": 1, "b": 2, "c": 1}, opts...)
+		var buf bytes.Buffer
+		assert.NoError(t, hashRing.ExportCSV(&buf))
+
+		imported, err := ImportCSV(&buf, opts...)
+		assert.NoError(t, err)
+		assert.NoError(t, imported.Validate())
+		assert.Equal(t, hashRing.sortedKeys, imported.sortedKeys)
+		for i := 0; i < 200; i++ {
+			key := strconv.Itoa(i)
+			expected, _ := hashRing.GetNodes(key, 2)
+			nodes, _ := imported.GetNodes(key, 2)
+			assert.Equal(t, expected, nodes)
+		}
+	}
+
+	// A curated layout, without header and out of order.
+	curated, err := ImportCSV(strings.NewReader("3000000000,b\n1000000000,a\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []HashKey64{1000000000, 3000000000}, curated.sortedKeys)
+	assert.Equal(t, "b", curated.ownerOf(2000000000))
+	assert.Equal(t, "a", curated.ownerOf(3500000000))
+	assert.Equal(t, 2, curated.Size())
+	assert.Equal(t, 3, curated.AddNode("c").Size())
+}
+
+func TestImportCSVErrors(t *testing.T) {
+	for _, data := range []string{
+		"1,a,extra\n",
+		"x,a\n",
+		"-1,a\n",
+		"4294967296,a\n",
+		"1,\n",
+		"1,a\n1,b\n",
+	} {
+		_, err := ImportCSV(strings.NewReader(data))
+		assert.Error(t, err, data)
+	}
+	_, err := ImportCSV(strings.NewReader("4294967296,a\n"), With64BitKeys())
+	assert.NoError(t, err)
+}
+
+func TestImportCSVLimits(t *testing.T) {
+	rows := "1,a\n2,b\n3,c\n4,c\n"
+	var limitErr *LimitError
+	_, err := ImportCSV(strings.NewReader(rows), WithMaxNodes(2))
+	assert.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, LimitError{Limit: "nodes", Max: 2, Actual: 3}, *limitErr)
+	_, err = ImportCSV(strings.NewReader(rows), WithMaxPoints(3))
+	assert.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, LimitError{Limit: "points", Max: 3, Actual: 4}, *limitErr)
+
+	hashRing, err := ImportCSV(strings.NewReader(rows), WithMaxNodes(3), WithMaxPoints(4))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, hashRing.Size())
+}
@@ -0,0 +1,290 @@
+package hashring
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxDashboardChanges is the number of recent changes a Dashboard keeps.
+	maxDashboardChanges = 20
+	// maxDashboardPoints caps the points drawn on the ring visualization.
+	maxDashboardPoints = 2000
+)
+
+// Dashboard is an http.Handler serving a small UI for on-call engineers: a
+// ring visualization, per-node ownership, recent topology changes and a tester
+// answering where a key lives right now.
+//
+//	dashboard := hashring.NewDashboard(ring)
+//	http.Handle("/debug/hashring/", http.StripPrefix("/debug/hashring", dashboard))
+//	...
+//	ring = ring.AddNode("d")
+//	dashboard.Update(ring)
+//
+// Besides the UI at "/", it serves "/lookup?key=..." as JSON, see
+// DashboardLookup: where the key hashes to, its owner and replicas, and
+// their labels.
+type Dashboard struct {
+	mu      sync.RWMutex
+	ring    *HashRing
+	changes []DashboardChange
+	now     func() time.Time
+}
+
+// DashboardChange is a topology change recorded by Dashboard.Update.
+type DashboardChange struct {
+	Time    time.Time
+	Summary string
+}
+
+// NewDashboard creates a Dashboard showing ring.
+func NewDashboard(ring *HashRing) *Dashboard {
+	ring = ring.orEmpty()
+	return &Dashboard{ring: ring, now: ring.config.clockOrSystem().Now}
+}
+
+// Update replaces the ring shown, recording what changed.
+func (d *Dashboard) Update(ring *HashRing) {
+	ring = ring.orEmpty()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if summary := topologyChange(d.ring, ring); summary != "" {
+		d.changes = append(d.changes, DashboardChange{Time: d.now(), Summary: summary})
+		if len(d.changes) > maxDashboardChanges {
+			d.changes = d.changes[len(d.changes)-maxDashboardChanges:]
+		}
+	}
+	d.ring = ring
+}
+
+// topologyChange describes the node and weight changes from old to new,
+// empty if there are none.
+func topologyChange(old, new *HashRing) string {
+	var parts []string
+	for _, node := range sortedNodes(new.nodes) {
+		oldWeight, ok := old.weights[node]
+		switch {
+		case !ok:
+			parts = append(parts, fmt.Sprintf("+%s (weight %d)", node, new.weights[node]))
+		case oldWeight != new.weights[node]:
+			parts = append(parts, fmt.Sprintf("%s weight %d → %d", node, oldWeight, new.weights[node]))
+		}
+	}
+	for _, node := range sortedNodes(old.nodes) {
+		if _, ok := new.weights[node]; !ok {
+			parts = append(parts, "-"+node)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ServeHTTP implements http.Handler.
+func (d *Dashboard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "", "/":
+		d.serveIndex(w, r)
+	case "/lookup":
+		d.serveLookup(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// DashboardLookup is the JSON answer of the lookup endpoint.
+type DashboardLookup struct {
+	Ring   string    `json:"ring,omitempty"`
+	Labels Labels    `json:"labels,omitempty"`
+	Key    string    `json:"key"`
+	Hash   HashKey64 `json:"hash"`
+	// Position is the index of the point Hash belongs to, in ring order,
+	// and Point its HashKey.
+	Position int       `json:"position"`
+	Point    HashKey64 `json:"point"`
+	Node     string    `json:"node"`
+	Replicas []string  `json:"replicas"`
+	// NodeLabels holds the labels of the replicas that have any, such as
+	// their zone, see WithNodeLabels.
+	NodeLabels map[string]Labels `json:"node_labels,omitempty"`
+}
+
+func (d *Dashboard) serveLookup(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	d.mu.RLock()
+	ring := d.ring
+	d.mu.RUnlock()
+
+	// Looking a key up on the dashboard is not a lookup of the ring: it is
+	// neither reported to the ring's metrics nor intercepted.
+	pos, ok := ring.GetNodePos(key)
+	if !ok {
+		http.Error(w, "ring is empty", http.StatusServiceUnavailable)
+		return
+	}
+	node := ring.ownerAt(pos)
+	replicas, _ := ring.walk(pos, len(sortedNodes(ring.nodes)))
+	lookup := DashboardLookup{
+		Ring:     ring.Name(),
+		Labels:   ring.RingLabels(),
+		Key:      key,
+		Hash:     ring.GenKey64(key),
+		Position: pos,
+		Point:    ring.sortedKeys[pos],
+		Node:     node,
+		Replicas: replicas,
+	}
+	for _, replica := range replicas {
+		if labels := ring.nodeLabels(replica); len(labels) > 0 {
+			if lookup.NodeLabels == nil {
+				lookup.NodeLabels = make(map[string]Labels)
+			}
+			lookup.NodeLabels[replica] = labels
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lookup)
+}
+
+type dashboardNode struct {
+	Name        string
+	Color       template.CSS
+	Weight      int
+	Points      int
+	WeightShare float64
+	Ownership   float64
+}
+
+type dashboardPoint struct {
+	X, Y  float64
+	Color template.CSS
+}
+
+type dashboardPage struct {
+	Name    string
+	Labels  []string
+	Nodes   []dashboardNode
+	Points  []dashboardPoint
+	Total   int
+	Changes []DashboardChange
+}
+
+func (d *Dashboard) serveIndex(w http.ResponseWriter, r *http.Request) {
+	d.mu.RLock()
+	ring := d.ring
+	changes := make([]DashboardChange, len(d.changes))
+	copy(changes, d.changes)
+	d.mu.RUnlock()
+
+	page := dashboardPage{Name: ring.Name(), Total: len(ring.sortedKeys)}
+	for key, value := range ring.RingLabels() {
+		page.Labels = append(page.Labels, key+"="+value)
+	}
+	sort.Strings(page.Labels)
+	for i := len(changes) - 1; i >= 0; i-- {
+		page.Changes = append(page.Changes, changes[i])
+	}
+
+	nodes := sortedNodes(ring.nodes)
+	colors := make(map[string]template.CSS, len(nodes))
+	totalWeight, points := 0, make(map[string]int)
+	for _, node := range nodes {
+		totalWeight += ring.weights[node]
+	}
+	for pos := range ring.sortedKeys {
+		points[ring.ownerAt(pos)]++
+	}
+	ownership := ring.ownership()
+	for i, node := range nodes {
+		colors[node] = template.CSS(fmt.Sprintf("hsl(%d, 65%%, 50%%)", i*360/len(nodes)))
+		page.Nodes = append(page.Nodes, dashboardNode{
+			Name:        node,
+			Color:       colors[node],
+			Weight:      ring.weights[node],
+			Points:      points[node],
+			WeightShare: float64(ring.weights[node]) / float64(totalWeight) * 100,
+			Ownership:   ownership[node] * 100,
+		})
+	}
+
+	step := 1
+	if len(ring.sortedKeys) > maxDashboardPoints {
+		step = (len(ring.sortedKeys) + maxDashboardPoints - 1) / maxDashboardPoints
+	}
+	for i := 0; i < len(ring.sortedKeys); i += step {
+		key := ring.sortedKeys[i]
+		angle := float64(key)/ring.keyspace()*2*math.Pi - math.Pi/2
+		page.Points = append(page.Points, dashboardPoint{
+			X:     150 + 120*math.Cos(angle),
+			Y:     150 + 120*math.Sin(angle),
+			Color: colors[ring.ownerAt(i)],
+		})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := dashboardTemplate.Execute(w, page); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>hashring{{with .Name}} {{.}}{{end}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+td, th { padding: 0.2em 0.8em; text-align: left; }
+.swatch { display: inline-block; width: 0.8em; height: 0.8em; margin-right: 0.4em; }
+</style>
+</head>
+<body>
+<h1>hashring{{with .Name}} {{.}}{{end}}</h1>
+{{with .Labels}}<p>{{range .}}<code>{{.}}</code> {{end}}</p>{{end}}
+
+<h2>Lookup</h2>
+<form id="lookup">
+<input name="key" placeholder="key" autofocus>
+<button>Lookup</button>
+</form>
+<pre id="result"></pre>
+
+<h2>Ring</h2>
+<svg width="300" height="300" viewBox="0 0 300 300">
+<circle cx="150" cy="150" r="120" fill="none" stroke="#ccc"/>
+{{range .Points}}<circle cx="{{printf "%.1f" .X}}" cy="{{printf "%.1f" .Y}}" r="2" fill="{{.Color}}"/>
+{{end}}</svg>
+<p>{{.Total}} points</p>
+
+<h2>Nodes</h2>
+<table>
+<tr><th>Node</th><th>Weight</th><th>Points</th><th>Weight share</th><th>Ownership</th></tr>
+{{range .Nodes}}<tr><td><span class="swatch" style="background: {{.Color}}"></span>{{.Name}}</td><td>{{.Weight}}</td><td>{{.Points}}</td><td>{{printf "%.2f" .WeightShare}}%</td><td>{{printf "%.2f" .Ownership}}%</td></tr>
+{{end}}</table>
+
+<h2>Recent changes</h2>
+{{if .Changes}}<ul>
+{{range .Changes}}<li>{{.Time.Format "2006-01-02 15:04:05"}}: {{.Summary}}</li>
+{{end}}</ul>{{else}}<p>None</p>{{end}}
+
+<script>
+document.getElementById("lookup").addEventListener("submit", function (e) {
+	e.preventDefault();
+	var key = new FormData(e.target).get("key");
+	fetch("lookup?key=" + encodeURIComponent(key))
+		.then(function (r) { return r.text(); })
+		.then(function (t) { document.getElementById("result").textContent = t; });
+});
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,128 @@
+package hashring
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDashboardLookup(t *testing.T) {
+	dashboard := NewDashboard(New([]string{"a", "b", "c"}))
+
+	rec := httptest.NewRecorder()
+	dashboard.ServeHTTP(rec, httptest.NewRequest("GET", "/lookup?key=test", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var lookup DashboardLookup
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &lookup))
+	assert.Equal(t, "test", lookup.Key)
+	assert.Equal(t, "a", lookup.Node)
+	assert.Equal(t, []string{"a", "b", "c"}, lookup.Replicas)
+	assert.Equal(t, New([]string{"a"}).GenKey64("test"), lookup.Hash)
+	pos, _ := dashboard.ring.GetNodePos("test")
+	assert.Equal(t, pos, lookup.Position)
+	assert.Equal(t, dashboard.ring.sortedKeys[pos], lookup.Point)
+	assert.Empty(t, lookup.NodeLabels)
+
+	dashboard.Update(New([]string{"a", "b", "c"}, WithNodeLabels(map[string]Labels{
+		"a": {"zone": "us-east-1a"},
+		"c": {"zone": "us-east-1c"},
+	})))
+	rec = httptest.NewRecorder()
+	dashboard.ServeHTTP(rec, httptest.NewRequest("GET", "/lookup?key=test", nil))
+	lookup = DashboardLookup{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &lookup))
+	assert.Equal(t, map[string]Labels{"a": {"zone": "us-east-1a"}, "c": {"zone": "us-east-1c"}}, lookup.NodeLabels)
+	assert.Contains(t, rec.Body.String(), `"node_labels":{"a":{"zone":"us-east-1a"}`)
+
+	dashboard.Update(New([]string{}))
+	rec = httptest.NewRecorder()
+	dashboard.ServeHTTP(rec, httptest.NewRequest("GET", "/lookup?key=test", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestDashboardLookupNotReported(t *testing.T) {
+	sink := &fakeSink{}
+	intercepted := 0
+	hashRing := New([]string{"a", "b", "c"}, WithMetrics(sink), WithInterceptors(Interceptor{
+		BeforeLookup: func(string) { intercepted++ },
+	}))
+	dashboard := NewDashboard(hashRing)
+
+	rec := httptest.NewRecorder()
+	dashboard.ServeHTTP(rec, httptest.NewRequest("GET", "/lookup?key=test", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, sink.lookups)
+	assert.Zero(t, intercepted)
+
+	var lookup DashboardLookup
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &lookup))
+	expectNode(t, hashRing, "test", lookup.Node)
+}
+
+func TestDashboardIndex(t *testing.T) {
+	dashboard := NewDashboard(New([]string{"a", "b", "c"}))
+	dashboard.now = func() time.Time { return time.Date(2019, 7, 26, 12, 0, 0, 0, time.UTC) }
+
+	ring := dashboard.ring.AddNode("d").RemoveNode("a").UpdateWeightedNode("b", 3)
+	dashboard.Update(ring)
+	dashboard.Update(ring)
+
+	rec := httptest.NewRecorder()
+	dashboard.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, `<span class="swatch" style="background: hsl(240, 65%, 50%)"></span>d</td>`)
+	assert.Contains(t, body, "2019-07-26 12:00:00: b weight 1 → 3, &#43;d (weight 1), -a")
+	assert.Equal(t, 1, strings.Count(body, "<li>"))
+	assert.Equal(t, len(ring.sortedKeys), strings.Count(body, `r="2"`))
+
+	rec = httptest.NewRecorder()
+	dashboard.ServeHTTP(rec, httptest.NewRequest("GET", "/nope", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestDashboardChangesBounded(t *testing.T) {
+	ring := New([]string{"a"})
+	dashboard := NewDashboard(ring)
+	for i := 0; i < maxDashboardChanges+5; i++ {
+		ring = ring.UpdateWeightedNode("a", i+2)
+		dashboard.Update(ring)
+	}
+	assert.Len(t, dashboard.changes, maxDashboardChanges)
+	assert.Equal(t, "a weight 25 → 26", dashboard.changes[maxDashboardChanges-1].Summary)
+}
+
+func TestDashboardNilRing(t *testing.T) {
+	dashboard := NewDashboard(nil)
+
+	rec := httptest.NewRecorder()
+	dashboard.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	dashboard.Update(New([]string{"a"}))
+	dashboard.Update(nil)
+	assert.Equal(t, "-a", dashboard.changes[1].Summary)
+}
+
+func TestDashboardNamedRing(t *testing.T) {
+	dashboard := NewDashboard(New([]string{"a"}, WithName("sessions"), WithRingLabels(Labels{"team": "storage"})))
+
+	rec := httptest.NewRecorder()
+	dashboard.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	assert.Contains(t, rec.Body.String(), "<h1>hashring sessions</h1>")
+	assert.Contains(t, rec.Body.String(), "<code>team=storage</code>")
+
+	rec = httptest.NewRecorder()
+	dashboard.ServeHTTP(rec, httptest.NewRequest("GET", "/lookup?key=test", nil))
+	var lookup DashboardLookup
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &lookup))
+	assert.Equal(t, "sessions", lookup.Ring)
+	assert.Equal(t, Labels{"team": "storage"}, lookup.Labels)
+}
@@ -0,0 +1,163 @@
+package hashring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// TopologyDiff describes the changes between two rings.
+type TopologyDiff struct {
+	Added         []string
+	Removed       []string
+	WeightChanges []WeightChange
+	// Ownership holds the keyspace share of every node of either ring.
+	Ownership []OwnershipDelta
+	// Churn is the fraction of the keyspace that changes owner.
+	Churn float64
+}
+
+// WeightChange is a node whose weight changed.
+type WeightChange struct {
+	Node     string
+	Old, New int
+}
+
+// OwnershipDelta is the keyspace share of a node before and after a change.
+type OwnershipDelta struct {
+	Node     string
+	Old, New float64
+}
+
+// Diff compares the rings old and new. All lists are sorted by node.
+func Diff(old, new *HashRing) TopologyDiff {
+	old, new = old.orEmpty(), new.orEmpty()
+	var d TopologyDiff
+	for _, node := range sortedNodes(new.nodes) {
+		oldWeight, ok := old.weights[node]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, node)
+		case oldWeight != new.weights[node]:
+			d.WeightChanges = append(d.WeightChanges, WeightChange{Node: node, Old: oldWeight, New: new.weights[node]})
+		}
+	}
+	for _, node := range sortedNodes(old.nodes) {
+		if _, ok := new.weights[node]; !ok {
+			d.Removed = append(d.Removed, node)
+		}
+	}
+
+	oldOwnership, newOwnership := old.ownership(), new.ownership()
+	for _, node := range sortedNodes(append(append([]string{}, old.nodes...), new.nodes...)) {
+		d.Ownership = append(d.Ownership, OwnershipDelta{Node: node, Old: oldOwnership[node], New: newOwnership[node]})
+	}
+
+	d.Churn = churn(old, new)
+	return d
+}
+
+// churn returns the fraction of the keyspace whose owner differs between a and b.
+//
+// Between two consecutive points of either ring, every key has the same owner
+// in both rings, so comparing the owners once per such arc is exact, up to
+// the keys equal to a point when the rings' boundaries differ.
+func churn(a, b *HashRing) float64 {
+	if len(a.sortedKeys) == 0 && len(b.sortedKeys) == 0 {
+		return 0
+	}
+	if len(a.sortedKeys) == 0 || len(b.sortedKeys) == 0 {
+		return 1
+	}
+
+	keyspace := a.keyspace()
+	boundaries := make([]HashKey64, 0, len(a.sortedKeys)+len(b.sortedKeys)+1)
+	boundaries = append(boundaries, 0)
+	i, j := 0, 0
+	for i < len(a.sortedKeys) || j < len(b.sortedKeys) {
+		var key HashKey64
+		if j == len(b.sortedKeys) || (i < len(a.sortedKeys) && a.sortedKeys[i] < b.sortedKeys[j]) {
+			key = a.sortedKeys[i]
+			i++
+		} else {
+			key = b.sortedKeys[j]
+			j++
+		}
+		if key != boundaries[len(boundaries)-1] {
+			boundaries = append(boundaries, key)
+		}
+	}
+
+	moved := 0.0
+	for k, start := range boundaries {
+		end := keyspace
+		if k+1 < len(boundaries) {
+			end = float64(boundaries[k+1])
+		}
+		// start+1 is inside the arc, which is [start, end) under BoundaryAfter
+		// and (start, end] under BoundaryAtOrAfter.
+		key := start
+		if k+1 < len(boundaries) && boundaries[k+1]-start >= 2 || k+1 == len(boundaries) && start != math.MaxUint64 {
+			key++
+		}
+		if a.ownerOf(key) != b.ownerOf(key) {
+			moved += end - float64(start)
+		}
+	}
+	return moved / keyspace
+}
+
+// ownerOf returns the node that key belongs to.
+func (h *HashRing) ownerOf(key HashKey64) string {
+	pos, ok := h.keyPos(key)
+	if !ok {
+		return ""
+	}
+	return h.ownerAt(pos)
+}
+
+// ApplyWeights makes weights the full set of nodes and weights of h, like
+// UpdateWithWeights, and reports the changes. With dryRun, h is left as is
+// and the report describes what applying would do; the rebuild of a dry run
+// is not reported to the metrics, interceptors or hooks of h.
+//
+// Weights must not be negative, 0 for a standby, see AddWeightedNode, and
+// must keep the ring within its limits, otherwise nothing is applied.
+func (h *HashRing) ApplyWeights(weights map[string]int, dryRun bool) (TopologyDiff, error) {
+	for node, weight := range weights {
+		if weight < 0 {
+			return TopologyDiff{}, fmt.Errorf("hashring: node %q has weight %d", node, weight)
+		}
+	}
+	if h == nil && !dryRun {
+		return TopologyDiff{}, errors.New("hashring: cannot apply weights to a nil ring")
+	}
+	if err := checkLimits(nodesOf(weights), weights, h.orEmpty().config); err != nil {
+		return TopologyDiff{}, err
+	}
+
+	desired := make(map[string]int, len(weights))
+	for node, weight := range weights {
+		desired[node] = weight
+	}
+	next := &HashRing{}
+	*next = *h.orEmpty()
+	if dryRun {
+		// A dry run changes no ring, so it reports nothing: no rebuild, no
+		// ownership loss and no consistency check.
+		next.config.onOwnershipLoss = nil
+		next.config.metrics = nil
+		next.config.interceptors = nil
+		next.config.onDrift = nil
+	}
+	if err := next.UpdateWithWeightsContext(context.Background(), desired); err != nil {
+		return TopologyDiff{}, err
+	}
+
+	d := Diff(h, next)
+	if !dryRun {
+		*h = *next
+	}
+	return d, nil
+}
@@ -0,0 +1,113 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	old := NewWithWeights(map[string]int{"a": 1, "b": 1, "c": 1})
+	new := NewWithWeights(map[string]int{"a": 1, "b": 2, "d": 1})
+
+	d := Diff(old, new)
+	assert.Equal(t, []string{"d"}, d.Added)
+	assert.Equal(t, []string{"c"}, d.Removed)
+	assert.Equal(t, []WeightChange{{Node: "b", Old: 1, New: 2}}, d.WeightChanges)
+
+	assert.Len(t, d.Ownership, 4)
+	assert.Equal(t, "c", d.Ownership[2].Node)
+	assert.Equal(t, 0.0, d.Ownership[2].New)
+	assert.Equal(t, old.ownership()["c"], d.Ownership[2].Old)
+
+	// The exact churn agrees with sampled keys.
+	moved := 0
+	for i := 0; i < 20000; i++ {
+		key := strconv.Itoa(i)
+		if old.ownerOf(old.GenKey64(key)) != new.ownerOf(new.GenKey64(key)) {
+			moved++
+		}
+	}
+	assert.InDelta(t, float64(moved)/20000, d.Churn, 0.02)
+}
+
+func TestDiffChurn(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"})
+	assert.Equal(t, 0.0, Diff(hashRing, hashRing).Churn)
+	assert.Equal(t, 1.0, Diff(hashRing, New([]string{"d"})).Churn)
+	assert.Equal(t, 1.0, Diff(New([]string{}), hashRing).Churn)
+	assert.Equal(t, 0.0, Diff(New([]string{}), New([]string{})).Churn)
+
+	removed := hashRing.RemoveNode("b")
+	assert.InDelta(t, hashRing.ownership()["b"], Diff(hashRing, removed).Churn, 1e-9)
+}
+
+func TestApplyWeights(t *testing.T) {
+	hashRing := NewWithWeights(map[string]int{"a": 1, "b": 1, "c": 1})
+	before := hashRing.sortedKeys
+	desired := map[string]int{"a": 1, "b": 3, "d": 1}
+
+	planned, err := hashRing.ApplyWeights(desired, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"d"}, planned.Added)
+	assert.Equal(t, []string{"c"}, planned.Removed)
+	assert.Equal(t, []WeightChange{{Node: "b", Old: 1, New: 3}}, planned.WeightChanges)
+	assert.Equal(t, before, hashRing.sortedKeys)
+	assert.Equal(t, 3, hashRing.Size())
+
+	applied, err := hashRing.ApplyWeights(desired, false)
+	assert.NoError(t, err)
+	assert.Equal(t, planned, applied)
+	expectSameCircle(t, hashRing, NewWithWeights(map[string]int{"a": 1, "b": 3, "d": 1}))
+	assert.Equal(t, before, hashRing.Previous().sortedKeys)
+
+	// The caller's map is not retained.
+	desired["e"] = 1
+	assert.Equal(t, 3, hashRing.Size())
+
+	// Applying the same state again changes nothing.
+	again, err := hashRing.ApplyWeights(map[string]int{"a": 1, "b": 3, "d": 1}, false)
+	assert.NoError(t, err)
+	assert.Empty(t, again.Added)
+	assert.Empty(t, again.Removed)
+	assert.Empty(t, again.WeightChanges)
+	assert.Equal(t, 0.0, again.Churn)
+}
+
+func TestApplyWeightsDryRunUnreported(t *testing.T) {
+	sink := &fakeSink{}
+	rebuilds, drifts := 0, 0
+	hashRing := New([]string{"a", "b", "c"}, WithMetrics(sink),
+		WithInterceptors(Interceptor{OnRebuild: func(RebuildEvent) { rebuilds++ }}),
+		WithConsistencyCheck(func(HealthWarning) { drifts++ }))
+	sink.rebuilds, rebuilds = nil, 0
+
+	_, err := hashRing.ApplyWeights(map[string]int{"a": 1, "b": 2, "d": 1}, true)
+	assert.NoError(t, err)
+	_, err = hashRing.OptimizeWeights(map[string]float64{"a": 2, "b": 1, "c": 1}, 1)
+	assert.NoError(t, err)
+	assert.Empty(t, sink.rebuilds)
+	assert.Zero(t, rebuilds)
+	assert.Zero(t, drifts)
+
+	_, err = hashRing.ApplyWeights(map[string]int{"a": 1, "b": 2, "d": 1}, false)
+	assert.NoError(t, err)
+	assert.Len(t, sink.rebuilds, 1)
+	assert.Equal(t, 1, rebuilds)
+}
+
+func TestApplyWeightsInvalid(t *testing.T) {
+	hashRing := New([]string{"a", "b"})
+	_, err := hashRing.ApplyWeights(map[string]int{"a": 1, "b": -1}, false)
+	assert.Error(t, err)
+	assert.Equal(t, 2, hashRing.Size())
+
+	var nilRing *HashRing
+	_, err = nilRing.ApplyWeights(map[string]int{"a": 1}, false)
+	assert.Error(t, err)
+	planned, err := nilRing.ApplyWeights(map[string]int{"a": 1}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a"}, planned.Added)
+	assert.Equal(t, 1.0, planned.Churn)
+}
@@ -0,0 +1,32 @@
+package hashring
+
+// WithPointsPerDigest sets the number of points a virtual node places from
+// its digest, one per 4 bytes (8 with 64-bit keys) from the start of the
+// digest, as many as the digest has at most.
+//
+// By default it is 3 with 32-bit keys, so the last 4 bytes of md5 are
+// unused, as in hash_ring, and 2 with 64-bit keys. 4 uses the whole md5
+// digest for more points and better balance, as ketama does, 1 places a point
+// per digest. Changing it moves most keys.
+func WithPointsPerDigest(n int) Option {
+	return func(c *config) {
+		c.pointsPerDigest = n
+	}
+}
+
+// pointsPerDigest returns the number of points h places per digest of a
+// virtual node, see WithPointsPerDigest.
+func (h *HashRing) pointsPerDigest() int {
+	switch {
+	case h.config.placement == placementKetama:
+		return 4
+	case h.config.placement == placementNginx:
+		return 1
+	case h.config.pointsPerDigest > 0:
+		return h.config.pointsPerDigest
+	case h.config.wide:
+		return 2
+	}
+	// It's still a mystery why the fourth byte is discarded.
+	return 3
+}
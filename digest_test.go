@@ -0,0 +1,35 @@
+package hashring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithPointsPerDigest(t *testing.T) {
+	nodes := []string{"a", "b", "c"}
+	for _, tc := range []struct {
+		opts   []Option
+		points int
+	}{
+		{nil, 3},
+		{[]Option{WithPointsPerDigest(4)}, 4},
+		{[]Option{WithPointsPerDigest(1)}, 1},
+		// md5 has room for 4 points only.
+		{[]Option{WithPointsPerDigest(5)}, 4},
+		{[]Option{With64BitKeys()}, 2},
+		{[]Option{With64BitKeys(), WithPointsPerDigest(1)}, 1},
+		{[]Option{WithKetama(), WithPointsPerDigest(1)}, 4},
+	} {
+		hashRing := New(nodes, tc.opts...)
+		assert.NoError(t, hashRing.Validate())
+		assert.Len(t, hashRing.sortedKeys, len(nodes)*defaultReplicas*tc.points)
+	}
+
+	// The default points are the first three of four.
+	four := New(nodes, WithPointsPerDigest(4))
+	for _, key := range New(nodes).sortedKeys {
+		assert.Contains(t, four.sortedKeys, key)
+	}
+	assert.Equal(t, New(nodes).sortedKeys, New(nodes, WithPointsPerDigest(3)).sortedKeys)
+}
@@ -0,0 +1,47 @@
+package hashring
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrEmptyRing is returned by lookups of a ring without points, as it has
+	// no nodes or only standbys.
+	ErrEmptyRing = errors.New("hashring: ring is empty")
+	// ErrNotEnoughNodes is returned by lookups asking for more nodes than
+	// the ring has, standbys aside.
+	ErrNotEnoughNodes = errors.New("hashring: not enough nodes")
+)
+
+// Lookup returns the node that stringKey belongs to like GetNode, or
+// ErrEmptyRing.
+func (h *HashRing) Lookup(stringKey string) (string, error) {
+	node, ok := h.GetNode(stringKey)
+	if !ok {
+		return "", ErrEmptyRing
+	}
+	return node, nil
+}
+
+// LookupNodes returns size nodes of stringKey like GetNodes, or an error
+// matching ErrEmptyRing or ErrNotEnoughNodes by errors.Is, telling an empty
+// ring apart from one too small.
+func (h *HashRing) LookupNodes(stringKey string, size int) ([]string, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("hashring: %d nodes requested", size)
+	}
+	if h == nil || len(h.sortedKeys) == 0 {
+		return nil, ErrEmptyRing
+	}
+	nodes, ok := h.GetNodes(stringKey, size)
+	if !ok {
+		return nil, h.notEnoughNodes(size)
+	}
+	return nodes, nil
+}
+
+// notEnoughNodes returns the ErrNotEnoughNodes of a lookup of size nodes.
+func (h *HashRing) notEnoughNodes(size int) error {
+	return fmt.Errorf("%w: %d needed, ring has %d", ErrNotEnoughNodes, size, h.serving())
+}
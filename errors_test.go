@@ -0,0 +1,35 @@
+package hashring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookup(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"})
+	node, err := hashRing.Lookup("test")
+	assert.NoError(t, err)
+	assert.Equal(t, "a", node)
+	nodes, err := hashRing.LookupNodes("test", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, nodes)
+
+	_, err = hashRing.LookupNodes("test", 4)
+	assert.ErrorIs(t, err, ErrNotEnoughNodes)
+	assert.EqualError(t, err, "hashring: not enough nodes: 4 needed, ring has 3")
+	_, err = hashRing.LookupNodes("test", 0)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotEnoughNodes)
+
+	// Empty rings, standbys only included, are told apart.
+	for _, empty := range []*HashRing{nil, New(nil), NewWithWeights(map[string]int{"a": 0})} {
+		_, err = empty.Lookup("test")
+		assert.ErrorIs(t, err, ErrEmptyRing)
+		_, err = empty.LookupNodes("test", 1)
+		assert.ErrorIs(t, err, ErrEmptyRing)
+	}
+
+	err = hashRing.DoQuorum("test", 4, 2, func(string) error { return nil })
+	assert.ErrorIs(t, err, ErrNotEnoughNodes)
+}
@@ -0,0 +1,63 @@
+package hashring
+
+import "time"
+
+// GetNodesExcluding returns size nodes like GetNodes, skipping the nodes of
+// exclude on the ring walk, e.g. to pick replicas other than a node that
+// just failed a request. ok is false if fewer than size nodes are left.
+func (h *HashRing) GetNodesExcluding(stringKey string, size int, exclude []string) (nodes []string, ok bool) {
+	if len(exclude) == 0 {
+		return h.GetNodes(stringKey, size)
+	}
+	if h.intercepted() {
+		defer h.afterLookups(stringKey, h.beforeLookup(stringKey), &nodes, &ok)
+	}
+	h = h.orEmpty()
+	excluded := make(map[string]bool, len(exclude))
+	left := h.Size()
+	for _, node := range exclude {
+		if _, ok := h.weights[node]; ok && !excluded[node] {
+			left--
+		}
+		excluded[node] = true
+	}
+	if size > left || size <= 0 {
+		return nil, false
+	}
+	if h.sampled() {
+		defer h.observeLookup(time.Now())
+	}
+
+	pos, ok := h.GetNodePos(stringKey)
+	if !ok {
+		return nil, false
+	}
+	nodes, ok = h.walkWhere(pos, size, func(node string) bool { return !excluded[node] })
+	if !ok {
+		return nil, false
+	}
+	if h.config.metrics != nil {
+		h.config.metrics.Lookup(nodes[0])
+	}
+	return nodes, true
+}
+
+// walkWhere is walk skipping the nodes keep returns false for.
+func (h *HashRing) walkWhere(pos int, size int, keep func(node string) bool) (nodes []string, ok bool) {
+	seen := make(map[string]bool, size)
+	nodes = make([]string, 0, size)
+
+	skips := len(h.skips) == len(h.sortedKeys)
+	for i, step := pos, 1; i < pos+len(h.sortedKeys) && len(nodes) < size; i += step {
+		j := i % len(h.sortedKeys)
+		if skips {
+			step = int(h.skips[j])
+		}
+		node := h.ownerAt(j)
+		if !seen[node] && keep(node) {
+			nodes = append(nodes, node)
+		}
+		seen[node] = true
+	}
+	return nodes, len(nodes) == size
+}
@@ -0,0 +1,40 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNodesExcluding(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c", "d"})
+	for i := 0; i < 200; i++ {
+		key := strconv.Itoa(i)
+		all, _ := hashRing.GetNodes(key, 4)
+
+		// The walk is GetNodes' without the excluded nodes.
+		var want []string
+		for _, node := range all {
+			if node != all[0] {
+				want = append(want, node)
+			}
+		}
+		nodes, ok := hashRing.GetNodesExcluding(key, 2, []string{all[0]})
+		assert.True(t, ok)
+		assert.Equal(t, want[:2], nodes)
+		nodes, ok = hashRing.GetNodesExcluding(key, 3, []string{all[0], all[0], "unknown"})
+		assert.True(t, ok)
+		assert.Equal(t, want, nodes)
+
+		_, ok = hashRing.GetNodesExcluding(key, 3, []string{all[0], all[1]})
+		assert.False(t, ok)
+		nodes, _ = hashRing.GetNodesExcluding(key, 2, nil)
+		assert.Equal(t, all[:2], nodes)
+	}
+
+	_, ok := hashRing.GetNodesExcluding("key", 0, []string{"a"})
+	assert.False(t, ok)
+	_, ok = New(nil).GetNodesExcluding("key", 1, []string{"a"})
+	assert.False(t, ok)
+}
@@ -0,0 +1,100 @@
+package hashring
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// GroupKeysByNode returns keys grouped by the node they belong to, see
+// GetNode, each group in the order of keys. It is empty if h is.
+func (h *HashRing) GroupKeysByNode(keys []string) map[string][]string {
+	groups := make(map[string][]string)
+	for _, key := range keys {
+		if node, ok := h.GetNode(key); ok {
+			groups[node] = append(groups[node], key)
+		}
+	}
+	return groups
+}
+
+// FanOutError is returned by FanOut when calls failed.
+type FanOutError struct {
+	// Errors holds the error of every node whose call failed.
+	Errors map[string]error
+}
+
+func (e *FanOutError) Error() string {
+	nodes := make([]string, 0, len(e.Errors))
+	for node := range e.Errors {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	failures := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		failures = append(failures, node+": "+e.Errors[node].Error())
+	}
+	return fmt.Sprintf("hashring: %d nodes failed (%s)", len(nodes), strings.Join(failures, "; "))
+}
+
+// FanOut groups keys by node, see GroupKeysByNode, and calls fn once per
+// node with its keys, at most concurrency calls at once, or all at once if
+// concurrency is not positive: the scatter of a scatter-gather.
+//
+// A failing call does not stop the others. FanOut waits for all of them and
+// returns a *FanOutError with the errors of the nodes that failed, or
+// ErrEmptyRing if keys are left without a node.
+func (h *HashRing) FanOut(ctx context.Context, keys []string, concurrency int, fn func(ctx context.Context, node string, keys []string) error) error {
+	if len(keys) > 0 && (h == nil || len(h.sortedKeys) == 0) {
+		return ErrEmptyRing
+	}
+	groups := h.GroupKeysByNode(keys)
+	if concurrency <= 0 {
+		concurrency = len(groups)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := make(map[string]error)
+	sem := make(chan struct{}, concurrency)
+	nodes := nodesOfGroups(groups)
+	sort.Strings(nodes)
+	for _, node := range nodes {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			mu.Lock()
+			errs[node] = ctx.Err()
+			mu.Unlock()
+			continue
+		}
+		wg.Add(1)
+		go func(node string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(ctx, node, groups[node]); err != nil {
+				mu.Lock()
+				errs[node] = err
+				mu.Unlock()
+			}
+		}(node)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return &FanOutError{Errors: errs}
+	}
+	return nil
+}
+
+// nodesOfGroups returns the nodes of groups.
+func nodesOfGroups(groups map[string][]string) []string {
+	nodes := make([]string, 0, len(groups))
+	for node := range groups {
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
@@ -0,0 +1,86 @@
+package hashring
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupKeysByNode(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"})
+	keys := []string{"test", "test1", "test2", "test3", "test4", "test5", "aaaa", "bbbb"}
+	assert.Equal(t, map[string][]string{
+		"a": {"test", "test5", "bbbb"},
+		"b": {"test1", "test2", "aaaa"},
+		"c": {"test3", "test4"},
+	}, hashRing.GroupKeysByNode(keys))
+	assert.Empty(t, New(nil).GroupKeysByNode(keys))
+}
+
+func TestFanOut(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c", "d"})
+	var keys []string
+	for i := 0; i < 100; i++ {
+		keys = append(keys, strconv.Itoa(i))
+	}
+
+	for _, concurrency := range []int{0, 1, 2} {
+		var mu sync.Mutex
+		calls := make(map[string][]string)
+		running, maxRunning := 0, 0
+		err := hashRing.FanOut(context.Background(), keys, concurrency, func(ctx context.Context, node string, keys []string) error {
+			mu.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			calls[node] = keys
+			mu.Unlock()
+
+			mu.Lock()
+			defer mu.Unlock()
+			running--
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, hashRing.GroupKeysByNode(keys), calls)
+		if concurrency > 0 {
+			assert.LessOrEqual(t, maxRunning, concurrency)
+		}
+	}
+
+	// Failures are gathered by node; the other nodes still run.
+	boom := errors.New("boom")
+	var mu sync.Mutex
+	var called []string
+	err := hashRing.FanOut(context.Background(), keys, 1, func(ctx context.Context, node string, keys []string) error {
+		mu.Lock()
+		called = append(called, node)
+		mu.Unlock()
+		if node == "b" || node == "d" {
+			return boom
+		}
+		return nil
+	})
+	var fanOutErr *FanOutError
+	assert.ErrorAs(t, err, &fanOutErr)
+	assert.Equal(t, map[string]error{"b": boom, "d": boom}, fanOutErr.Errors)
+	assert.EqualError(t, err, "hashring: 2 nodes failed (b: boom; d: boom)")
+	assert.Equal(t, []string{"a", "b", "c", "d"}, called)
+
+	// A done context fails the nodes not started.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = hashRing.FanOut(ctx, keys, 1, func(ctx context.Context, node string, keys []string) error {
+		return ctx.Err()
+	})
+	assert.ErrorAs(t, err, &fanOutErr)
+	assert.Len(t, fanOutErr.Errors, 4)
+
+	assert.ErrorIs(t, New(nil).FanOut(context.Background(), keys, 1, nil), ErrEmptyRing)
+	assert.NoError(t, New(nil).FanOut(context.Background(), nil, 1, nil))
+}
@@ -0,0 +1,248 @@
+package hashring
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RingFlag implements flag.Value for a ring definition, so command-line tools
+// can accept a ring like "-ring=a:1,b:2,c:1" without custom parsing.
+//
+// Each comma separated entry is node[:weight], weight defaults to 1; a
+// weight of 0 makes the node a standby, see AddWeightedNode.
+// The weight is taken after the last colon, so a node whose name contains a
+// colon (e.g. "host:port") must give its weight explicitly: "10.0.0.1:11211:1".
+//
+// The nodes may be followed by options, each after a semicolon, as
+// name=value or, for switches, name alone:
+//
+//	a:1,b:2,c:1;hasher=xxhash64;64bit;replicas=160
+//
+// The options are:
+//
+//	replicas=N          WithReplicaFactor
+//	points-per-digest=N WithPointsPerDigest
+//	seed=N              WithSeed
+//	hasher=H            WithHasher, H one of md5 (the default), xxhash64, murmur3
+//	64bit               With64BitKeys
+//	ketama              WithKetama
+//	nginx               WithNginxConsistent
+//	boundary=B          WithBoundary, B one of after (the default), at-or-after
+//	target-imbalance=P  WithTargetImbalance
+//	max-share=F         WithMaxShare
+//	bounded-load=C      WithBoundedLoad
+//	read-spread=K       WithReadSpread
+//	name=S              WithName
+//
+// Set returns an error on any other option. Options taking code, such as
+// WithMetrics or WithClock, are passed to Ring instead. The limits of
+// WithMaxNodes and WithMaxPoints are not options of the flag; they are passed
+// to RingChecked, which returns their LimitError.
+//
+//	var ring hashring.RingFlag
+//	flag.Var(&ring, "ring", "ring definition, e.g. a:1,b:2;ketama")
+//	flag.Parse()
+//	server, _ := ring.Ring().GetNode("my_key")
+type RingFlag struct {
+	weights map[string]int
+	options []flagOption
+}
+
+// flagOption is an option of a RingFlag, value "" for a switch.
+type flagOption struct {
+	name, value string
+	opt         Option
+}
+
+// ringFlagOptions parses the options of RingFlag by name. Switches return
+// an error if given a value, other options if not.
+var ringFlagOptions = map[string]func(value string) (Option, error){
+	"replicas":          intFlagOption(WithReplicaFactor),
+	"points-per-digest": intFlagOption(WithPointsPerDigest),
+	"seed": func(value string) (Option, error) {
+		seed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return WithSeed(seed), nil
+	},
+	"hasher": func(value string) (Option, error) {
+		switch value {
+		case "md5":
+			return func(*config) {}, nil
+		case "xxhash64":
+			return WithHasher(XXHash64), nil
+		case "murmur3":
+			return WithHasher(Murmur3), nil
+		}
+		return nil, fmt.Errorf("unknown hasher %q", value)
+	},
+	"64bit":  switchFlagOption(With64BitKeys),
+	"ketama": switchFlagOption(WithKetama),
+	"nginx":  switchFlagOption(WithNginxConsistent),
+	"boundary": func(value string) (Option, error) {
+		switch value {
+		case "after":
+			return WithBoundary(BoundaryAfter), nil
+		case "at-or-after":
+			return WithBoundary(BoundaryAtOrAfter), nil
+		}
+		return nil, fmt.Errorf("unknown boundary %q", value)
+	},
+	"target-imbalance": floatFlagOption(WithTargetImbalance),
+	"max-share":        floatFlagOption(WithMaxShare),
+	"bounded-load":     floatFlagOption(WithBoundedLoad),
+	"read-spread":      intFlagOption(WithReadSpread),
+	"name": func(value string) (Option, error) {
+		if value == "" {
+			return nil, errors.New("empty name")
+		}
+		return WithName(value), nil
+	},
+}
+
+// intFlagOption parses the value of an option of an integer.
+func intFlagOption(with func(int) Option) func(string) (Option, error) {
+	return func(value string) (Option, error) {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, err
+		}
+		return with(n), nil
+	}
+}
+
+// floatFlagOption parses the value of an option of a float.
+func floatFlagOption(with func(float64) Option) func(string) (Option, error) {
+	return func(value string) (Option, error) {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, err
+		}
+		return with(f), nil
+	}
+}
+
+// switchFlagOption parses an option without a value.
+func switchFlagOption(with func() Option) func(string) (Option, error) {
+	return func(value string) (Option, error) {
+		if value != "" {
+			return nil, fmt.Errorf("takes no value, got %q", value)
+		}
+		return with(), nil
+	}
+}
+
+// String returns the ring definition in canonical form, nodes sorted by name,
+// followed by the options in the order given.
+func (f *RingFlag) String() string {
+	if f == nil || len(f.weights) == 0 && len(f.options) == 0 {
+		return ""
+	}
+	nodes := make([]string, 0, len(f.weights))
+	for node := range f.weights {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	entries := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		entries = append(entries, node+":"+strconv.Itoa(f.weights[node]))
+	}
+	definition := strings.Join(entries, ",")
+	for _, o := range f.options {
+		definition += ";" + o.name
+		if o.value != "" {
+			definition += "=" + o.value
+		}
+	}
+	return definition
+}
+
+// Set parses a ring definition. It replaces any previous value.
+func (f *RingFlag) Set(value string) error {
+	value, suffix, _ := strings.Cut(value, ";")
+	var options []flagOption
+	if suffix != "" {
+		for _, entry := range strings.Split(suffix, ";") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			name, optValue, _ := strings.Cut(entry, "=")
+			name, optValue = strings.TrimSpace(name), strings.TrimSpace(optValue)
+			parse, ok := ringFlagOptions[name]
+			if !ok {
+				return fmt.Errorf("hashring: unknown option %q", name)
+			}
+			opt, err := parse(optValue)
+			if err != nil {
+				return fmt.Errorf("hashring: invalid option %q: %v", entry, err)
+			}
+			options = append(options, flagOption{name: name, value: optValue, opt: opt})
+		}
+	}
+
+	weights := make(map[string]int)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		node, weight := entry, 1
+		if i := strings.LastIndex(entry, ":"); i >= 0 {
+			w, err := strconv.Atoi(entry[i+1:])
+			if err != nil {
+				return fmt.Errorf("hashring: invalid weight in %q: %v", entry, err)
+			}
+			node, weight = entry[:i], w
+		}
+
+		if node == "" {
+			return fmt.Errorf("hashring: empty node name in %q", entry)
+		}
+		if weight < 0 {
+			return fmt.Errorf("hashring: weight of %q must not be negative", node)
+		}
+		if _, ok := weights[node]; ok {
+			return fmt.Errorf("hashring: duplicate node %q", node)
+		}
+		weights[node] = weight
+	}
+
+	f.weights, f.options = weights, options
+	return nil
+}
+
+// Weights returns a copy of the parsed node weights.
+func (f *RingFlag) Weights() map[string]int {
+	weights := make(map[string]int, len(f.weights))
+	for node, weight := range f.weights {
+		weights[node] = weight
+	}
+	return weights
+}
+
+// Options returns the parsed options, in the order given.
+func (f *RingFlag) Options() []Option {
+	opts := make([]Option, 0, len(f.options))
+	for _, o := range f.options {
+		opts = append(opts, o.opt)
+	}
+	return opts
+}
+
+// Ring builds a HashRing from the parsed definition, with the parsed options
+// followed by opts.
+func (f *RingFlag) Ring(opts ...Option) *HashRing {
+	return NewWithWeights(f.Weights(), append(f.Options(), opts...)...)
+}
+
+// RingChecked builds a HashRing like Ring, or returns a LimitError.
+func (f *RingFlag) RingChecked(opts ...Option) (*HashRing, error) {
+	return NewWithWeightsChecked(f.Weights(), append(f.Options(), opts...)...)
+}
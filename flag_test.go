@@ -0,0 +1,113 @@
+package hashring
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRingFlag(t *testing.T) {
+	var ring RingFlag
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&ring, "ring", "ring definition")
+
+	err := fs.Parse([]string{"-ring=a:1,b:2,c:1"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"a": 1, "b": 2, "c": 1}, ring.Weights())
+	assert.Equal(t, "a:1,b:2,c:1", ring.String())
+
+	// Same placement as TestNewWeighted.
+	hashRing := ring.Ring()
+	expectNode(t, hashRing, "test", "b")
+	expectNode(t, hashRing, "test3", "c")
+	expectNode(t, hashRing, "bbbb", "a")
+}
+
+func TestRingFlagDefaultWeight(t *testing.T) {
+	var ring RingFlag
+	assert.NoError(t, ring.Set("c, a ,b,"))
+	assert.Equal(t, "a:1,b:1,c:1", ring.String())
+
+	expectNodesABC(t, ring.Ring())
+}
+
+func TestRingFlagHostPort(t *testing.T) {
+	var ring RingFlag
+	assert.NoError(t, ring.Set("10.0.0.1:11211:1,10.0.0.2:11211:3"))
+	assert.Equal(t, map[string]int{"10.0.0.1:11211": 1, "10.0.0.2:11211": 3}, ring.Weights())
+}
+
+func TestRingFlagInvalid(t *testing.T) {
+	for _, value := range []string{"a:x", "a:-1", ":1", "a,a"} {
+		var ring RingFlag
+		assert.Error(t, ring.Set(value), value)
+	}
+}
+
+func TestRingFlagStandby(t *testing.T) {
+	var ring RingFlag
+	assert.NoError(t, ring.Set("a:1,b:1,c:1,d:0"))
+	assert.Equal(t, map[string]int{"a": 1, "b": 1, "c": 1, "d": 0}, ring.Weights())
+	assert.Equal(t, "a:1,b:1,c:1,d:0", ring.String())
+	assert.Equal(t, New([]string{"a", "b", "c"}).sortedKeys, ring.Ring().sortedKeys)
+}
+
+func TestRingFlagZeroValue(t *testing.T) {
+	var ring RingFlag
+	assert.Equal(t, "", ring.String())
+	assert.Equal(t, 0, ring.Ring().Size())
+
+	var nilFlag *RingFlag
+	assert.Equal(t, "", nilFlag.String())
+}
+
+func TestRingFlagOptions(t *testing.T) {
+	var ring RingFlag
+	assert.NoError(t, ring.Set("a:1,b:2,c:1; hasher=xxhash64;64bit ;replicas=160;"))
+	assert.Equal(t, map[string]int{"a": 1, "b": 2, "c": 1}, ring.Weights())
+	assert.Equal(t, "a:1,b:2,c:1;hasher=xxhash64;64bit;replicas=160", ring.String())
+	assert.Len(t, ring.Options(), 3)
+	expectSameCircle(t, ring.Ring(), NewWithWeights(ring.Weights(), WithHasher(XXHash64), With64BitKeys(), WithReplicaFactor(160)))
+
+	// The canonical form parses back to the same ring.
+	var again RingFlag
+	assert.NoError(t, again.Set(ring.String()))
+	expectSameCircle(t, ring.Ring(), again.Ring())
+
+	for value, opts := range map[string][]Option{
+		"a,b;ketama":                       {WithKetama()},
+		"a,b;nginx":                        {WithNginxConsistent()},
+		"a,b;seed=42;hasher=md5":           {WithSeed(42)},
+		"a,b;boundary=at-or-after":         {WithBoundary(BoundaryAtOrAfter)},
+		"a,b;points-per-digest=4":          {WithPointsPerDigest(4)},
+		"a,b,c;target-imbalance=0.05":      {WithTargetImbalance(0.05)},
+		"a,b,c,d;max-share=0.3":            {WithMaxShare(0.3)},
+		"a,b;hasher=murmur3;read-spread=2": {WithHasher(Murmur3), WithReadSpread(2)},
+	} {
+		var ring RingFlag
+		assert.NoError(t, ring.Set(value), value)
+		expectSameCircle(t, ring.Ring(), NewWithWeights(ring.Weights(), opts...))
+	}
+
+	assert.NoError(t, ring.Set("a;name=cache;bounded-load=1.25"))
+	hashRing := ring.Ring(WithClock(NewManualClock(time.Unix(0, 0))))
+	assert.Equal(t, "cache", hashRing.config.name)
+	assert.Equal(t, "a:1", (&RingFlag{weights: ring.Weights()}).String())
+
+	// Set replaces the options too.
+	assert.NoError(t, ring.Set("a,b"))
+	assert.Empty(t, ring.Options())
+}
+
+func TestRingFlagInvalidOptions(t *testing.T) {
+	for _, value := range []string{
+		"a;unknown", "a;replicas", "a;replicas=x", "a;seed=-1", "a;hasher=sha1",
+		"a;64bit=yes", "a;boundary=before", "a;max-share=x", "a;name=",
+		"a;max-nodes=3", "a;max-points=100",
+	} {
+		var ring RingFlag
+		assert.Error(t, ring.Set(value), value)
+	}
+}
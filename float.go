@@ -0,0 +1,79 @@
+package hashring
+
+import "math"
+
+// floatWeightScale is the number of integer weight units per unit of float
+// weight in rings created by NewWithFloatWeights.
+const floatWeightScale = 1000
+
+// NewWithFloatWeights creates an instance of HashRing according to weights
+// map, with fractional weights such as 1.25 and 0.8.
+//
+// The ring keeps weights as integers in thousandths, so 1.25 is a weight
+// of 1250 to the integer methods, such as AddWeightedNode and Topology, and
+// AddNode adds a node of weight 1000. The virtual nodes follow the ratios of
+// the weights to a thousandth, finer than the virtual nodes can tell apart.
+// It panics if given WithMaxNodes or WithMaxPoints, like NewWithWeights.
+func NewWithFloatWeights(weights map[string]float64, opts ...Option) *HashRing {
+	config := newConfig(opts)
+	config.mustBeUnlimited("NewWithFloatWeights")
+	config.weightScale = floatWeightScale
+	ints := make(map[string]int, len(weights))
+	for node, weight := range weights {
+		ints[node] = scaleWeight(weight, config.weightUnit())
+	}
+	return newHashRing(nodesOf(ints), ints, config)
+}
+
+// weightUnit returns the integer weight of a node of weight 1, see
+// NewWithFloatWeights.
+func (c config) weightUnit() int {
+	if c.weightScale > 0 {
+		return c.weightScale
+	}
+	return 1
+}
+
+// scaleWeight returns float weight in integer units of unit. A positive
+// weight stays positive.
+func scaleWeight(weight float64, unit int) int {
+	scaled := int(math.Round(weight * float64(unit)))
+	if scaled <= 0 && weight > 0 {
+		return 1
+	}
+	return scaled
+}
+
+// FloatWeights returns the weights of the nodes of h, see
+// NewWithFloatWeights. For other rings, they are the integer weights.
+func (h *HashRing) FloatWeights() map[string]float64 {
+	h = h.orEmpty()
+	unit := float64(h.config.weightUnit())
+	weights := make(map[string]float64, len(h.weights))
+	for node, weight := range h.weights {
+		weights[node] = float64(weight) / unit
+	}
+	return weights
+}
+
+// AddFloatWeightedNode is AddWeightedNode with a fractional weight, see
+// NewWithFloatWeights. Other rings round it to an integer.
+func (h *HashRing) AddFloatWeightedNode(node string, weight float64) *HashRing {
+	return h.AddWeightedNode(node, scaleWeight(weight, h.orEmpty().config.weightUnit()))
+}
+
+// UpdateFloatWeightedNode is UpdateWeightedNode with a fractional weight, see
+// NewWithFloatWeights. Other rings round it to an integer.
+func (h *HashRing) UpdateFloatWeightedNode(node string, weight float64) *HashRing {
+	return h.UpdateWeightedNode(node, scaleWeight(weight, h.orEmpty().config.weightUnit()))
+}
+
+// UpdateWithFloatWeights is UpdateWithWeights with fractional weights, see
+// NewWithFloatWeights. Other rings round them to integers.
+func (h *HashRing) UpdateWithFloatWeights(weights map[string]float64) {
+	ints := make(map[string]int, len(weights))
+	for node, weight := range weights {
+		ints[node] = scaleWeight(weight, h.orEmpty().config.weightUnit())
+	}
+	h.UpdateWithWeights(ints)
+}
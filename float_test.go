@@ -0,0 +1,45 @@
+package hashring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWithFloatWeights(t *testing.T) {
+	hashRing := NewWithFloatWeights(map[string]float64{"a": 1.25, "b": 0.8, "c": 1})
+	assert.NoError(t, hashRing.Validate())
+	assert.Equal(t, map[string]float64{"a": 1.25, "b": 0.8, "c": 1}, hashRing.FloatWeights())
+	assert.Equal(t, Topology{"a": 1250, "b": 800, "c": 1000}, hashRing.Topology())
+	expectSameCircle(t, hashRing, NewWithWeights(map[string]int{"a": 125, "b": 80, "c": 100}))
+
+	// AddNode adds a node of weight 1.
+	added := hashRing.AddNode("d")
+	assert.Equal(t, 1.0, added.FloatWeights()["d"])
+	expectSameCircle(t, added, NewWithWeights(map[string]int{"a": 125, "b": 80, "c": 100, "d": 100}))
+	assert.Equal(t, 1.0, hashRing.Apply(func(tx *RingTx) { tx.AddNode("d") }).FloatWeights()["d"])
+
+	added = hashRing.AddFloatWeightedNode("d", 0.0001)
+	assert.Equal(t, 0.001, added.FloatWeights()["d"])
+	assert.Same(t, hashRing, hashRing.AddFloatWeightedNode("d", -1))
+
+	updated := hashRing.UpdateFloatWeightedNode("b", 1.5)
+	assert.Equal(t, 1.5, updated.FloatWeights()["b"])
+	expectSameCircle(t, updated, NewWithFloatWeights(map[string]float64{"a": 1.25, "b": 1.5, "c": 1}))
+
+	hashRing.UpdateWithFloatWeights(map[string]float64{"a": 2.5, "c": 0.4})
+	assert.Equal(t, map[string]float64{"a": 2.5, "c": 0.4}, hashRing.FloatWeights())
+	expectSameCircle(t, hashRing, NewWithWeights(map[string]int{"a": 25, "c": 4}))
+}
+
+func TestFloatWeightsIntegerRing(t *testing.T) {
+	hashRing := NewWithWeights(map[string]int{"a": 1, "b": 2})
+	assert.Equal(t, map[string]float64{"a": 1, "b": 2}, hashRing.FloatWeights())
+	assert.Equal(t, 2, hashRing.AddFloatWeightedNode("c", 1.6).weights["c"])
+	assert.Empty(t, (*HashRing)(nil).FloatWeights())
+}
+
+func TestFloatWeightsNginx(t *testing.T) {
+	hashRing := NewWithFloatWeights(map[string]float64{"a:80": 1, "b:80": 2}, WithNginxConsistent())
+	assert.Equal(t, map[string]int{"a:80": 160, "b:80": 320}, hashRing.factors)
+}
@@ -0,0 +1,61 @@
+package hashring
+
+import "math"
+
+// GetNodesFrom returns up to size nodes of candidates in ring order from
+// stringKey, like GetNodes restricted to candidates, e.g. to build a fallback
+// list among healthy nodes. The first is the node of GetNodeFrom. ok is false
+// if fewer than size candidates are on the ring.
+func (h *HashRing) GetNodesFrom(stringKey string, size int, candidates []string) (nodes []string, ok bool) {
+	if size <= 0 {
+		return nil, false
+	}
+	pos, ok := h.GetNodePos(stringKey)
+	if !ok {
+		return nil, false
+	}
+	allowed := make(map[string]bool, len(candidates))
+	for _, node := range candidates {
+		allowed[node] = true
+	}
+	return h.walkWhere(pos, size, func(node string) bool { return allowed[node] })
+}
+
+// GetNodeFromWeighted returns the node of nodes that stringKey belongs to,
+// like GetNodeFrom, choosing among the candidates in proportion to their
+// weights.
+//
+// GetNodeFrom takes the first candidate following stringKey on the ring, so a
+// candidate's share depends on where the points of the other candidates
+// happen to fall. GetNodeFromWeighted instead ranks the candidates by weighted
+// rendezvous hashing of stringKey and the node: each candidate gets its weight
+// over the weights of the candidates, and removing a candidate only moves the
+// keys it had.
+//
+// Nodes not on the ring, and standbys, are ignored.
+func (h *HashRing) GetNodeFromWeighted(stringKey string, nodes []string) (node string, ok bool) {
+	if h == nil || len(h.sortedKeys) == 0 {
+		return "", false
+	}
+
+	best := math.Inf(-1)
+	for _, n := range nodes {
+		weight, member := h.weights[n]
+		if !member || weight == 0 {
+			continue
+		}
+		score := h.rendezvousScore(stringKey, h.identity(n), weight)
+		if !ok || score > best || score == best && n < node {
+			node, best, ok = n, score, true
+		}
+	}
+	return node, ok
+}
+
+// rendezvousScore returns the weighted rendezvous score of node for key,
+// -weight/ln(u) for a hash u of node and key uniform in (0, 1). The node with
+// the highest score is chosen with probability proportional to its weight.
+func (h *HashRing) rendezvousScore(key, node string, weight int) float64 {
+	u := (float64(h.GenKey64(node+"\x00"+key)) + 0.5) / h.keyspace()
+	return -float64(weight) / math.Log(u)
+}
@@ -0,0 +1,78 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNodeFromWeighted(t *testing.T) {
+	hashRing := NewWithWeights(map[string]int{"a": 1, "b": 2, "c": 3, "d": 1})
+
+	candidates := []string{"a", "b", "c"}
+	counts := make(map[string]int)
+	for i := 0; i < 6000; i++ {
+		node, ok := hashRing.GetNodeFromWeighted(strconv.Itoa(i), candidates)
+		assert.True(t, ok)
+		counts[node]++
+	}
+	assert.InDelta(t, 1000, counts["a"], 150)
+	assert.InDelta(t, 2000, counts["b"], 200)
+	assert.InDelta(t, 3000, counts["c"], 200)
+
+	// Dropping a candidate only moves its keys.
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		before, _ := hashRing.GetNodeFromWeighted(key, candidates)
+		after, _ := hashRing.GetNodeFromWeighted(key, []string{"a", "c"})
+		if before != "b" {
+			assert.Equal(t, before, after, key)
+		}
+	}
+
+	node, ok := hashRing.GetNodeFromWeighted("test", []string{"x", "d"})
+	assert.True(t, ok)
+	assert.Equal(t, "d", node)
+
+	_, ok = hashRing.GetNodeFromWeighted("test", []string{"x"})
+	assert.False(t, ok)
+	_, ok = hashRing.GetNodeFromWeighted("test", nil)
+	assert.False(t, ok)
+	_, ok = (*HashRing)(nil).GetNodeFromWeighted("test", candidates)
+	assert.False(t, ok)
+}
+
+func TestGetNodesFrom(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c", "d", "e"})
+	candidates := []string{"b", "d", "e", "unknown"}
+	for i := 0; i < 200; i++ {
+		key := strconv.Itoa(i)
+		all, _ := hashRing.GetNodes(key, 5)
+		var want []string
+		for _, node := range all {
+			if node == "b" || node == "d" || node == "e" {
+				want = append(want, node)
+			}
+		}
+
+		nodes, ok := hashRing.GetNodesFrom(key, 2, candidates)
+		assert.True(t, ok)
+		assert.Equal(t, want[:2], nodes)
+		first, _ := hashRing.GetNodeFrom(key, candidates)
+		assert.Equal(t, first, nodes[0])
+
+		// Fewer candidates than size are all returned.
+		nodes, ok = hashRing.GetNodesFrom(key, 4, candidates)
+		assert.False(t, ok)
+		assert.Equal(t, want, nodes)
+	}
+
+	_, ok := hashRing.GetNodesFrom("key", 0, candidates)
+	assert.False(t, ok)
+	nodes, ok := hashRing.GetNodesFrom("key", 1, nil)
+	assert.False(t, ok)
+	assert.Empty(t, nodes)
+	_, ok = New(nil).GetNodesFrom("key", 1, candidates)
+	assert.False(t, ok)
+}
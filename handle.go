@@ -0,0 +1,68 @@
+package hashring
+
+import "time"
+
+// KeyHandle is a key hashed once by PrepareKey, so pipelines resolving the
+// same key several times, to route, retry and check replicas, hash it once.
+//
+// A handle holds the position of the key on the ring it was prepared by, so
+// it is only valid on rings hashing keys alike: that ring, rings derived from
+// it by AddNode, RemoveNode and friends, and rings of the same hashing
+// options, e.g. WithHasher and With64BitKeys.
+type KeyHandle struct {
+	key  string
+	hash HashKey64
+}
+
+// PrepareKey hashes stringKey into a KeyHandle.
+func (h *HashRing) PrepareKey(stringKey string) KeyHandle {
+	return KeyHandle{key: stringKey, hash: h.GenKey64(stringKey)}
+}
+
+// Key returns the key of k.
+func (k KeyHandle) Key() string {
+	return k.key
+}
+
+// Hash returns the position of the key of k on ring, see GenKey64.
+func (k KeyHandle) Hash() HashKey64 {
+	return k.hash
+}
+
+// GetNodeHandle returns the node that the key of k belongs to, like GetNode,
+// interceptors and latency sampling included.
+func (h *HashRing) GetNodeHandle(k KeyHandle) (node string, ok bool) {
+	if h.intercepted() {
+		defer h.afterLookup(k.key, h.beforeLookup(k.key), &node, &ok)
+	}
+	if h.sampled() {
+		defer h.observeLookup(time.Now())
+	}
+	if h.single() {
+		return h.lookupAt(0), true
+	}
+	pos, ok := h.keyPos(k.hash)
+	if !ok {
+		return "", false
+	}
+	return h.lookupAt(pos), true
+}
+
+// GetNodesHandle returns size nodes for the key of k, see GetNodes.
+func (h *HashRing) GetNodesHandle(k KeyHandle, size int) (nodes []string, ok bool) {
+	if h.intercepted() {
+		defer h.afterLookups(k.key, h.beforeLookup(k.key), &nodes, &ok)
+	}
+	if size > h.Size() || size <= 0 {
+		return nil, false
+	}
+	if h.sampled() {
+		defer h.observeLookup(time.Now())
+	}
+
+	pos, ok := h.keyPos(k.hash)
+	if !ok {
+		return nil, false
+	}
+	return h.nodesAt(pos, size)
+}
@@ -0,0 +1,73 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyHandle(t *testing.T) {
+	for _, opts := range [][]Option{nil, {With64BitKeys(), WithHasher(XXHash64)}, {WithBoundary(BoundaryAtOrAfter)}} {
+		hashRing := New([]string{"a", "b", "c"}, opts...)
+		derived := hashRing.AddNode("d")
+		for i := 0; i < 200; i++ {
+			key := strconv.Itoa(i)
+			k := hashRing.PrepareKey(key)
+			assert.Equal(t, key, k.Key())
+			assert.Equal(t, hashRing.GenKey64(key), k.Hash())
+
+			// Derived rings hash alike, so the handle stays valid.
+			for _, ring := range []*HashRing{hashRing, derived} {
+				node, _ := ring.GetNode(key)
+				byHandle, ok := ring.GetNodeHandle(k)
+				assert.True(t, ok)
+				assert.Equal(t, node, byHandle)
+				nodes, _ := ring.GetNodes(key, 2)
+				byHandles, ok := ring.GetNodesHandle(k, 2)
+				assert.True(t, ok)
+				assert.Equal(t, nodes, byHandles)
+			}
+		}
+	}
+
+	hashRing := New([]string{"a"})
+	node, ok := hashRing.GetNodeHandle(hashRing.PrepareKey("key"))
+	assert.True(t, ok)
+	assert.Equal(t, "a", node)
+	_, ok = hashRing.GetNodesHandle(hashRing.PrepareKey("key"), 2)
+	assert.False(t, ok)
+	_, ok = New(nil).GetNodeHandle(hashRing.PrepareKey("key"))
+	assert.False(t, ok)
+
+	k := hashRing.PrepareKey("key")
+	allocs := testing.AllocsPerRun(100, func() { hashRing.GetNodeHandle(k) })
+	assert.Equal(t, 0.0, allocs)
+}
+
+func TestKeyHandleHooks(t *testing.T) {
+	var r callRecorder
+	hashRing := New([]string{"a", "b", "c"}, WithInterceptors(r.interceptor("i")))
+	k := hashRing.PrepareKey("test")
+	r.reset()
+	node, ok := hashRing.GetNodeHandle(k)
+	assert.Equal(t, []string{"i before test", "i after test"}, r.calls)
+	assert.Equal(t, LookupEvent{Key: "test", Nodes: []string{node}, OK: ok, Duration: r.lookups[0].Duration}, r.lookups[0])
+	nodes, _ := hashRing.GetNodesHandle(k, 2)
+	assert.Equal(t, nodes, r.lookups[1].Nodes)
+	_, ok = hashRing.GetNodesHandle(k, 4)
+	assert.False(t, ok)
+	assert.False(t, r.lookups[2].OK)
+
+	var warnings []HealthWarning
+	hashRing = New([]string{"a", "b", "c"}, WithLatencyBudget(time.Nanosecond, func(w HealthWarning) {
+		warnings = append(warnings, w)
+	}))
+	k = hashRing.PrepareKey("test")
+	for i := 0; i < latencySampleEvery*latencyWindow/2; i++ {
+		hashRing.GetNodeHandle(k)
+		hashRing.GetNodesHandle(k, 2)
+	}
+	assert.Len(t, warnings, 1)
+}
@@ -0,0 +1,207 @@
+package hashring
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Handoff moves ranges of the keyspace of a ring between nodes in two
+// phases, so stateful systems hand a range over without a window of double
+// or missing ownership: PrepareTransfer announces the move while lookups
+// keep returning the old owner, e.g. while it streams the range to the new
+// one, and CommitTransfer switches lookups of the range to the new owner at
+// once. AbortTransfer gives the move up.
+//
+// A Handoff implements NodeLocator, and is safe for concurrent use. Its ring
+// does not change; ranges are moved on top of it.
+type Handoff struct {
+	ring *HashRing
+
+	mu        sync.RWMutex
+	transfers []*Transfer // prepared, in order of preparation.
+	overrides []override  // committed, sorted and disjoint.
+}
+
+// override is a range owned by node To after the transfers committed for it.
+type override struct {
+	Range
+	To string
+}
+
+// Transfer is the move of Range from node From to node To, see Handoff.
+type Transfer struct {
+	Range
+	From, To string
+}
+
+// NewHandoff creates a Handoff moving ranges of ring.
+func NewHandoff(ring *HashRing) *Handoff {
+	return &Handoff{ring: ring.orEmpty()}
+}
+
+// Ring returns the ring of h.
+func (h *Handoff) Ring() *HashRing {
+	return h.ring
+}
+
+// PrepareTransfer prepares the move of r from node from to node to. It
+// returns an error if from does not own all of r, to is not on the ring, or
+// r overlaps another prepared transfer.
+func (h *Handoff) PrepareTransfer(r Range, from, to string) (*Transfer, error) {
+	if r.Start > r.End || r.End > h.ring.maxHash() {
+		return nil, fmt.Errorf("hashring: invalid range [%d, %d]", r.Start, r.End)
+	}
+	if _, ok := h.ring.weights[to]; !ok {
+		return nil, fmt.Errorf("hashring: node %q is not on the ring", to)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, t := range h.transfers {
+		if t.Start <= r.End && r.Start <= t.End {
+			return nil, fmt.Errorf("hashring: range [%d, %d] overlaps the transfer of [%d, %d]", r.Start, r.End, t.Start, t.End)
+		}
+	}
+	if !h.ownsLocked(r, from) {
+		return nil, fmt.Errorf("hashring: node %q does not own all of [%d, %d]", from, r.Start, r.End)
+	}
+	t := &Transfer{Range: r, From: from, To: to}
+	h.transfers = append(h.transfers, t)
+	return t, nil
+}
+
+// ownsLocked reports whether node owns every hash of r, transfers committed
+// so far included.
+func (h *Handoff) ownsLocked(r Range, node string) bool {
+	// Owners only change at the bounds of the ring's runs and of transfers.
+	starts := []HashKey64{r.Start}
+	for _, owned := range h.ring.OwnershipTable(1) {
+		if r.Contains(owned.Start) {
+			starts = append(starts, owned.Start)
+		}
+	}
+	for _, o := range h.overrides {
+		for _, bound := range []HashKey64{o.Start, o.End + 1} {
+			if bound != 0 && r.Contains(bound) {
+				starts = append(starts, bound)
+			}
+		}
+	}
+	for _, hash := range starts {
+		if owner, _ := h.ownerLocked(hash); owner != node {
+			return false
+		}
+	}
+	return true
+}
+
+// errTransferDone is returned when committing or aborting a transfer twice.
+var errTransferDone = errors.New("hashring: transfer already committed or aborted")
+
+// CommitTransfer makes t.To the owner of the range of t for lookups from now
+// on.
+func (h *Handoff) CommitTransfer(t *Transfer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.removeLocked(t) {
+		return errTransferDone
+	}
+	h.overrideLocked(t)
+	return nil
+}
+
+// overrideLocked merges the committed transfer t into the overrides, cutting
+// the parts of earlier ones that t moves again.
+func (h *Handoff) overrideLocked(t *Transfer) {
+	o := h.overrides
+	i := sort.Search(len(o), func(i int) bool { return o[i].End >= t.Start })
+	j := sort.Search(len(o), func(i int) bool { return o[i].Start > t.End })
+	merged := make([]override, 0, len(o)+2)
+	merged = append(merged, o[:i]...)
+	if i < j && o[i].Start < t.Start {
+		merged = append(merged, override{Range{o[i].Start, t.Start - 1}, o[i].To})
+	}
+	merged = append(merged, override{t.Range, t.To})
+	if i < j && o[j-1].End > t.End {
+		merged = append(merged, override{Range{t.End + 1, o[j-1].End}, o[j-1].To})
+	}
+	h.overrides = append(merged, o[j:]...)
+}
+
+// AbortTransfer gives up the prepared transfer t; From keeps the range.
+func (h *Handoff) AbortTransfer(t *Transfer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.removeLocked(t) {
+		return errTransferDone
+	}
+	return nil
+}
+
+// removeLocked removes t from the prepared transfers, and reports whether it
+// was one.
+func (h *Handoff) removeLocked(t *Transfer) bool {
+	for i, prepared := range h.transfers {
+		if prepared == t {
+			h.transfers = append(h.transfers[:i:i], h.transfers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Pending returns the transfers prepared but not committed, in order of
+// preparation.
+func (h *Handoff) Pending() []Transfer {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	var pending []Transfer
+	for _, t := range h.transfers {
+		pending = append(pending, *t)
+	}
+	return pending
+}
+
+// ownerLocked returns the node of hash: the target of the last transfer
+// committed for it, or its node on the ring.
+func (h *Handoff) ownerLocked(hash HashKey64) (node string, transferred bool) {
+	o := h.overrides
+	if i := sort.Search(len(o), func(i int) bool { return o[i].End >= hash }); i < len(o) && o[i].Start <= hash {
+		return o[i].To, true
+	}
+	return h.ring.ownerOf(hash), false
+}
+
+// GetNode returns the node stringKey belongs to, taking committed transfers
+// into account.
+func (h *Handoff) GetNode(stringKey string) (node string, ok bool) {
+	if len(h.ring.sortedKeys) == 0 {
+		return "", false
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	node, _ = h.ownerLocked(h.ring.GenKey64(stringKey))
+	return node, true
+}
+
+// GetNodes returns size nodes for stringKey like HashRing.GetNodes: the node
+// of GetNode first, followed by the other nodes in ring order.
+func (h *Handoff) GetNodes(stringKey string, size int) (nodes []string, ok bool) {
+	if size > h.ring.Size() || size <= 0 {
+		return nil, false
+	}
+	pos, ok := h.ring.GetNodePos(stringKey)
+	if !ok {
+		return nil, false
+	}
+	h.mu.RLock()
+	owner, transferred := h.ownerLocked(h.ring.GenKey64(stringKey))
+	h.mu.RUnlock()
+	if !transferred {
+		return h.ring.walk(pos, size)
+	}
+	rest, ok := h.ring.walkWhere(pos, size-1, func(node string) bool { return node != owner })
+	return append([]string{owner}, rest...), ok
+}
@@ -0,0 +1,156 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandoff(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"})
+	h := NewHandoff(hashRing)
+	var locator NodeLocator = h
+	r := hashRing.KeyRangesForNode("a")[0]
+
+	// Keys of the range, and others.
+	var inside, outside []string
+	for i := 0; i < 10000 && len(inside) < 20; i++ {
+		key := strconv.Itoa(i)
+		if r.Contains(hashRing.GenKey64(key)) {
+			inside = append(inside, key)
+		} else if len(outside) < 50 {
+			outside = append(outside, key)
+		}
+	}
+	assert.NotEmpty(t, inside)
+
+	transfer, err := h.PrepareTransfer(r, "a", "c")
+	assert.NoError(t, err)
+	assert.Equal(t, []Transfer{{Range: r, From: "a", To: "c"}}, h.Pending())
+	for _, key := range inside {
+		expectLocated(t, h, key, "a")
+	}
+
+	assert.NoError(t, h.CommitTransfer(transfer))
+	assert.Empty(t, h.Pending())
+	for _, key := range inside {
+		expectLocated(t, locator, key, "c")
+		nodes, ok := h.GetNodes(key, 3)
+		assert.True(t, ok)
+		assert.Equal(t, "c", nodes[0])
+		assert.ElementsMatch(t, []string{"a", "b", "c"}, nodes)
+	}
+	for _, key := range outside {
+		want, _ := hashRing.GetNode(key)
+		expectLocated(t, h, key, want)
+		wantNodes, _ := hashRing.GetNodes(key, 2)
+		nodes, ok := h.GetNodes(key, 2)
+		assert.True(t, ok)
+		assert.Equal(t, wantNodes, nodes, key)
+	}
+	assert.Error(t, h.CommitTransfer(transfer))
+	assert.Error(t, h.AbortTransfer(transfer))
+
+	// The range is c's now, and can move on.
+	_, err = h.PrepareTransfer(r, "a", "b")
+	assert.Error(t, err)
+	transfer, err = h.PrepareTransfer(r, "c", "b")
+	assert.NoError(t, err)
+	assert.NoError(t, h.CommitTransfer(transfer))
+	for _, key := range inside {
+		expectLocated(t, h, key, "b")
+	}
+	assert.Same(t, hashRing, h.Ring())
+}
+
+func TestHandoffAbort(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"})
+	h := NewHandoff(hashRing)
+	r := hashRing.KeyRangesForNode("b")[1]
+
+	transfer, err := h.PrepareTransfer(r, "b", "a")
+	assert.NoError(t, err)
+	_, err = h.PrepareTransfer(Range{Start: r.End, End: r.End}, "b", "c")
+	assert.Error(t, err, "overlaps a prepared transfer")
+	assert.NoError(t, h.AbortTransfer(transfer))
+	assert.Empty(t, h.Pending())
+	assert.Error(t, h.CommitTransfer(transfer))
+
+	for i := 0; i < 300; i++ {
+		key := strconv.Itoa(i)
+		want, _ := hashRing.GetNode(key)
+		expectLocated(t, h, key, want)
+	}
+	_, err = h.PrepareTransfer(Range{Start: r.End, End: r.End}, "b", "c")
+	assert.NoError(t, err)
+}
+
+func TestHandoffPrepareErrors(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"})
+	h := NewHandoff(hashRing)
+	a := hashRing.KeyRangesForNode("a")[0]
+
+	_, err := h.PrepareTransfer(Range{Start: a.End, End: a.Start}, "a", "b")
+	assert.Error(t, err)
+	_, err = h.PrepareTransfer(Range{Start: 0, End: hashRing.maxHash() + 1}, "a", "b")
+	assert.Error(t, err)
+	_, err = h.PrepareTransfer(a, "a", "unknown")
+	assert.Error(t, err)
+	_, err = h.PrepareTransfer(a, "b", "c")
+	assert.Error(t, err)
+	_, err = h.PrepareTransfer(Range{Start: a.Start, End: a.End + 1}, "a", "b")
+	assert.Error(t, err)
+
+	empty := NewHandoff(nil)
+	_, ok := empty.GetNode("key")
+	assert.False(t, ok)
+	_, ok = empty.GetNodes("key", 1)
+	assert.False(t, ok)
+}
+
+// expectLocated checks that locator finds node for key.
+func expectLocated(t *testing.T, locator NodeLocator, key string, node string) {
+	t.Helper()
+	got, ok := locator.GetNode(key)
+	assert.True(t, ok, key)
+	assert.Equal(t, node, got, key)
+}
+
+func TestHandoffOverlappingCommits(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"})
+	h := NewHandoff(hashRing)
+	r := hashRing.KeyRangesForNode("a")[0]
+	mid := r.Start + (r.End-r.Start)/2
+
+	commit := func(r Range, from, to string) {
+		transfer, err := h.PrepareTransfer(r, from, to)
+		assert.NoError(t, err)
+		assert.NoError(t, h.CommitTransfer(transfer))
+	}
+	commit(r, "a", "c")
+	commit(Range{mid - 1, mid + 1}, "c", "b")
+	commit(Range{r.Start, r.Start}, "c", "a")
+
+	assert.Equal(t, []override{
+		{Range{r.Start, r.Start}, "a"},
+		{Range{r.Start + 1, mid - 2}, "c"},
+		{Range{mid - 1, mid + 1}, "b"},
+		{Range{mid + 2, r.End}, "c"},
+	}, h.overrides)
+	for hash, want := range map[HashKey64]string{
+		r.Start: "a", r.Start + 1: "c", mid: "b", mid + 2: "c", r.End: "c", r.End + 1: hashRing.ownerOf(r.End + 1),
+	} {
+		owner, _ := h.ownerLocked(hash)
+		assert.Equal(t, want, owner, hash)
+	}
+
+	// A transfer covering several replaces them.
+	commit(Range{mid + 2, r.End}, "c", "b")
+	commit(Range{mid - 1, r.End}, "b", "a")
+	assert.Equal(t, []override{
+		{Range{r.Start, r.Start}, "a"},
+		{Range{r.Start + 1, mid - 2}, "c"},
+		{Range{mid - 1, r.End}, "a"},
+	}, h.overrides)
+}
@@ -0,0 +1,86 @@
+package hashring
+
+import (
+	"bytes"
+	"crypto/md5"
+	"hash"
+	"sync"
+)
+
+// Hashable is a key that writes itself into a hasher, so composite keys
+// (tenant + object + version) can be looked up without concatenating them
+// into a throwaway string first.
+//
+// Writing the same bytes as a string key places the key where that string
+// would be placed:
+//
+//	func (k ObjectKey) HashKeyInto(h hash.Hash) {
+//		io.WriteString(h, k.Tenant)
+//		io.WriteString(h, "/")
+//		io.WriteString(h, k.Object)
+//	}
+type Hashable interface {
+	HashKeyInto(h hash.Hash)
+}
+
+var md5Pool = sync.Pool{
+	New: func() interface{} { return md5.New() },
+}
+
+// GenKeyFor generates HashKey of key.
+func (h *HashRing) GenKeyFor(key Hashable) HashKey {
+	return h.narrow(h.keyFor(key))
+}
+
+// keyFor returns the position of key on ring.
+func (h *HashRing) keyFor(key Hashable) HashKey64 {
+	if h != nil && h.config.hasher != nil {
+		d := &hasherHash{hasher: h.config.hasher}
+		key.HashKeyInto(d)
+		return h.position(d.Sum(nil))
+	}
+
+	d := md5Pool.Get().(hash.Hash)
+	d.Reset()
+	key.HashKeyInto(d)
+
+	var bKey [md5.Size]byte
+	d.Sum(bKey[:0])
+	md5Pool.Put(d)
+	return h.position(bKey[:])
+}
+
+// GetNodeFor returns the node that key belongs to.
+func (h *HashRing) GetNodeFor(key Hashable) (node string, ok bool) {
+	if h.single() {
+		return h.lookupAt(0), true
+	}
+	pos, ok := h.keyPos(h.keyFor(key))
+	if !ok {
+		return "", false
+	}
+	return h.lookupAt(pos), true
+}
+
+// GetNodesFor returns size nodes for key, see GetNodes.
+func (h *HashRing) GetNodesFor(key Hashable, size int) (nodes []string, ok bool) {
+	if size > h.Size() || size <= 0 {
+		return nil, false
+	}
+
+	pos, ok := h.keyPos(h.keyFor(key))
+	if !ok {
+		return nil, false
+	}
+	return h.nodesAt(pos, size)
+}
+
+// hasherHash adapts a Hasher to hash.Hash by buffering what is written.
+type hasherHash struct {
+	bytes.Buffer
+	hasher Hasher
+}
+
+func (d *hasherHash) Sum(b []byte) []byte { return append(b, d.hasher.Hash(d.Bytes())...) }
+func (d *hasherHash) Size() int           { return len(d.hasher.Hash(nil)) }
+func (d *hasherHash) BlockSize() int      { return 1 }
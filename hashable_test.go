@@ -0,0 +1,52 @@
+package hashring
+
+import (
+	"hash"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type objectKey struct {
+	tenant  string
+	object  string
+	version int
+}
+
+func (k objectKey) HashKeyInto(h hash.Hash) {
+	io.WriteString(h, k.tenant)
+	io.WriteString(h, "/")
+	io.WriteString(h, k.object)
+	io.WriteString(h, "@")
+	io.WriteString(h, strconv.Itoa(k.version))
+}
+
+func TestGetNodeFor(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"})
+	for i := 0; i < 100; i++ {
+		key := objectKey{tenant: "t" + strconv.Itoa(i%7), object: strconv.Itoa(i), version: i % 3}
+		str := key.tenant + "/" + key.object + "@" + strconv.Itoa(key.version)
+
+		assert.Equal(t, hashRing.GenKey(str), hashRing.GenKeyFor(key))
+
+		expected, _ := hashRing.GetNode(str)
+		node, ok := hashRing.GetNodeFor(key)
+		assert.True(t, ok)
+		assert.Equal(t, expected, node)
+
+		expectedNodes, _ := hashRing.GetNodes(str, 2)
+		nodes, ok := hashRing.GetNodesFor(key, 2)
+		assert.True(t, ok)
+		assert.Equal(t, expectedNodes, nodes)
+	}
+}
+
+func TestGetNodeForEmpty(t *testing.T) {
+	hashRing := New([]string{})
+	_, ok := hashRing.GetNodeFor(objectKey{})
+	assert.False(t, ok)
+	_, ok = hashRing.GetNodesFor(objectKey{}, 1)
+	assert.False(t, ok)
+}
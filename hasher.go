@@ -0,0 +1,31 @@
+package hashring
+
+// Hasher computes the digests that keys and virtual nodes are placed by.
+//
+// Digests must be at least 4 bytes long. The HashKey of a key is made of its
+// first 4 bytes, and a virtual node places a point for every 4 bytes of its
+// digest, up to 3, as with md5.
+type Hasher interface {
+	Hash(key []byte) []byte
+}
+
+// HasherFunc is a function implementing Hasher.
+type HasherFunc func(key []byte) []byte
+
+// Hash implements Hasher.
+func (f HasherFunc) Hash(key []byte) []byte {
+	return f(key)
+}
+
+// WithHasher replaces md5 with hasher to place keys and virtual nodes, e.g.
+// to match another system or for a faster hash.
+//
+//	ring := hashring.New(nodes, hashring.WithHasher(hashring.HasherFunc(func(key []byte) []byte {
+//		sum := sha256.Sum256(key)
+//		return sum[:]
+//	})))
+func WithHasher(hasher Hasher) Option {
+	return func(c *config) {
+		c.hasher = hasher
+	}
+}
@@ -0,0 +1,66 @@
+package hashring
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash/fnv"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var md5Hasher = HasherFunc(func(key []byte) []byte {
+	sum := md5.Sum(key)
+	return sum[:]
+})
+
+var sha256Hasher = HasherFunc(func(key []byte) []byte {
+	sum := sha256.Sum256(key)
+	return sum[:]
+})
+
+func TestWithHasherMD5(t *testing.T) {
+	nodes := []string{"a", "b", "c"}
+	hashRing := New(nodes)
+	custom := New(nodes, WithHasher(md5Hasher))
+	expectSameCircle(t, custom, hashRing)
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		assert.Equal(t, hashRing.GenKey(key), custom.GenKey(key))
+		assert.Equal(t, hashRing.GenKeyUint64(uint64(i)), custom.GenKeyUint64(uint64(i)))
+		objKey := objectKey{tenant: "t", object: key}
+		assert.Equal(t, hashRing.GenKeyFor(objKey), custom.GenKeyFor(objKey))
+	}
+}
+
+func TestWithHasher(t *testing.T) {
+	nodes := []string{"a", "b", "c"}
+	hashRing := New(nodes, WithHasher(sha256Hasher))
+	assert.NoError(t, hashRing.Validate())
+	assert.Len(t, hashRing.sortedKeys, 3*defaultReplicas*3)
+
+	sum := sha256.Sum256([]byte("test"))
+	assert.Equal(t, hashVal(sum[0:4]), hashRing.GenKey("test"))
+	assert.NotEqual(t, New(nodes).sortedKeys, hashRing.sortedKeys)
+
+	// Derived rings keep the hasher.
+	added := hashRing.AddNode("d")
+	expectSameCircle(t, added, New([]string{"a", "b", "c", "d"}, WithHasher(sha256Hasher)))
+	assert.Empty(t, added.HealthReport())
+}
+
+func TestWithHasherShortDigest(t *testing.T) {
+	fnv64 := HasherFunc(func(key []byte) []byte {
+		h := fnv.New64a()
+		h.Write(key)
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], h.Sum64())
+		return b[:]
+	})
+	hashRing := New([]string{"a", "b"}, WithHasher(fnv64))
+	assert.Len(t, hashRing.sortedKeys, 2*defaultReplicas*2)
+	_, ok := hashRing.GetNode("test")
+	assert.True(t, ok)
+}
@@ -1,15 +1,23 @@
 package hashring
 
 import (
+	"context"
 	"crypto/md5"
+	"encoding/binary"
+	"errors"
 	"math"
 	"sort"
 	"strconv"
+	"time"
 )
 
 // HashKey represents hash value
 type HashKey uint32
 
+// HashKey64 is a position on the ring. It holds a HashKey unless the ring
+// uses 64-bit keys, see With64BitKeys.
+type HashKey64 uint64
+
 // HashKeyOrder implements sort.Interface for []HashKey.
 type HashKeyOrder []HashKey
 
@@ -19,6 +27,9 @@ func (h HashKeyOrder) Less(i, j int) bool { return h[i] < h[j] }
 
 // HashRing provides consistent hashing.
 //
+// The zero value and a nil *HashRing are empty rings: lookups return not
+// found, and AddNode returns a ring with the node.
+//
 // Suppose we have 8 nodes: (nodeName:HashKey)
 //     	n1:k1, n2:k2, n3:k3, n4:k4, n5:k5, n6:k6, n7:k7, n8:k8
 // What stored on ring is:
@@ -26,52 +37,115 @@ func (h HashKeyOrder) Less(i, j int) bool { return h[i] < h[j] }
 //   	↑				  ↓
 // 		k8 <- k7 <- k6 <- k5
 // Where the keys are stored in ascending order, which means $k1 < k2 < k3 < ...$
-// Given a key with HashKey kk, it belongs to the smallest node whose HashKey is larger than kk.
+// Given a key with HashKey kk, it belongs to the smallest node whose HashKey is larger than kk
+// (or equal, see WithBoundary).
 // 	- Suppose that $k5 < kk < k6$, then kk belongs to n6.
 // 	- If $kk > k8$, it belongs to n1.
 // Actually, one node has multiple keys(virtual nodes) on ring for more balanced key distribution.
 type HashRing struct {
-	ring       map[HashKey]string // HashKey to node. It includes virtual nodes.
-	sortedKeys []HashKey          // sorted HashKeys on ring. for binary search.
+	sortedKeys []HashKey64 // sorted HashKeys on ring, including virtual nodes. for binary search.
+	owners     []int32     // node of each of sortedKeys, as an index into names.
+	names      []string    // node table of owners.
 	nodes      []string
 	weights    map[string]int
+	config     config
+	replicas   int               // virtual nodes of a node with average weight.
+	factors    map[string]int    // number of virtual nodes of each node.
+	skips      []int32           // points from each point to the next of another node, see walk.
+	prev       *HashRing         // ring before the last change, without its own prev.
+	changed    time.Time         // time of the last change, zero if none, see GetNodeStale.
+	tombstones []tombstone       // soft-removed nodes, see RemoveNodeSoft.
+	aliases    map[string]string // node to the name it places its virtual nodes by, see RenameNode.
+	collisions int               // virtual node points placed on an existing point, see Collisions.
 }
 
 // New creates an instance of HashRing from nodes.
-func New(nodes []string) *HashRing {
+// A node listed n times has n times the weight of a node listed once, so
+// duplicates place the same ring whatever their order.
+// New panics if given WithMaxNodes or WithMaxPoints, see NewChecked.
+func New(nodes []string, opts ...Option) *HashRing {
+	config := newConfig(opts)
+	config.mustBeUnlimited("New")
+	unique, weights := countNodes(nodes, config.weightUnit())
+	return newHashRing(unique, weights, config)
+}
+
+// countNodes returns the unique nodes of nodes, in order of first listing,
+// each with weight unit times the times it is listed.
+func countNodes(nodes []string, unit int) ([]string, map[string]int) {
+	unique := make([]string, 0, len(nodes))
+	weights := make(map[string]int, len(nodes))
+	for _, node := range nodes {
+		if _, ok := weights[node]; !ok {
+			unique = append(unique, node)
+		}
+		weights[node] += unit
+	}
+	return unique, weights
+}
+
+// NewWithWeights creates an instance of HashRing according to weights map.
+// It panics if given WithMaxNodes or WithMaxPoints, see NewWithWeightsChecked.
+func NewWithWeights(weights map[string]int, opts ...Option) *HashRing {
+	config := newConfig(opts)
+	config.mustBeUnlimited("NewWithWeights")
+	return newHashRing(nodesOf(weights), weights, config)
+}
+
+func newHashRing(nodes []string, weights map[string]int, config config) *HashRing {
 	hashRing := &HashRing{
-		ring:       make(map[HashKey]string),
-		sortedKeys: make([]HashKey, 0),
-		nodes:      nodes,
-		weights:    make(map[string]int),
+		nodes:   nodes,
+		weights: weights,
+		config:  config,
 	}
 	hashRing.generateCircle()
 	return hashRing
 }
 
-// NewWithWeights creates an instance of HashRing according to weights map.
-func NewWithWeights(weights map[string]int) *HashRing {
+// nodesOf returns the nodes of weights map.
+func nodesOf(weights map[string]int) []string {
 	nodes := make([]string, 0, len(weights))
 	for node := range weights {
 		nodes = append(nodes, node)
 	}
-	hashRing := &HashRing{
-		ring:       make(map[HashKey]string),
-		sortedKeys: make([]HashKey, 0),
-		nodes:      nodes,
-		weights:    weights,
-	}
-	hashRing.generateCircle()
-	return hashRing
+	return nodes
 }
 
-// Size returns the number of nodes in HashRing.
+// Size returns the number of nodes in HashRing, standbys included, see
+// AddWeightedNode.
 func (h *HashRing) Size() int {
+	if h == nil {
+		return 0
+	}
 	return len(h.nodes)
 }
 
+// orEmpty returns h, or a new empty HashRing if h is nil.
+func (h *HashRing) orEmpty() *HashRing {
+	if h == nil {
+		return &HashRing{}
+	}
+	return h
+}
+
 // UpdateWithWeights updates HashRing with weights map.
+// Only the virtual nodes whose count changed are moved, see UpdateWeightedNode.
+// A nil HashRing cannot be updated in place and is left as is. It panics on
+// a ring with limits, see UpdateWithWeightsContext.
 func (h *HashRing) UpdateWithWeights(weights map[string]int) {
+	if h != nil {
+		h.config.mustBeUnlimited("UpdateWithWeights")
+	}
+	h.UpdateWithWeightsContext(context.Background(), weights)
+}
+
+// UpdateWithWeightsContext is UpdateWithWeights, giving up the rebuild as
+// soon as ctx is done, e.g. because a newer update superseded it. A ring
+// whose update fails is left as is, e.g. with a LimitError.
+func (h *HashRing) UpdateWithWeightsContext(ctx context.Context, weights map[string]int) error {
+	if h == nil {
+		return errors.New("hashring: cannot update a nil ring")
+	}
 	nodesChgFlg := false
 	if len(weights) != len(h.weights) {
 		nodesChgFlg = true
@@ -86,66 +160,370 @@ func (h *HashRing) UpdateWithWeights(weights map[string]int) {
 	}
 
 	if nodesChgFlg {
-		newhring := NewWithWeights(weights)
+		if err := checkLimits(nodesOf(weights), weights, h.config); err != nil {
+			return err
+		}
+		next, err := newHashRingFrom(ctx, h, nodesOf(weights), weights)
+		if err != nil {
+			return err
+		}
+		newhring := h.derive(next)
+		h.prev = newhring.prev
+		h.changed = newhring.changed
+		h.tombstones = newhring.tombstones
 		h.weights = newhring.weights
 		h.nodes = newhring.nodes
-		h.ring = newhring.ring
 		h.sortedKeys = newhring.sortedKeys
+		h.owners = newhring.owners
+		h.names = newhring.names
+		h.skips = newhring.skips
+		h.replicas = newhring.replicas
+		h.factors = newhring.factors
+		h.aliases = newhring.aliases
+		h.collisions = newhring.collisions
 	}
+	return nil
 }
 
 func (h *HashRing) generateCircle() {
-	totalWeight := 0
+	h.generateCircleContext(context.Background())
+}
+
+// generateCircleContext places the points of h, or returns ctx.Err() if ctx
+// is done first.
+func (h *HashRing) generateCircleContext(ctx context.Context) error {
+	defer h.observeRebuild(time.Now())
+
 	for _, node := range h.nodes {
-		if weight, ok := h.weights[node]; ok {
-			totalWeight += weight
-		} else {
-			totalWeight++
-			h.weights[node] = 1
+		if _, ok := h.weights[node]; !ok {
+			h.weights[node] = h.config.weightUnit()
 		}
 	}
 
+	h.replicas = h.config.baseReplicas()
+	if err := h.placePoints(ctx); err != nil {
+		return err
+	}
+	if h.config.targetImbalance > 0 {
+		return h.tuneReplicas(ctx)
+	}
+	return nil
+}
+
+// placePoints places the virtual nodes of all nodes on ring according to h.replicas.
+// It returns ctx.Err() if ctx is done first.
+func (h *HashRing) placePoints(ctx context.Context) error {
+	h.factors = make(map[string]int)
+	index := h.setNames(sortedNodes(h.nodes))
+
+	points := make([]point, 0)
+	w := h.weighting()
 	for _, node := range h.nodes {
-		weight := h.weights[node]
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		factor := h.nodeFactor(node, w)
+		h.factors[node] = factor
+		for _, key := range h.nodePoints(node, 0, factor) {
+			points = append(points, point{key, index[node]})
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].key < points[j].key })
+
+	h.sortedKeys = make([]HashKey64, 0, len(points))
+	h.owners = make([]int32, 0, len(points))
+	h.collisions = 0
+	for _, p := range points {
+		// A point colliding with an earlier one is kept once, and goes to
+		// the node that wins the collision.
+		if last := len(h.sortedKeys) - 1; last >= 0 && h.sortedKeys[last] == p.key {
+			h.collisions++
+			if h.winsCollision(h.names[p.owner], h.ownerAt(last)) {
+				h.owners[last] = p.owner
+			}
+			continue
+		}
+		h.sortedKeys = append(h.sortedKeys, p.key)
+		h.owners = append(h.owners, p.owner)
+	}
+	h.skips = skipsOf(h.owners)
+	return nil
+}
+
+// point is a point of a ring being placed, owned by the node at index owner
+// of the ring's names.
+type point struct {
+	key   HashKey64
+	owner int32
+}
+
+// setNames makes names the node table of h, and returns the index of each
+// node in it.
+func (h *HashRing) setNames(names []string) map[string]int32 {
+	h.names = names
+	index := make(map[string]int32, len(names))
+	for i, node := range names {
+		index[node] = int32(i)
+	}
+	return index
+}
+
+// ownerAt returns the node of the point at pos.
+func (h *HashRing) ownerAt(pos int) string {
+	return h.names[h.owners[pos]]
+}
 
-		// math.Ceil makes sure that factor would not be zero (at least one).
-		factor := math.Ceil(float64(40*len(h.nodes)*weight) / float64(totalWeight))
+// pointOwner returns the node of the point key, if key is a point of h.
+func (h *HashRing) pointOwner(key HashKey64) (node string, ok bool) {
+	pos := sort.Search(len(h.sortedKeys), func(i int) bool { return h.sortedKeys[i] >= key })
+	if pos == len(h.sortedKeys) || h.sortedKeys[pos] != key {
+		return "", false
+	}
+	return h.ownerAt(pos), true
+}
 
-		for j := 0; j < int(factor); j++ {
-			nodeKey := node + "-" + strconv.FormatInt(int64(j), 10)
-			bKey := hashDigest(nodeKey)
+// newHashRingFrom creates a HashRing with nodes and weights like newHashRing,
+// but reuses the points of prev.
+//
+// The points of a node only depend on its name and their index, so a node
+// whose number of virtual nodes changes from f1 to f2 only gains or loses the
+// points between f1 and f2, and only the keys on those points move.
+//
+// It returns ctx.Err() if ctx is done before the ring is placed.
+func newHashRingFrom(ctx context.Context, prev *HashRing, nodes []string, weights map[string]int) (*HashRing, error) {
+	hashRing := &HashRing{
+		nodes:   nodes,
+		weights: weights,
+		config:  prev.config,
+		aliases: prev.aliasesOf(nodes),
+	}
+	// Tuning may pick another number of replicas, which moves every point,
+	// and duplicated nodes place their points once per occurrence. A point
+	// that collided is owned by one node only, removing it would not hand it
+	// back to the other, so rings with collisions are placed in full too.
+	if hashRing.config.targetImbalance > 0 || prev.replicas != prev.config.baseReplicas() ||
+		len(sortedNodes(nodes)) != len(nodes) || len(sortedNodes(prev.nodes)) != len(prev.nodes) ||
+		prev.pointsFor(prev.replicas) != len(prev.sortedKeys) {
+		return hashRing, hashRing.generateCircleContext(ctx)
+	}
+
+	for _, node := range nodes {
+		if _, ok := weights[node]; !ok {
+			weights[node] = hashRing.config.weightUnit()
+		}
+	}
+	start := time.Now()
+	if !hashRing.placePointsFrom(ctx, prev) {
+		return hashRing, hashRing.generateCircleContext(ctx)
+	}
+	hashRing.observeRebuild(start)
+	return hashRing, nil
+}
 
-			// It's still a mystery why the fourth byte is discarded.
-			for i := 0; i < 3; i++ {
-				key := hashVal(bKey[i*4 : i*4+4])
-				h.ring[key] = node
-				h.sortedKeys = append(h.sortedKeys, key)
+// placePointsFrom places the virtual nodes of h by moving the points of prev
+// whose node's number of virtual nodes changed. It returns false if a moved
+// point collides with another node's, which only a full placement resolves,
+// or if ctx is done, which the full placement then reports.
+func (h *HashRing) placePointsFrom(ctx context.Context, prev *HashRing) bool {
+	h.replicas = h.config.baseReplicas()
+	h.factors = make(map[string]int, len(h.nodes))
+	index := h.setNames(sortedNodes(h.nodes))
+
+	removed := make(map[HashKey64]bool)
+	added := make([]point, 0)
+	remove := func(node string, from, to int) bool {
+		for _, key := range prev.nodePoints(node, from, to) {
+			if owner, ok := prev.pointOwner(key); !ok || owner != node {
+				return false
 			}
+			removed[key] = true
 		}
+		return true
 	}
 
-	sort.Sort(HashKeyOrder(h.sortedKeys))
+	taken := make(map[HashKey64]bool)
+	w := h.weighting()
+	for _, node := range h.nodes {
+		if ctx.Err() != nil {
+			return false
+		}
+		oldFactor, factor := prev.factors[node], h.nodeFactor(node, w)
+		h.factors[node] = factor
+		if !remove(node, factor, oldFactor) {
+			return false
+		}
+		for _, key := range h.nodePoints(node, oldFactor, factor) {
+			if _, ok := prev.pointOwner(key); ok && !removed[key] || taken[key] {
+				return false
+			}
+			taken[key] = true
+			added = append(added, point{key, index[node]})
+		}
+	}
+	for node, oldFactor := range prev.factors {
+		if _, ok := h.factors[node]; ok {
+			continue
+		}
+		if !remove(node, 0, oldFactor) {
+			return false
+		}
+	}
+
+	// The nodes of prev's remaining points are all in h.
+	remap := make([]int32, len(prev.names))
+	for i, node := range prev.names {
+		remap[i] = index[node]
+	}
+	sort.Slice(added, func(i, j int) bool { return added[i].key < added[j].key })
+	n := len(prev.sortedKeys) - len(removed) + len(added)
+	h.sortedKeys = make([]HashKey64, 0, n)
+	h.owners = make([]int32, 0, n)
+	i := 0
+	for pos, key := range prev.sortedKeys {
+		if removed[key] {
+			continue
+		}
+		for ; i < len(added) && added[i].key < key; i++ {
+			h.sortedKeys = append(h.sortedKeys, added[i].key)
+			h.owners = append(h.owners, added[i].owner)
+		}
+		h.sortedKeys = append(h.sortedKeys, key)
+		h.owners = append(h.owners, remap[prev.owners[pos]])
+	}
+	for ; i < len(added); i++ {
+		h.sortedKeys = append(h.sortedKeys, added[i].key)
+		h.owners = append(h.owners, added[i].owner)
+	}
+	h.skips = skipsOf(h.owners)
+	return true
+}
+
+// weighting is the total weight of the nodes of a ring, and the number of
+// nodes with a weight, i.e. without standbys.
+type weighting struct {
+	total, nodes int
+	capped       map[string]int // weights lowered by WithMaxShare.
+}
+
+// weighting sums the weights of h.nodes, duplicated nodes are counted once per occurrence.
+func (h *HashRing) weighting() weighting {
+	w := weighting{capped: h.capShares()}
+	for _, node := range h.nodes {
+		if weight := w.weight(node, h.weights[node]); weight > 0 {
+			w.total += weight
+			w.nodes++
+		}
+	}
+	return w
+}
+
+// weight returns the weight node places its virtual nodes by, weight unless
+// it is capped.
+func (w weighting) weight(node string, weight int) int {
+	if capped, ok := w.capped[node]; ok {
+		return capped
+	}
+	return weight
+}
+
+// nodeFactor returns the number of virtual nodes of node, none for a standby.
+func (h *HashRing) nodeFactor(node string, w weighting) int {
+	weight := w.weight(node, h.weights[node])
+	if weight <= 0 {
+		return 0
+	}
+	switch h.config.placement {
+	case placementKetama:
+		return ketamaFactor(weight, w.total, w.nodes)
+	case placementNginx:
+		return weight * nginxPointsPerWeight / h.config.weightUnit()
+	}
+
+	// math.Ceil makes sure that factor would not be zero (at least one).
+	return int(math.Ceil(float64(h.replicas*w.nodes*weight) / float64(w.total)))
+}
+
+// nodePoints returns the HashKeys of node's virtual nodes from index from up to to (exclusive).
+func (h *HashRing) nodePoints(node string, from, to int) []HashKey64 {
+	node = h.identity(node)
+	if h.config.placement == placementNginx {
+		return nginxPoints(node, from, to)
+	}
+
+	points := make([]HashKey64, 0)
+	for j := from; j < to; j++ {
+		nodeKey := node + "-" + strconv.FormatInt(int64(j), 10)
+		if h.config.seed != 0 {
+			nodeKey = strconv.FormatUint(h.config.seed, 10) + "/" + nodeKey
+		}
+		bKey := h.digest(nodeKey)
+
+		perDigest := h.pointsPerDigest()
+		if h.config.wide {
+			for i := 0; i < perDigest && i*8+8 <= len(bKey); i++ {
+				points = append(points, HashKey64(binary.LittleEndian.Uint64(bKey[i*8:])))
+			}
+			continue
+		}
+		for i := 0; i < perDigest && i*4+4 <= len(bKey); i++ {
+			points = append(points, HashKey64(hashVal(bKey[i*4:i*4+4])))
+		}
+	}
+	return points
 }
 
 // GetNode returns the node that stringKey belongs to.
 func (h *HashRing) GetNode(stringKey string) (node string, ok bool) {
+	if h.intercepted() {
+		defer h.afterLookup(stringKey, h.beforeLookup(stringKey), &node, &ok)
+	}
+	if h.sampled() {
+		defer h.observeLookup(time.Now())
+	}
+	if h.single() {
+		return h.lookupAt(0), true
+	}
 	pos, ok := h.GetNodePos(stringKey)
 	if !ok {
 		return "", false
 	}
-	return h.ring[h.sortedKeys[pos]], true
+	return h.lookupAt(pos), true
+}
+
+// single reports whether every point of h belongs to one node, so lookups
+// need not hash their key to find it. Standbys own no points, so a ring of
+// one node and standbys is single too.
+func (h *HashRing) single() bool {
+	if h == nil || len(h.sortedKeys) == 0 {
+		return false
+	}
+	if len(h.skips) == len(h.sortedKeys) {
+		// The point after each one of another node is a whole turn away.
+		return int(h.skips[0]) == len(h.sortedKeys)
+	}
+	return len(h.factors) == 1
 }
 
 // GetNodePos returns the position on ring that stringKey belongs to.
 func (h *HashRing) GetNodePos(stringKey string) (pos int, ok bool) {
-	if len(h.ring) == 0 {
+	if h == nil || len(h.sortedKeys) == 0 {
 		return 0, false
 	}
+	return h.keyPos(h.GenKey64(stringKey))
+}
 
-	key := h.GenKey(stringKey)
+// keyPos returns the position on ring that key belongs to.
+func (h *HashRing) keyPos(key HashKey64) (pos int, ok bool) {
+	if h == nil || len(h.sortedKeys) == 0 {
+		return 0, false
+	}
 
 	nodes := h.sortedKeys
-	pos = sort.Search(len(nodes), func(i int) bool { return nodes[i] > key })
+	if h.config.boundary == BoundaryAtOrAfter {
+		pos = sort.Search(len(nodes), func(i int) bool { return nodes[i] >= key })
+	} else {
+		pos = sort.Search(len(nodes), func(i int) bool { return nodes[i] > key })
+	}
 
 	if pos == len(nodes) {
 		// Wrap the search, should return first node
@@ -155,9 +533,67 @@ func (h *HashRing) GetNodePos(stringKey string) (pos int, ok bool) {
 }
 
 // GenKey generates HashKey of key.
+// With 64-bit keys, it is the upper half of GenKey64.
 func (h *HashRing) GenKey(key string) HashKey {
+	return h.narrow(h.GenKey64(key))
+}
+
+// GenKey64 generates the position of key on the ring.
+func (h *HashRing) GenKey64(key string) HashKey64 {
+	if h != nil && h.config.hasher != nil {
+		if _, ok := h.config.hasher.(xxHash64); ok {
+			return h.sumPosition(xxh64(key))
+		}
+		return h.position(h.config.hasher.Hash([]byte(key)))
+	}
 	bKey := hashDigest(key)
-	return hashVal(bKey[0:4])
+	return h.position(bKey[:])
+}
+
+// position returns the position on ring of a key with digest bKey.
+func (h *HashRing) position(bKey []byte) HashKey64 {
+	if h != nil && h.config.wide {
+		return HashKey64(binary.LittleEndian.Uint64(bKey[0:8]))
+	}
+	return HashKey64(hashVal(bKey[0:4]))
+}
+
+// sumPosition returns the position on ring of a key whose digest is the 8
+// little-endian bytes of sum, like position.
+func (h *HashRing) sumPosition(sum uint64) HashKey64 {
+	if h != nil && h.config.wide {
+		return HashKey64(sum)
+	}
+	return HashKey64(uint32(sum))
+}
+
+// narrow returns the HashKey of position key.
+func (h *HashRing) narrow(key HashKey64) HashKey {
+	if h != nil && h.config.wide {
+		return HashKey(key >> 32)
+	}
+	return HashKey(key)
+}
+
+// widen returns the position of HashKey key.
+func (h *HashRing) widen(key HashKey) HashKey64 {
+	if h != nil && h.config.wide {
+		return HashKey64(key) << 32
+	}
+	return HashKey64(key)
+}
+
+// keyspace returns the number of positions on ring.
+func (h *HashRing) keyspace() float64 {
+	if h != nil && h.config.wide {
+		return float64(math.MaxUint64) + 1
+	}
+	return float64(math.MaxUint32) + 1
+}
+
+// sortKeys sorts keys in ascending order.
+func sortKeys(keys []HashKey64) {
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
 }
 
 // GetNodeFrom returns the node that stringKey belongs to.
@@ -174,8 +610,7 @@ func (h *HashRing) GetNodeFrom(stringKey string, nodes []string) (node string, o
 	}
 
 	for i := pos; i < pos+len(h.sortedKeys); i++ {
-		key := h.sortedKeys[i%len(h.sortedKeys)]
-		val := h.ring[key]
+		val := h.ownerAt(i % len(h.sortedKeys))
 		if _, ok := nm[val]; ok {
 			return val, ok
 		}
@@ -185,27 +620,58 @@ func (h *HashRing) GetNodeFrom(stringKey string, nodes []string) (node string, o
 
 // GetNodes returns size nodes from the ring.
 //
-// size should be less than or equal to the number of nodes on the ring.
+// size should be less than or equal to the number of nodes on the ring,
+// standbys aside; ok is false otherwise.
 //
 // The first node returned is where stringKey belongs.
 // The other $size-1$ nodes are unique ones following on the ring.
 func (h *HashRing) GetNodes(stringKey string, size int) (nodes []string, ok bool) {
-	if size > len(h.nodes) || size <= 0 {
+	if h.intercepted() {
+		defer h.afterLookups(stringKey, h.beforeLookup(stringKey), &nodes, &ok)
+	}
+	if size > h.Size() || size <= 0 {
 		return nil, false
 	}
+	if h.sampled() {
+		defer h.observeLookup(time.Now())
+	}
 
 	pos, ok := h.GetNodePos(stringKey)
 	if !ok {
 		return nil, false
 	}
+	return h.nodesAt(pos, size)
+}
+
+// nodesAt returns size unique nodes following on the ring from pos, or
+// false if fewer than size nodes own points, e.g. as the others are
+// standbys.
+func (h *HashRing) nodesAt(pos int, size int) (nodes []string, ok bool) {
+	nodes, ok = h.walk(pos, size)
+	if !ok {
+		return nil, false
+	}
+	if h.config.metrics != nil {
+		h.config.metrics.Lookup(nodes[0])
+	}
+	return nodes, ok
+}
 
+// walk is nodesAt without reporting the lookup.
+//
+// Runs of points of one node are skipped at once, see skipsOf.
+func (h *HashRing) walk(pos int, size int) (nodes []string, ok bool) {
 	returnedValues := make(map[string]bool, size)
 	//mergedSortedKeys := append(h.sortedKeys[pos:], h.sortedKeys[:pos]...)
 	resultSlice := make([]string, 0, size)
 
-	for i := pos; i < pos+len(h.sortedKeys); i++ {
-		key := h.sortedKeys[i%len(h.sortedKeys)]
-		val := h.ring[key]
+	skips := len(h.skips) == len(h.sortedKeys)
+	for i, step := pos, 1; i < pos+len(h.sortedKeys); i += step {
+		j := i % len(h.sortedKeys)
+		if skips {
+			step = int(h.skips[j])
+		}
+		val := h.ownerAt(j)
 		if !returnedValues[val] {
 			returnedValues[val] = true
 			resultSlice = append(resultSlice, val)
@@ -218,19 +684,70 @@ func (h *HashRing) GetNodes(stringKey string, size int) (nodes []string, ok bool
 	return resultSlice, len(resultSlice) == size
 }
 
-// AddNode adds node to ring, and returns the new HashRing.
+// skipsOf returns, for each point of owners, the number of points to the
+// next point of another node, wrapping around; len(owners) if all points
+// belong to one node.
+func skipsOf(owners []int32) []int32 {
+	n := len(owners)
+	skips := make([]int32, n)
+	start := -1
+	for i := 0; i < n; i++ {
+		if owners[i] != owners[(i+n-1)%n] {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		for i := range skips {
+			skips[i] = int32(n)
+		}
+		return skips
+	}
+
+	// Going backwards from the first point of a run, each point is either
+	// followed by another node or continues the run of its successor.
+	for k := 1; k <= n; k++ {
+		i, next := (start-k+n)%n, (start-k+1+n)%n
+		if owners[i] != owners[next] {
+			skips[i] = 1
+		} else {
+			skips[i] = skips[next] + 1
+		}
+	}
+	return skips
+}
+
+// AddNode adds node to ring, and returns the new HashRing. It panics on a
+// ring with limits, see AddNodeChecked.
 func (h *HashRing) AddNode(node string) *HashRing {
-	return h.AddWeightedNode(node, 1)
+	return h.AddWeightedNode(node, h.orEmpty().config.weightUnit())
 }
 
 // AddWeightedNode adds node with weight to ring, and returns the new HashRing.
+//
+// Only the points of node, and of the nodes whose number of virtual nodes
+// changes with the total weight, are placed, see UpdateWeightedNode.
+//
+// A node of weight 0 is a standby: it is on the ring, see Nodes, but owns no
+// keys until UpdateWeightedNode gives it a weight. Adding it moves no keys.
+//
+// AddWeightedNode panics on a ring with limits, see AddWeightedNodeChecked.
 func (h *HashRing) AddWeightedNode(node string, weight int) *HashRing {
-	if weight <= 0 {
-		return h
+	h = h.orEmpty()
+	h.config.mustBeUnlimited("AddWeightedNode")
+	next, _ := h.addWeightedNode(node, weight)
+	return next
+}
+
+// addWeightedNode is AddWeightedNode, returning a LimitError if the change
+// takes h over its limits.
+func (h *HashRing) addWeightedNode(node string, weight int) (*HashRing, error) {
+	if weight < 0 {
+		return h, nil
 	}
 
 	if _, ok := h.weights[node]; ok {
-		return h
+		return h, nil
 	}
 
 	nodes := make([]string, len(h.nodes), len(h.nodes)+1)
@@ -242,26 +759,39 @@ func (h *HashRing) AddWeightedNode(node string, weight int) *HashRing {
 		weights[eNode] = eWeight
 	}
 	weights[node] = weight
-
-	hashRing := &HashRing{
-		ring:       make(map[HashKey]string),
-		sortedKeys: make([]HashKey, 0),
-		nodes:      nodes,
-		weights:    weights,
+	if err := checkLimits(nodes, weights, h.config); err != nil {
+		return h, err
 	}
-	hashRing.generateCircle()
-	return hashRing
+
+	next, _ := newHashRingFrom(context.Background(), h, nodes, weights)
+	return h.derive(next), nil
 }
 
 // UpdateWeightedNode updates node with weight, and returns the new HashRing.
+//
+// Virtual nodes are added or removed for the weight delta only, the others
+// stay where they are, so the keys moved are proportional to the change.
+// Weight 0 makes node a standby, see AddWeightedNode.
+//
+// UpdateWeightedNode panics on a ring with limits, see
+// UpdateWeightedNodeChecked.
 func (h *HashRing) UpdateWeightedNode(node string, weight int) *HashRing {
-	if weight <= 0 {
-		return h
+	h = h.orEmpty()
+	h.config.mustBeUnlimited("UpdateWeightedNode")
+	next, _ := h.updateWeightedNode(node, weight)
+	return next
+}
+
+// updateWeightedNode is UpdateWeightedNode, returning a LimitError if the
+// change takes h over its limits.
+func (h *HashRing) updateWeightedNode(node string, weight int) (*HashRing, error) {
+	if weight < 0 {
+		return h, nil
 	}
 
 	/* node is not need to update for node is not existed or weight is not changed */
 	if oldWeight, ok := h.weights[node]; (!ok) || (ok && oldWeight == weight) {
-		return h
+		return h, nil
 	}
 
 	nodes := make([]string, len(h.nodes), len(h.nodes))
@@ -272,19 +802,20 @@ func (h *HashRing) UpdateWeightedNode(node string, weight int) *HashRing {
 		weights[eNode] = eWeight
 	}
 	weights[node] = weight
-
-	hashRing := &HashRing{
-		ring:       make(map[HashKey]string),
-		sortedKeys: make([]HashKey, 0),
-		nodes:      nodes,
-		weights:    weights,
+	if err := checkLimits(nodes, weights, h.config); err != nil {
+		return h, err
 	}
-	hashRing.generateCircle()
-	return hashRing
+
+	next, _ := newHashRingFrom(context.Background(), h, nodes, weights)
+	return h.derive(next), nil
 }
 
 // RemoveNode removes node from ring, and returns the new HashRing.
+//
+// Only the points of node, and of the nodes whose number of virtual nodes
+// changes with the total weight, move, see UpdateWeightedNode.
 func (h *HashRing) RemoveNode(node string) *HashRing {
+	h = h.orEmpty()
 	/* if node isn't exist in hashring, don't refresh hashring */
 	if _, ok := h.weights[node]; !ok {
 		return h
@@ -304,14 +835,8 @@ func (h *HashRing) RemoveNode(node string) *HashRing {
 		}
 	}
 
-	hashRing := &HashRing{
-		ring:       make(map[HashKey]string),
-		sortedKeys: make([]HashKey, 0),
-		nodes:      nodes,
-		weights:    weights,
-	}
-	hashRing.generateCircle()
-	return hashRing
+	next, _ := newHashRingFrom(context.Background(), h, nodes, weights)
+	return h.derive(next)
 }
 
 func hashVal(bKey []byte) HashKey {
@@ -321,8 +846,22 @@ func hashVal(bKey []byte) HashKey {
 		(HashKey(bKey[0])))
 }
 
+// digest returns the digest of key by the ring's Hasher, md5 by default.
+func (h *HashRing) digest(key string) []byte {
+	if h.config.hasher != nil {
+		return h.config.hasher.Hash([]byte(key))
+	}
+	bKey := hashDigest(key)
+	return bKey[:]
+}
+
 // hashDigest returns the md5 sum of key.
 // One key's md5 sum never changes.
 func hashDigest(key string) [md5.Size]byte {
 	return md5.Sum([]byte(key))
 }
+
+// hashDigestBytes returns the md5 sum of key.
+func hashDigestBytes(key []byte) [md5.Size]byte {
+	return md5.Sum(key)
+}
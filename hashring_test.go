@@ -1,6 +1,8 @@
 package hashring
 
 import (
+	"context"
+	"crypto/md5"
 	"reflect"
 	"strconv"
 	"testing"
@@ -242,16 +244,32 @@ func TestDuplicateNodes(t *testing.T) {
 	expectNode(t, hashRing, "test1", "b")
 	expectNode(t, hashRing, "test2", "b")
 	expectNode(t, hashRing, "test3", "a")
-	expectNode(t, hashRing, "test4", "b")
+	expectNode(t, hashRing, "test4", "a")
 	expectNode(t, hashRing, "test5", "a")
-	expectNode(t, hashRing, "aaaa", "b")
+	expectNode(t, hashRing, "aaaa", "a")
 	expectNode(t, hashRing, "bbbb", "a")
+
+	// Duplicates are weight increments, whatever their order.
+	assert.Equal(t, 2, hashRing.Size())
+	expectWeights(t, hashRing, map[string]int{"a": 4, "b": 1})
+	expectSameCircle(t, hashRing, NewWithWeights(map[string]int{"a": 4, "b": 1}))
+	expectSameCircle(t, hashRing, New([]string{"b", "a", "a", "a", "a"}))
+	checked, err := NewChecked(nodes, WithMaxNodes(2))
+	assert.NoError(t, err)
+	expectSameCircle(t, checked, hashRing)
+	reordered := New([]string{"a", "b", "a", "c", "a", "c"})
+	expectSameCircle(t, reordered, New([]string{"c", "a", "c", "a", "b", "a"}))
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		nodes, _ := reordered.GetNodes(key, 3)
+		expectNodes(t, NewWithWeights(map[string]int{"a": 3, "b": 1, "c": 2}), key, nodes[:2])
+	}
 }
 
 func TestAddWeightedNode(t *testing.T) {
 	nodes := []string{"a", "c"}
 	hashRing := New(nodes)
-	hashRing = hashRing.AddWeightedNode("b", 0)
+	hashRing = hashRing.AddWeightedNode("b", -1)
 	hashRing = hashRing.AddWeightedNode("b", 2)
 	hashRing = hashRing.AddWeightedNode("b", 2)
 
@@ -274,7 +292,7 @@ func TestUpdateWeightedNode(t *testing.T) {
 	hashRing = hashRing.AddWeightedNode("b", 1)
 	hashRing = hashRing.UpdateWeightedNode("b", 2)
 	hashRing = hashRing.UpdateWeightedNode("b", 2)
-	hashRing = hashRing.UpdateWeightedNode("b", 0)
+	hashRing = hashRing.UpdateWeightedNode("b", -1)
 	hashRing = hashRing.UpdateWeightedNode("d", 2)
 
 	expectNode(t, hashRing, "test", "b")
@@ -290,6 +308,152 @@ func TestUpdateWeightedNode(t *testing.T) {
 	expectNodes(t, hashRing, "test", []string{"b", "a"})
 }
 
+func expectSameCircle(t *testing.T, hashRing *HashRing, expected *HashRing) {
+	assert.Equal(t, expected.sortedKeys, hashRing.sortedKeys)
+	assert.Equal(t, pointsOf(expected), pointsOf(hashRing))
+	assert.Equal(t, expected.factors, hashRing.factors)
+}
+
+// pointsOf returns the node of each point of h.
+func pointsOf(h *HashRing) map[HashKey64]string {
+	points := make(map[HashKey64]string, len(h.sortedKeys))
+	for pos, key := range h.sortedKeys {
+		points[key] = h.ownerAt(pos)
+	}
+	return points
+}
+
+func TestAddWeightedNodeDelta(t *testing.T) {
+	hashRing := NewWithWeights(map[string]int{"a": 3, "b": 1, "c": 2})
+
+	added := hashRing.AddWeightedNode("d", 2)
+	expectSameCircle(t, added, NewWithWeights(map[string]int{"a": 3, "b": 1, "c": 2, "d": 2}))
+	assert.Len(t, added.skips, len(added.sortedKeys))
+
+	// With equal weights, the points of the other nodes stay.
+	equal := New([]string{"a", "b", "c"})
+	added = equal.AddNode("d")
+	addedPoints := pointsOf(added)
+	for key, node := range pointsOf(equal) {
+		assert.Equal(t, node, addedPoints[key])
+	}
+}
+
+func TestRemoveNodeDelta(t *testing.T) {
+	hashRing := NewWithWeights(map[string]int{"a": 3, "b": 1, "c": 2, "d": 2})
+
+	removed := hashRing.RemoveNode("b")
+	expectSameCircle(t, removed, NewWithWeights(map[string]int{"a": 3, "c": 2, "d": 2}))
+	assert.Len(t, removed.skips, len(removed.sortedKeys))
+
+	// A removed node may own points that collided with those of other nodes,
+	// which a hash of 16 bits per point makes likely.
+	colliding := WithHasher(HasherFunc(func(key []byte) []byte {
+		sum := md5.Sum(key)
+		for i := range sum {
+			if i%4 >= 2 {
+				sum[i] = 0
+			}
+		}
+		return sum[:]
+	}))
+	nodes := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	hashRing = New(nodes, colliding)
+	for _, node := range nodes {
+		others := make([]string, 0, len(nodes)-1)
+		for _, other := range nodes {
+			if other != node {
+				others = append(others, other)
+			}
+		}
+		expectSameCircle(t, hashRing.RemoveNode(node), New(others, colliding))
+	}
+}
+
+func TestUpdateWeightedNodeDelta(t *testing.T) {
+	weights := map[string]int{"a": 3, "b": 1, "c": 2}
+	hashRing := NewWithWeights(weights)
+
+	updated := hashRing.UpdateWeightedNode("a", 4)
+	expectSameCircle(t, updated, NewWithWeights(map[string]int{"a": 4, "b": 1, "c": 2}))
+
+	updated = updated.UpdateWeightedNode("a", 1)
+	expectSameCircle(t, updated, NewWithWeights(map[string]int{"a": 1, "b": 1, "c": 2}))
+
+	// The original ring is left untouched.
+	expectSameCircle(t, hashRing, NewWithWeights(weights))
+}
+
+func TestUpdateWeightedNodeChurn(t *testing.T) {
+	nodes := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	hashRing := New(nodes)
+	updated := hashRing.UpdateWeightedNode("a", 2)
+
+	moved, movedToA := 0, 0
+	for i := 0; i < 10000; i++ {
+		key := strconv.Itoa(i)
+		before, _ := hashRing.GetNode(key)
+		after, _ := updated.GetNode(key)
+		if before != after {
+			moved++
+		}
+		if before != "a" && after == "a" {
+			movedToA++
+		}
+	}
+	// a grows from 1/8 to 2/9 of the weight, so about 10% of keys have to
+	// move to it. The other nodes shrink a little, which moves a few more.
+	assert.True(t, moved < 2000, "%d keys moved", moved)
+	assert.True(t, movedToA > 800, "%d keys moved to a", movedToA)
+}
+
+func TestUpdateWithWeightsDelta(t *testing.T) {
+	hashRing := NewWithWeights(map[string]int{"a": 1, "b": 2, "c": 1})
+
+	hashRing.UpdateWithWeights(map[string]int{"a": 2, "b": 2, "d": 1})
+	expectSameCircle(t, hashRing, NewWithWeights(map[string]int{"a": 2, "b": 2, "d": 1}))
+	expectWeights(t, hashRing, map[string]int{"a": 2, "b": 2, "d": 1})
+}
+
+// countdownContext is done after its Err has been checked n times.
+type countdownContext struct {
+	context.Context
+	n int
+}
+
+func (c *countdownContext) Err() error {
+	if c.n--; c.n < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestUpdateWithWeightsContext(t *testing.T) {
+	weights := map[string]int{"a": 1, "b": 2, "c": 1}
+	desired := map[string]int{"a": 2, "b": 2, "d": 1}
+	for _, opts := range [][]Option{nil, {WithTargetImbalance(0.01)}} {
+		hashRing := NewWithWeights(weights, opts...)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.Equal(t, context.Canceled, hashRing.UpdateWithWeightsContext(ctx, desired))
+		expectSameCircle(t, hashRing, NewWithWeights(weights, opts...))
+
+		// Canceled halfway through the rebuild.
+		assert.Equal(t, context.Canceled, hashRing.UpdateWithWeightsContext(&countdownContext{context.Background(), 2}, desired))
+		expectSameCircle(t, hashRing, NewWithWeights(weights, opts...))
+		expectWeights(t, hashRing, weights)
+
+		assert.NoError(t, hashRing.UpdateWithWeightsContext(context.Background(), desired))
+		expectSameCircle(t, hashRing, NewWithWeights(desired, opts...))
+	}
+
+	limited, _ := NewChecked([]string{"a"}, WithMaxNodes(1))
+	var limitErr *LimitError
+	assert.ErrorAs(t, limited.UpdateWithWeightsContext(context.Background(), map[string]int{"a": 1, "b": 1}), &limitErr)
+	assert.Error(t, (*HashRing)(nil).UpdateWithWeightsContext(context.Background(), weights))
+}
+
 func TestRemoveAddNode(t *testing.T) {
 	nodes := []string{"a", "b", "c"}
 	hashRing := New(nodes)
@@ -524,6 +688,93 @@ func TestGetNodefrom(t *testing.T) {
 	assert.Equal(t, "a", n)
 }
 
+func TestSingleNode(t *testing.T) {
+	for _, hashRing := range []*HashRing{New([]string{"a"}), New([]string{"a", "a"}), New([]string{"b"}).AddNode("a").RemoveNode("b")} {
+		assert.True(t, hashRing.single())
+		for _, key := range []string{"test", "test1", "aaaa"} {
+			node, ok := hashRing.GetNode(key)
+			assert.True(t, ok)
+			assert.Equal(t, "a", node)
+
+			pos, _ := hashRing.GetNodePos(key)
+			assert.Equal(t, "a", hashRing.ownerAt(pos))
+		}
+		node, ok := hashRing.GetNodeBytes([]byte("test"))
+		assert.True(t, ok)
+		assert.Equal(t, "a", node)
+		node, ok = hashRing.GetNodeUint64(1)
+		assert.True(t, ok)
+		assert.Equal(t, "a", node)
+	}
+	assert.False(t, New([]string{"a", "b"}).single())
+	assert.False(t, New(nil).single())
+	assert.False(t, (*HashRing)(nil).single())
+}
+
+func TestSkipsOf(t *testing.T) {
+	assert.Equal(t, []int32{2, 1, 1, 2, 1, 3}, skipsOf([]int32{0, 0, 1, 2, 2, 0}))
+	assert.Equal(t, []int32{2, 2}, skipsOf([]int32{0, 0}))
+	assert.Empty(t, skipsOf(nil))
+
+	// Walks skipping runs find the nodes a walk of every point finds.
+	hashRing := NewWithWeights(map[string]int{"a": 1, "b": 8, "c": 1, "d": 2})
+	plain := *hashRing
+	plain.skips = nil
+	for i := 0; i < 200; i++ {
+		key := strconv.Itoa(i)
+		for size := 1; size <= 4; size++ {
+			expected, _ := plain.GetNodes(key, size)
+			nodes, ok := hashRing.GetNodes(key, size)
+			assert.True(t, ok)
+			assert.Equal(t, expected, nodes)
+		}
+	}
+	assert.Len(t, hashRing.UpdateWeightedNode("a", 3).skips, len(hashRing.UpdateWeightedNode("a", 3).sortedKeys))
+}
+
+func TestEmptyRing(t *testing.T) {
+	var nilRing *HashRing
+	for name, hashRing := range map[string]*HashRing{"nil": nilRing, "zero": {}} {
+		assert.Equal(t, 0, hashRing.Size(), name)
+		assert.NoError(t, hashRing.Validate(), name)
+		assert.Empty(t, hashRing.HealthReport(), name)
+		assert.Equal(t, 0.0, hashRing.Imbalance(), name)
+		assert.Equal(t, 0.0, Diff(hashRing, hashRing).Churn, name)
+
+		_, ok := hashRing.GetNode("test")
+		assert.False(t, ok, name)
+		_, ok = hashRing.GetNodePos("test")
+		assert.False(t, ok, name)
+		_, ok = hashRing.GetNodeFrom("test", []string{"a"})
+		assert.False(t, ok, name)
+		_, ok = hashRing.GetNodes("test", 1)
+		assert.False(t, ok, name)
+		_, ok = hashRing.GetNodesWithConstraints("test", 1)
+		assert.False(t, ok, name)
+		_, ok = hashRing.GetNodeFor(objectKey{tenant: "t", object: "test"})
+		assert.False(t, ok, name)
+		_, ok = hashRing.GetNodesFor(objectKey{tenant: "t", object: "test"}, 1)
+		assert.False(t, ok, name)
+		_, ok = hashRing.GetNodeUint64(42)
+		assert.False(t, ok, name)
+		assert.Equal(t, New([]string{"a"}).GenKey("test"), hashRing.GenKey("test"), name)
+		assert.Equal(t, New([]string{"a"}).GenKeyUint64(42), hashRing.GenKeyUint64(42), name)
+
+		assert.Equal(t, 0, hashRing.RemoveNode("a").Size(), name)
+		assert.Equal(t, 0, hashRing.UpdateWeightedNode("a", 2).Size(), name)
+
+		added := hashRing.AddNode("a").AddWeightedNode("b", 2)
+		expectSameCircle(t, NewWithWeights(map[string]int{"a": 1, "b": 2}), added)
+	}
+
+	nilRing.UpdateWithWeights(map[string]int{"a": 1})
+	assert.Nil(t, nilRing)
+
+	zeroRing := &HashRing{}
+	zeroRing.UpdateWithWeights(map[string]int{"a": 1, "b": 2})
+	expectSameCircle(t, NewWithWeights(map[string]int{"a": 1, "b": 2}), zeroRing)
+}
+
 func TestHashDigest(t *testing.T) {
 	key := "whatever"
 	val := hashDigest(key)
@@ -593,6 +844,58 @@ func BenchmarkHashesSingle(b *testing.B) {
 	}
 }
 
+func BenchmarkHashesSingleNode(b *testing.B) {
+	hashRing := New([]string{"a"})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hashRing.GetNode("test")
+	}
+}
+
+func BenchmarkGetNodesManyPoints(b *testing.B) {
+	hashRing := NewWithWeights(map[string]int{"a": 1, "b": 50, "c": 1, "d": 50, "e": 1})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hashRing.GetNodes("test", 5)
+	}
+}
+
+func BenchmarkAddNode(b *testing.B) {
+	nodes := make([]string, 300)
+	for i := range nodes {
+		nodes[i] = "node-" + strconv.Itoa(i)
+	}
+	hashRing := New(nodes)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hashRing.AddNode("new")
+	}
+}
+
+func BenchmarkRemoveNode(b *testing.B) {
+	nodes := make([]string, 300)
+	for i := range nodes {
+		nodes[i] = "node-" + strconv.Itoa(i)
+	}
+	hashRing := New(nodes)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hashRing.RemoveNode("node-0")
+	}
+}
+
+func BenchmarkNewManyNodes(b *testing.B) {
+	nodes := make([]string, 1000)
+	for i := range nodes {
+		nodes[i] = "node-" + strconv.Itoa(i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = New(nodes)
+	}
+}
+
 func BenchmarkNew(b *testing.B) {
 	nodes := []string{"a", "b", "c", "d", "e", "f", "g"}
 	b.ResetTimer()
@@ -0,0 +1,190 @@
+package hashring
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// HealthWarningKind identifies the condition a HealthWarning reports.
+type HealthWarningKind string
+
+const (
+	// WarnOwnershipDeviation means a node owns a share of the keyspace that
+	// deviates from its share of the total weight by more than allowed.
+	WarnOwnershipDeviation HealthWarningKind = "ownership_deviation"
+	// WarnCollision means virtual nodes collided on the same HashKey, so some
+	// points silently belong to another node.
+	WarnCollision HealthWarningKind = "collision"
+	// WarnNoPoints means a node is part of the ring but owns no points.
+	WarnNoPoints HealthWarningKind = "no_points"
+	// WarnRingSize means the ring holds more points than allowed.
+	WarnRingSize HealthWarningKind = "ring_size"
+	// WarnLookupLatency means lookups are slower than allowed, see
+	// WithLatencyBudget.
+	WarnLookupLatency HealthWarningKind = "lookup_latency"
+	// WarnDrift means a derived ring differs from a full rebuild, see
+	// WithConsistencyCheck.
+	WarnDrift HealthWarningKind = "drift"
+)
+
+// HealthWarning is a single finding of HealthReport.
+type HealthWarning struct {
+	Kind HealthWarningKind
+	// Node is the node concerned, empty for ring-wide warnings.
+	Node    string
+	Message string
+}
+
+// HealthThresholds configures when HealthReport raises warnings.
+type HealthThresholds struct {
+	// MaxOwnershipDeviation is the allowed relative deviation of a node's
+	// keyspace share from its weight share, e.g. 0.3 allows 30%.
+	MaxOwnershipDeviation float64
+	// MaxPoints is the allowed number of points on the ring.
+	MaxPoints int
+}
+
+// DefaultHealthThresholds is used by HealthReport.
+var DefaultHealthThresholds = HealthThresholds{
+	MaxOwnershipDeviation: 0.3,
+	MaxPoints:             1 << 20,
+}
+
+// HealthReport checks the ring against DefaultHealthThresholds.
+// An empty result means the ring is healthy.
+func (h *HashRing) HealthReport() []HealthWarning {
+	return h.HealthReportWith(DefaultHealthThresholds)
+}
+
+// HealthReportWith checks the ring against t. Warnings are grouped by kind,
+// ordered by node within a kind.
+func (h *HashRing) HealthReportWith(t HealthThresholds) []HealthWarning {
+	h = h.orEmpty()
+	warnings := make([]HealthWarning, 0)
+
+	nodes := sortedNodes(h.nodes)
+	w := h.weighting()
+	uniqueWeight := 0
+	for _, node := range nodes {
+		uniqueWeight += w.weight(node, h.weights[node])
+	}
+	ownership := h.ownership()
+	for _, node := range nodes {
+		if _, ok := ownership[node]; !ok {
+			continue
+		}
+		expected := float64(w.weight(node, h.weights[node])) / float64(uniqueWeight)
+		deviation := ownership[node]/expected - 1
+		if math.Abs(deviation) > t.MaxOwnershipDeviation {
+			warnings = append(warnings, HealthWarning{
+				Kind:    WarnOwnershipDeviation,
+				Node:    node,
+				Message: fmt.Sprintf("owns %.2f%% of keyspace, expected %.2f%% by weight", ownership[node]*100, expected*100),
+			})
+		}
+	}
+
+	collisions := 0
+	for _, node := range nodes {
+		for _, key := range h.nodePoints(node, 0, h.nodeFactor(node, w)) {
+			if owner, _ := h.pointOwner(key); owner != node {
+				collisions++
+			}
+		}
+	}
+	if collisions > 0 {
+		warnings = append(warnings, HealthWarning{
+			Kind:    WarnCollision,
+			Message: fmt.Sprintf("%d virtual node collisions", collisions),
+		})
+	}
+
+	for _, node := range nodes {
+		if _, ok := ownership[node]; !ok && h.weights[node] > 0 {
+			warnings = append(warnings, HealthWarning{
+				Kind:    WarnNoPoints,
+				Node:    node,
+				Message: "owns no points on ring",
+			})
+		}
+	}
+
+	if len(h.sortedKeys) > t.MaxPoints {
+		warnings = append(warnings, HealthWarning{
+			Kind:    WarnRingSize,
+			Message: fmt.Sprintf("%d points on ring, limit is %d", len(h.sortedKeys), t.MaxPoints),
+		})
+	}
+
+	return warnings
+}
+
+// Imbalance returns the standard deviation of the nodes' relative ownership
+// error, i.e. of (keyspace share / weight share - 1). A perfectly balanced ring
+// returns 0. Weight shares are capped by WithMaxShare.
+func (h *HashRing) Imbalance() float64 {
+	h = h.orEmpty()
+	nodes := make([]string, 0, len(h.nodes))
+	for _, node := range sortedNodes(h.nodes) {
+		if h.weights[node] > 0 {
+			nodes = append(nodes, node)
+		}
+	}
+	if len(nodes) == 0 {
+		return 0
+	}
+	w := h.weighting()
+	uniqueWeight := 0
+	for _, node := range nodes {
+		uniqueWeight += w.weight(node, h.weights[node])
+	}
+
+	ownership := h.ownership()
+	sum := 0.0
+	for _, node := range nodes {
+		expected := float64(w.weight(node, h.weights[node])) / float64(uniqueWeight)
+		deviation := ownership[node]/expected - 1
+		sum += deviation * deviation
+	}
+	return math.Sqrt(sum / float64(len(nodes)))
+}
+
+// ownership returns the fraction of the keyspace owned by each node with at
+// least one point on ring.
+//
+// A point owns the keys from the previous point (inclusive) up to itself
+// (exclusive), the first point also owns the wrapped arc after the last one.
+func (h *HashRing) ownership() map[string]float64 {
+	owned := make(map[string]float64)
+	if len(h.sortedKeys) == 0 {
+		return owned
+	}
+
+	keyspace := h.keyspace()
+	last := h.sortedKeys[len(h.sortedKeys)-1]
+	for i, key := range h.sortedKeys {
+		var arc float64
+		if i == 0 {
+			arc = keyspace - float64(last) + float64(key)
+		} else {
+			arc = float64(key - h.sortedKeys[i-1])
+		}
+		owned[h.ownerAt(i)] += arc / keyspace
+	}
+	return owned
+}
+
+// sortedNodes returns a sorted copy of nodes without duplicates.
+func sortedNodes(nodes []string) []string {
+	seen := make(map[string]struct{}, len(nodes))
+	sorted := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		if _, ok := seen[node]; !ok {
+			seen[node] = struct{}{}
+			sorted = append(sorted, node)
+		}
+	}
+	sort.Strings(sorted)
+	return sorted
+}
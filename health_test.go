@@ -0,0 +1,61 @@
+package hashring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthReportHealthy(t *testing.T) {
+	hashRing := NewWithWeights(map[string]int{"a": 1, "b": 2, "c": 1})
+	assert.Empty(t, hashRing.HealthReport())
+}
+
+func TestHealthReportEmpty(t *testing.T) {
+	assert.Empty(t, New([]string{}).HealthReport())
+}
+
+func TestHealthReportOwnershipDeviation(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"})
+
+	warnings := hashRing.HealthReportWith(HealthThresholds{MaxOwnershipDeviation: 0, MaxPoints: 1000})
+	assert.Len(t, warnings, 3)
+	for i, node := range []string{"a", "b", "c"} {
+		assert.Equal(t, WarnOwnershipDeviation, warnings[i].Kind)
+		assert.Equal(t, node, warnings[i].Node)
+	}
+}
+
+func TestHealthReportRingSize(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"})
+
+	warnings := hashRing.HealthReportWith(HealthThresholds{MaxOwnershipDeviation: 1, MaxPoints: 10})
+	assert.Equal(t, []HealthWarning{{Kind: WarnRingSize, Message: "360 points on ring, limit is 10"}}, warnings)
+}
+
+func TestHealthReportCollision(t *testing.T) {
+	hashRing := New([]string{"a", "b"})
+
+	// Hand a's points over to b, as a collision would.
+	for i := range hashRing.owners {
+		hashRing.owners[i] = 1
+	}
+	assert.Equal(t, "b", hashRing.names[1])
+
+	warnings := hashRing.HealthReportWith(HealthThresholds{MaxOwnershipDeviation: 1, MaxPoints: 1000})
+	assert.Equal(t, []HealthWarning{
+		{Kind: WarnCollision, Message: "120 virtual node collisions"},
+		{Kind: WarnNoPoints, Node: "a", Message: "owns no points on ring"},
+	}, warnings)
+}
+
+func TestOwnership(t *testing.T) {
+	ownership := New([]string{"a"}).ownership()
+	assert.Equal(t, map[string]float64{"a": 1}, ownership)
+
+	total := 0.0
+	for _, share := range NewWithWeights(map[string]int{"a": 1, "b": 2, "c": 1}).ownership() {
+		total += share
+	}
+	assert.InDelta(t, 1, total, 1e-9)
+}
@@ -0,0 +1,94 @@
+package hashring
+
+// Tier is an entry of a hierarchical ring: a node when Children is nil,
+// otherwise a group (region, zone, ...) of weighted children.
+type Tier struct {
+	// Weight is the weight within the parent level, 1 if zero.
+	Weight   int
+	Children map[string]Tier
+}
+
+// HierarchicalRing models multi-level topologies such as region → zone → node.
+// A key first selects a region on the region ring, then a zone on that
+// region's ring, and so on down to a node. Each level has its own weights.
+type HierarchicalRing struct {
+	ring     *HashRing
+	children map[string]*HierarchicalRing
+}
+
+// NewHierarchical creates a HierarchicalRing from the top level tiers.
+// Groups without any node are left out. opts apply to the ring of every level;
+// given limits, it panics, see NewHierarchicalChecked.
+//
+//	ring := hashring.NewHierarchical(map[string]hashring.Tier{
+//		"us-east": {Weight: 2, Children: map[string]hashring.Tier{
+//			"us-east-1a": {Children: map[string]hashring.Tier{"a": {}, "b": {}}},
+//			"us-east-1b": {Children: map[string]hashring.Tier{"c": {}}},
+//		}},
+//		"eu-west": {Children: map[string]hashring.Tier{
+//			"eu-west-1a": {Children: map[string]hashring.Tier{"d": {}}},
+//		}},
+//	})
+func NewHierarchical(tiers map[string]Tier, opts ...Option) *HierarchicalRing {
+	newConfig(opts).mustBeUnlimited("NewHierarchical")
+	h, _ := newHierarchical(tiers, opts)
+	return h
+}
+
+// NewHierarchicalChecked creates a HierarchicalRing like NewHierarchical, or
+// returns the LimitError of the first level whose ring exceeds the limits.
+func NewHierarchicalChecked(tiers map[string]Tier, opts ...Option) (*HierarchicalRing, error) {
+	return newHierarchical(tiers, opts)
+}
+
+func newHierarchical(tiers map[string]Tier, opts []Option) (*HierarchicalRing, error) {
+	weights := make(map[string]int, len(tiers))
+	children := make(map[string]*HierarchicalRing)
+	for name, tier := range tiers {
+		if tier.Children != nil {
+			child, err := newHierarchical(tier.Children, opts)
+			if err != nil {
+				return nil, err
+			}
+			if child.ring.Size() == 0 {
+				continue
+			}
+			children[name] = child
+		}
+
+		weight := tier.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		weights[name] = weight
+	}
+
+	ring, err := NewWithWeightsChecked(weights, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &HierarchicalRing{ring: ring, children: children}, nil
+}
+
+// GetNode returns the node that stringKey belongs to.
+func (h *HierarchicalRing) GetNode(stringKey string) (node string, ok bool) {
+	path, ok := h.GetPath(stringKey)
+	if !ok {
+		return "", false
+	}
+	return path[len(path)-1], true
+}
+
+// GetPath returns the entry selected at each level for stringKey, from the top
+// level down to the node, e.g. [region zone node].
+func (h *HierarchicalRing) GetPath(stringKey string) (path []string, ok bool) {
+	for level := h; level != nil; {
+		name, ok := level.ring.GetNode(stringKey)
+		if !ok {
+			return nil, false
+		}
+		path = append(path, name)
+		level = level.children[name]
+	}
+	return path, true
+}
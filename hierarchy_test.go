@@ -0,0 +1,67 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestHierarchy() *HierarchicalRing {
+	return NewHierarchical(map[string]Tier{
+		"us": {Weight: 2, Children: map[string]Tier{
+			"us-1": {Children: map[string]Tier{"a": {}, "b": {}}},
+			"us-2": {Children: map[string]Tier{"c": {Weight: 3}}},
+		}},
+		"eu": {Children: map[string]Tier{
+			"eu-1": {Children: map[string]Tier{"d": {}}},
+			"eu-2": {Children: map[string]Tier{}},
+		}},
+	})
+}
+
+func TestHierarchicalRing(t *testing.T) {
+	hashRing := newTestHierarchy()
+
+	parents := map[string][]string{
+		"a": {"us", "us-1"},
+		"b": {"us", "us-1"},
+		"c": {"us", "us-2"},
+		"d": {"eu", "eu-1"},
+	}
+	counts := make(map[string]int)
+	for i := 0; i < 3000; i++ {
+		key := strconv.Itoa(i)
+		path, ok := hashRing.GetPath(key)
+		assert.True(t, ok)
+		assert.Len(t, path, 3)
+
+		node, ok := hashRing.GetNode(key)
+		assert.True(t, ok)
+		assert.Equal(t, path[2], node)
+		assert.Equal(t, parents[node], path[:2])
+		counts[path[0]]++
+	}
+
+	// us has twice the weight of eu.
+	assert.InDelta(t, 2000, counts["us"], 300)
+	assert.InDelta(t, 1000, counts["eu"], 300)
+}
+
+func TestHierarchicalRingSkipsEmptyGroups(t *testing.T) {
+	hashRing := newTestHierarchy()
+	assert.Equal(t, 1, hashRing.children["eu"].ring.Size())
+}
+
+func TestHierarchicalRingFlat(t *testing.T) {
+	hashRing := NewHierarchical(map[string]Tier{"a": {}, "b": {}, "c": {}})
+	for _, key := range []string{"test", "test1", "test3", "aaaa"} {
+		expected, _ := New([]string{"a", "b", "c"}).GetNode(key)
+		node, ok := hashRing.GetNode(key)
+		assert.True(t, ok)
+		assert.Equal(t, expected, node)
+	}
+
+	_, ok := NewHierarchical(nil).GetNode("test")
+	assert.False(t, ok)
+}
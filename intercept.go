@@ -0,0 +1,92 @@
+package hashring
+
+import "time"
+
+// Interceptor observes the operations of a ring, so metrics, tracing, shadow
+// comparisons or audit logs can be layered on without this package knowing
+// them, see WithInterceptors. Any hook may be nil. Hooks run on the goroutine
+// of the operation, so they should return quickly, and must be safe for
+// concurrent use.
+type Interceptor struct {
+	// BeforeLookup is called with the key of GetNode, GetNodes,
+	// GetNodesExcluding or GetUpToNodes before the lookup.
+	BeforeLookup func(key string)
+	// AfterLookup is called with the result of the lookup.
+	AfterLookup func(LookupEvent)
+	// OnRebuild is called after the points of a ring were placed, by New or a
+	// change such as AddNode.
+	OnRebuild func(RebuildEvent)
+}
+
+// LookupEvent is a lookup seen by Interceptor.AfterLookup.
+type LookupEvent struct {
+	Key string
+	// Nodes holds the node of GetNode, or the nodes of GetNodes.
+	Nodes    []string
+	OK       bool
+	Duration time.Duration
+}
+
+// RebuildEvent is a rebuild seen by Interceptor.OnRebuild.
+type RebuildEvent struct {
+	// Ring is the ring rebuilt. Its points are placed, but it is not yet
+	// returned to the caller of the change.
+	Ring     *HashRing
+	Duration time.Duration
+	Points   int
+}
+
+// WithInterceptors adds interceptors to the ring, after those of earlier
+// WithInterceptors. Before hooks run in order, after hooks in reverse order,
+// so each interceptor wraps the ones after it. Rings derived by AddNode,
+// RemoveNode and friends keep them.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(c *config) {
+		c.interceptors = append(c.interceptors[:len(c.interceptors):len(c.interceptors)], interceptors...)
+	}
+}
+
+// intercepted reports whether h has interceptors.
+func (h *HashRing) intercepted() bool {
+	return h != nil && len(h.config.interceptors) > 0
+}
+
+// beforeLookup calls the BeforeLookup hooks of h, and returns the start of
+// the lookup.
+func (h *HashRing) beforeLookup(key string) time.Time {
+	for _, i := range h.config.interceptors {
+		if i.BeforeLookup != nil {
+			i.BeforeLookup(key)
+		}
+	}
+	return time.Now()
+}
+
+// afterLookup calls the AfterLookup hooks of h with the result of GetNode.
+func (h *HashRing) afterLookup(key string, start time.Time, node *string, ok *bool) {
+	var nodes []string
+	if *ok {
+		nodes = []string{*node}
+	}
+	h.afterLookups(key, start, &nodes, ok)
+}
+
+// afterLookups calls the AfterLookup hooks of h with the result of GetNodes.
+func (h *HashRing) afterLookups(key string, start time.Time, nodes *[]string, ok *bool) {
+	e := LookupEvent{Key: key, Nodes: *nodes, OK: *ok, Duration: time.Since(start)}
+	for i := len(h.config.interceptors) - 1; i >= 0; i-- {
+		if after := h.config.interceptors[i].AfterLookup; after != nil {
+			after(e)
+		}
+	}
+}
+
+// interceptRebuild calls the OnRebuild hooks of h for a rebuild of d.
+func (h *HashRing) interceptRebuild(d time.Duration) {
+	e := RebuildEvent{Ring: h, Duration: d, Points: len(h.sortedKeys)}
+	for _, i := range h.config.interceptors {
+		if i.OnRebuild != nil {
+			i.OnRebuild(e)
+		}
+	}
+}
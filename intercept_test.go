@@ -0,0 +1,89 @@
+package hashring
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// callRecorder records the hooks called, in order.
+type callRecorder struct {
+	mu       sync.Mutex
+	calls    []string
+	lookups  []LookupEvent
+	rebuilds []RebuildEvent
+}
+
+func (r *callRecorder) interceptor(name string) Interceptor {
+	record := func(call string) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.calls = append(r.calls, name+" "+call)
+	}
+	return Interceptor{
+		BeforeLookup: func(key string) { record("before " + key) },
+		AfterLookup: func(e LookupEvent) {
+			record("after " + e.Key)
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.lookups = append(r.lookups, e)
+		},
+		OnRebuild: func(e RebuildEvent) {
+			record("rebuild")
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.rebuilds = append(r.rebuilds, e)
+		},
+	}
+}
+
+func (r *callRecorder) reset() {
+	r.calls, r.lookups, r.rebuilds = nil, nil, nil
+}
+
+func TestWithInterceptors(t *testing.T) {
+	var r callRecorder
+	hashRing := New([]string{"a", "b", "c"}, WithInterceptors(r.interceptor("outer")),
+		WithInterceptors(r.interceptor("inner")))
+	assert.Equal(t, []string{"outer rebuild", "inner rebuild"}, r.calls)
+	assert.Equal(t, hashRing, r.rebuilds[0].Ring)
+	assert.Equal(t, len(hashRing.sortedKeys), r.rebuilds[0].Points)
+
+	r.reset()
+	node, ok := hashRing.GetNode("test")
+	assert.Equal(t, []string{"outer before test", "inner before test", "inner after test", "outer after test"}, r.calls)
+	assert.Equal(t, LookupEvent{Key: "test", Nodes: []string{node}, OK: ok, Duration: r.lookups[0].Duration}, r.lookups[0])
+
+	r.reset()
+	nodes, _ := hashRing.GetNodes("test", 2)
+	assert.Equal(t, nodes, r.lookups[0].Nodes)
+	_, ok = hashRing.GetNodes("test", 4)
+	assert.False(t, ok)
+	// Each lookup is seen by both interceptors.
+	assert.False(t, r.lookups[2].OK)
+	nodes, _ = hashRing.GetNodesExcluding("test", 2, []string{"a"})
+	assert.Equal(t, nodes, r.lookups[4].Nodes)
+
+	// Derived rings keep the interceptors.
+	r.reset()
+	added := hashRing.AddNode("d")
+	assert.Equal(t, []string{"outer rebuild", "inner rebuild"}, r.calls)
+	assert.Equal(t, added, r.rebuilds[0].Ring)
+	added.GetNode("test")
+	assert.Len(t, r.lookups, 2)
+
+	// A failed lookup of an empty ring is seen too.
+	r.reset()
+	_, ok = New(nil, WithInterceptors(r.interceptor("empty"))).GetNode("test")
+	assert.False(t, ok)
+	assert.Equal(t, LookupEvent{Key: "test", Duration: r.lookups[0].Duration}, r.lookups[0])
+}
+
+func TestInterceptorNilHooks(t *testing.T) {
+	hashRing := New([]string{"a", "b"}, WithInterceptors(Interceptor{}))
+	_, ok := hashRing.GetNode("test")
+	assert.True(t, ok)
+	_, ok = hashRing.AddNode("c").GetNodes("test", 2)
+	assert.True(t, ok)
+}
@@ -0,0 +1,40 @@
+package hashring
+
+import "iter"
+
+// WalkFrom returns the distinct nodes of the ring in ring order from the
+// position of stringKey, the first being its node, see GetNodes. Callers can
+// stop early or filter the nodes as they go:
+//
+//	for node := range ring.WalkFrom(key) {
+//		if healthy(node) {
+//			return node
+//		}
+//	}
+//
+// Each range over the sequence walks the ring anew. Standbys are never
+// yielded.
+func (h *HashRing) WalkFrom(stringKey string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		pos, ok := h.GetNodePos(stringKey)
+		if !ok {
+			return
+		}
+		seen := make(map[int32]bool)
+		skips := len(h.skips) == len(h.sortedKeys)
+		for i, step := pos, 1; i < pos+len(h.sortedKeys) && len(seen) < len(h.names); i += step {
+			j := i % len(h.sortedKeys)
+			if skips {
+				step = int(h.skips[j])
+			}
+			owner := h.owners[j]
+			if seen[owner] {
+				continue
+			}
+			seen[owner] = true
+			if !yield(h.names[owner]) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package hashring
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalkFrom(t *testing.T) {
+	hashRing := NewWithWeights(map[string]int{"a": 1, "b": 2, "c": 1, "d": 1, "s": 0})
+	for i := 0; i < 200; i++ {
+		key := strconv.Itoa(i)
+		nodes, _ := hashRing.GetNodes(key, 4)
+		assert.Equal(t, nodes, slices.Collect(hashRing.WalkFrom(key)), key)
+
+		// Stopping early.
+		var first []string
+		for node := range hashRing.WalkFrom(key) {
+			first = append(first, node)
+			if len(first) == 2 {
+				break
+			}
+		}
+		assert.Equal(t, nodes[:2], first)
+	}
+
+	assert.Empty(t, slices.Collect(New(nil).WalkFrom("key")))
+	var empty *HashRing
+	assert.Empty(t, slices.Collect(empty.WalkFrom("key")))
+}
@@ -0,0 +1,92 @@
+package hashring
+
+// JumpHash returns the bucket of key among buckets, by jump consistent hash
+// (Lamping and Veach). Growing buckets by one moves only the keys going to
+// the new bucket; it runs in O(log buckets) and does not allocate. It
+// returns -1 if buckets is not positive.
+func JumpHash(key uint64, buckets int) int {
+	if buckets <= 0 {
+		return -1
+	}
+	b, j := int64(-1), int64(0)
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
+
+// Jump maps keys to a fixed pool of nodes by JumpHash of their xxHash64,
+// much faster than the ring and without allocating. Nodes are buckets in the
+// order given, so nodes can only be added or removed at the end without
+// moving more keys than needed; weights are not supported. Jump implements
+// NodeLocator.
+type Jump struct {
+	nodes []string
+	hash  *HashRing // hashes keys, without nodes; nil for xxHash64.
+}
+
+// NewJump creates a Jump over nodes, bucket i being nodes[i]. Given opts,
+// keys are hashed as by a HashRing of the same options, e.g. WithHasher and
+// With64BitKeys, instead of by xxHash64.
+func NewJump(nodes []string, opts ...Option) *Jump {
+	j := &Jump{nodes: append([]string{}, nodes...)}
+	if len(opts) > 0 {
+		j.hash = &HashRing{config: newConfig(opts)}
+	}
+	return j
+}
+
+// with returns a Jump over nodes hashing keys as j does.
+func (j *Jump) with(nodes []string) *Jump {
+	next := &Jump{nodes: nodes}
+	if j != nil {
+		next.hash = j.hash
+	}
+	return next
+}
+
+// Nodes returns the nodes of j, in bucket order.
+func (j *Jump) Nodes() []string {
+	if j == nil {
+		return nil
+	}
+	return append([]string{}, j.nodes...)
+}
+
+// Size returns the number of nodes of j.
+func (j *Jump) Size() int {
+	if j == nil {
+		return 0
+	}
+	return len(j.nodes)
+}
+
+// AddNode returns a new Jump with node as the last bucket. Only keys moving
+// to node move.
+func (j *Jump) AddNode(node string) *Jump {
+	return j.with(append(j.Nodes(), node))
+}
+
+// RemoveLastNode returns a new Jump without the last bucket. Only its keys
+// move.
+func (j *Jump) RemoveLastNode() *Jump {
+	nodes := j.Nodes()
+	if len(nodes) == 0 {
+		return j
+	}
+	return j.with(nodes[:len(nodes)-1])
+}
+
+// GetNode returns the node stringKey belongs to. It implements NodeLocator.
+func (j *Jump) GetNode(stringKey string) (node string, ok bool) {
+	if j == nil || len(j.nodes) == 0 {
+		return "", false
+	}
+	key := xxh64(stringKey)
+	if j.hash != nil {
+		key = uint64(j.hash.GenKey64(stringKey))
+	}
+	return j.nodes[JumpHash(key, len(j.nodes))], true
+}
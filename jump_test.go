@@ -0,0 +1,67 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJumpHash(t *testing.T) {
+	// Values of the reference implementation.
+	assert.Equal(t, 0, JumpHash(0, 1))
+	assert.Equal(t, 0, JumpHash(0, 100))
+	assert.Equal(t, 6, JumpHash(1, 10))
+	assert.Equal(t, -1, JumpHash(1, 0))
+
+	counts := make([]int, 10)
+	for key := uint64(0); key < 10000; key++ {
+		b := JumpHash(xxh64(strconv.FormatUint(key, 10)), 10)
+		counts[b]++
+
+		// Growing the buckets moves keys to the new bucket only.
+		if next := JumpHash(xxh64(strconv.FormatUint(key, 10)), 11); next != b {
+			assert.Equal(t, 10, next)
+		}
+	}
+	for _, count := range counts {
+		assert.InDelta(t, 1000, count, 150)
+	}
+}
+
+func TestJump(t *testing.T) {
+	j := NewJump([]string{"a", "b", "c"})
+	assert.Equal(t, []string{"a", "b", "c"}, j.Nodes())
+	added := j.AddNode("d")
+	assert.Equal(t, 4, added.Size())
+	assert.Equal(t, j.Nodes(), added.RemoveLastNode().Nodes())
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		before, ok := j.GetNode(key)
+		assert.True(t, ok)
+		if after, _ := added.GetNode(key); after != before {
+			assert.Equal(t, "d", after)
+		}
+	}
+
+	var nilJump *Jump
+	_, ok := nilJump.GetNode("test")
+	assert.False(t, ok)
+	_, ok = NewJump(nil).RemoveLastNode().GetNode("test")
+	assert.False(t, ok)
+	node, _ := nilJump.AddNode("a").GetNode("test")
+	assert.Equal(t, "a", node)
+}
+
+func BenchmarkJump(b *testing.B) {
+	nodes := make([]string, 300)
+	for i := range nodes {
+		nodes[i] = "node-" + strconv.Itoa(i)
+	}
+	j := NewJump(nodes)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		j.GetNode("test")
+	}
+}
@@ -0,0 +1,44 @@
+package hashring
+
+import "math"
+
+// placement selects how nodes are placed on the ring.
+type placement int
+
+const (
+	placementDefault placement = iota
+	placementKetama
+	placementNginx
+)
+
+// ketamaPointsPerServer is the number of points of a node of average weight
+// in ketama, 4 per md5 digest.
+const ketamaPointsPerServer = 160
+
+// WithKetama places nodes and keys as the weighted ketama of libmemcached
+// and twemproxy do, so a ring routes keys like memcached clients using them:
+//
+//   - a node of weight w out of a total of W among n nodes hashes
+//     "<node>-<i>" with md5 for i below floor(w/W*160/4*n), each digest
+//     giving 4 points;
+//   - a key belongs to the first point at or after the first 4 bytes of its
+//     md5, little-endian.
+//
+// Nodes must be named as the clients name servers, e.g. "10.0.0.1:11211" for
+// twemproxy, and "10.0.0.1" for libmemcached on the default port.
+//
+// WithKetama overrides WithHasher, With64BitKeys, WithSeed, WithBoundary and
+// WithTargetImbalance. A node whose weight share rounds down to no digest has
+// no points, as in ketama.
+func WithKetama() Option {
+	return func(c *config) {
+		c.placement = placementKetama
+	}
+}
+
+// ketamaFactor returns the number of md5 digests of a node of weight out of
+// totalWeight among nodes, in ketama's float32 arithmetic.
+func ketamaFactor(weight, totalWeight, nodes int) int {
+	pct := float32(weight) / float32(totalWeight)
+	return int(math.Floor(float64(pct*ketamaPointsPerServer/4*float32(nodes)) + 0.0000000001))
+}
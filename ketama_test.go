@@ -0,0 +1,84 @@
+package hashring
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"math"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ketamaContinuum builds the continuum as libmemcached's update_continuum
+// does for MEMCACHED_BEHAVIOR_KETAMA_WEIGHTED, transcribed from its C source
+// rather than built on ketamaFactor, for comparison.
+func ketamaContinuum(servers []string, weights map[string]int) (points []uint32, owners map[uint32]string) {
+	total := 0
+	for _, server := range servers {
+		total += weights[server]
+	}
+	owners = make(map[uint32]string)
+	for _, server := range servers {
+		// float pct = (float)weight / (float)total_weight;
+		// pointer_per_server = (uint32_t)((floor((float)(pct *
+		//     MEMCACHED_POINTS_PER_SERVER_KETAMA / 4 * (float)number_of_hosts +
+		//     0.0000000001))) * 4);
+		pct := float32(weights[server]) / float32(total)
+		pointerPerServer := int(math.Floor(float64(float32(float64(pct*160/4*float32(len(servers)))+0.0000000001)))) * 4
+		for i := 0; i < pointerPerServer/4; i++ {
+			digest := md5.Sum([]byte(server + "-" + strconv.Itoa(i)))
+			for a := 0; a < 4; a++ {
+				point := binary.LittleEndian.Uint32(digest[a*4:])
+				points = append(points, point)
+				owners[point] = server
+			}
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i] < points[j] })
+	return points, owners
+}
+
+func TestWithKetama(t *testing.T) {
+	servers := []string{"10.0.0.1:11211", "10.0.0.2:11211", "10.0.0.3:11211", "10.0.0.4:11211"}
+	weights := map[string]int{servers[0]: 1, servers[1]: 2, servers[2]: 1, servers[3]: 3}
+	hashRing := NewWithWeights(weights, WithKetama(), With64BitKeys(), WithHasher(XXHash64))
+	assert.NoError(t, hashRing.Validate())
+
+	points, owners := ketamaContinuum(servers, weights)
+	assert.Len(t, hashRing.sortedKeys, len(points))
+	for i := 0; i < 2000; i++ {
+		key := "key-" + strconv.Itoa(i)
+		digest := md5.Sum([]byte(key))
+		hash := binary.LittleEndian.Uint32(digest[:])
+		pos := sort.Search(len(points), func(i int) bool { return points[i] >= hash })
+		if pos == len(points) {
+			pos = 0
+		}
+
+		assert.Equal(t, HashKey(hash), hashRing.GenKey(key))
+		node, ok := hashRing.GetNode(key)
+		assert.True(t, ok)
+		assert.Equal(t, owners[points[pos]], node, key)
+	}
+
+	// Derived rings place points like ketama does for the new servers.
+	added := hashRing.AddWeightedNode("10.0.0.5:11211", 2)
+	points, _ = ketamaContinuum(append(servers, "10.0.0.5:11211"), added.weights)
+	assert.Len(t, added.sortedKeys, len(points))
+}
+
+func TestKetamaFactor(t *testing.T) {
+	assert.Equal(t, 40, ketamaFactor(1, 4, 4))
+	assert.Equal(t, 26, ketamaFactor(1, 3, 2))
+	assert.Equal(t, 53, ketamaFactor(2, 3, 2))
+	// Rounded in float32, an even share may fall just short of 40 digests.
+	assert.Equal(t, 39, ketamaFactor(1, 25, 25))
+	weights := make(map[string]int)
+	for i := 0; i < 25; i++ {
+		weights["10.0.0."+strconv.Itoa(i)] = 1
+	}
+	points, _ := ketamaContinuum(nodesOf(weights), weights)
+	assert.Len(t, points, 25*39*4)
+}
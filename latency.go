@@ -0,0 +1,73 @@
+package hashring
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// latencySampleEvery is the fraction of lookups timed by a latency budget,
+	// one in latencySampleEvery, so the rest do not pay for the clock.
+	latencySampleEvery = 64
+	// latencyWindow is the number of samples the p99 is computed over.
+	latencyWindow = 128
+)
+
+// WithLatencyBudget times a sample of the lookups of GetNode and GetNodes
+// and calls onExceeded with a WarnLookupLatency warning whenever the p99
+// latency of a window of samples exceeds budget, e.g. because the ring grew
+// huge. onExceeded runs on the goroutine of a lookup, so it should return
+// quickly, e.g. by logging or by signalling a rebuild with fewer points.
+//
+// Rings derived by AddNode, RemoveNode and friends share the budget's
+// samples.
+func WithLatencyBudget(budget time.Duration, onExceeded func(HealthWarning)) Option {
+	return func(c *config) {
+		c.latency = &latencyGuard{budget: budget, onExceeded: onExceeded}
+	}
+}
+
+// latencyGuard gathers the lookup latencies of WithLatencyBudget.
+type latencyGuard struct {
+	budget     time.Duration
+	onExceeded func(HealthWarning)
+	lookups    atomic.Uint64
+
+	mu      sync.Mutex
+	samples [latencyWindow]time.Duration
+	n       int
+}
+
+// sampled reports whether the current lookup of h is to be timed.
+func (h *HashRing) sampled() bool {
+	return h != nil && h.config.latency != nil && h.config.latency.lookups.Add(1)%latencySampleEvery == 0
+}
+
+// observeLookup records a lookup of h started at start, see sampled.
+func (h *HashRing) observeLookup(start time.Time) {
+	g := h.config.latency
+	d := time.Since(start)
+
+	g.mu.Lock()
+	g.samples[g.n] = d
+	g.n++
+	if g.n < latencyWindow {
+		g.mu.Unlock()
+		return
+	}
+	window := g.samples
+	g.n = 0
+	g.mu.Unlock()
+
+	sort.Slice(window[:], func(i, j int) bool { return window[i] < window[j] })
+	p99 := window[(latencyWindow*99+99)/100-1]
+	if p99 > g.budget && g.onExceeded != nil {
+		g.onExceeded(HealthWarning{
+			Kind:    WarnLookupLatency,
+			Message: fmt.Sprintf("p99 lookup latency %v exceeds the budget of %v with %d points on ring", p99, g.budget, len(h.sortedKeys)),
+		})
+	}
+}
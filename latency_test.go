@@ -0,0 +1,48 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithLatencyBudget(t *testing.T) {
+	var warnings []HealthWarning
+	onExceeded := func(w HealthWarning) { warnings = append(warnings, w) }
+
+	hashRing := New([]string{"a", "b", "c"}, WithLatencyBudget(time.Hour, onExceeded))
+	for i := 0; i < 4*latencySampleEvery*latencyWindow; i++ {
+		hashRing.GetNode(strconv.Itoa(i))
+	}
+	assert.Empty(t, warnings)
+
+	hashRing = New([]string{"a", "b", "c"}, WithLatencyBudget(time.Nanosecond, onExceeded))
+	for i := 0; i < latencySampleEvery*latencyWindow-1; i++ {
+		hashRing.GetNode(strconv.Itoa(i))
+	}
+	assert.Empty(t, warnings)
+	hashRing.GetNodes("last", 2)
+	if assert.Len(t, warnings, 1) {
+		assert.Equal(t, WarnLookupLatency, warnings[0].Kind)
+		assert.Contains(t, warnings[0].Message, "360 points")
+	}
+
+	// Derived rings share the samples.
+	added := hashRing.AddNode("d")
+	for i := 0; i < latencySampleEvery*latencyWindow; i++ {
+		added.GetNode(strconv.Itoa(i))
+	}
+	if assert.Len(t, warnings, 2) {
+		assert.Contains(t, warnings[1].Message, "480 points")
+	}
+}
+
+func BenchmarkHashesLatencyBudget(b *testing.B) {
+	hashRing := New([]string{"a", "b", "c", "d", "e", "f", "g"}, WithLatencyBudget(time.Millisecond, nil))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hashRing.GetNode("test")
+	}
+}
@@ -0,0 +1,142 @@
+package hashring
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// OwnershipLease holds a change of a registered ring back from Gets until
+// every node the change gives hashes to has acquired them, see
+// AcquireOwnership, so readers never ask a new owner for keys it has not
+// taken over yet, e.g. during a scale-out:
+//
+//	lease := hashring.LeaseOwnership("cache", func(ring *hashring.HashRing) *hashring.HashRing {
+//		return ring.AddNode("10.0.0.5:11211")
+//	})
+//	// On 10.0.0.5, once the keys of lease.Gained("10.0.0.5:11211") are migrated:
+//	lease.AcquireOwnership("10.0.0.5:11211", lease.Gained("10.0.0.5:11211")...)
+//
+// An OwnershipLease is safe for concurrent use.
+type OwnershipLease struct {
+	name       string
+	base, next *HashRing
+	gained     map[string][]Range
+
+	mu      sync.Mutex
+	pending map[string][]Range // gained ranges not acquired yet, by node.
+	done    bool
+}
+
+// errLeaseDone is returned when acquiring ranges of a lease no longer held.
+var errLeaseDone = errors.New("hashring: ownership lease already released")
+
+// LeaseOwnership derives the next ring of name by fn, like Update, but leaves
+// the current ring registered until the nodes that gain hashes by the change
+// acquire them. A change that gives no node hashes is registered at once.
+func LeaseOwnership(name string, fn func(ring *HashRing) *HashRing) *OwnershipLease {
+	base, _ := Get(name)
+	next := fn(base)
+	// The hashes a node gains are those next owns and base gives another.
+	gained := LostRanges(next, base)
+	l := &OwnershipLease{name: name, base: base, next: next, gained: gained, pending: make(map[string][]Range, len(gained))}
+	for node, ranges := range gained {
+		l.pending[node] = ranges
+	}
+	if len(l.pending) == 0 {
+		l.release()
+	}
+	return l
+}
+
+// Next returns the ring the lease holds back.
+func (l *OwnershipLease) Next() *HashRing {
+	return l.next
+}
+
+// Gained returns the hashes node gains by the change, sorted and disjoint.
+func (l *OwnershipLease) Gained(node string) []Range {
+	return append([]Range(nil), l.gained[node]...)
+}
+
+// Pending returns the nodes that have ranges left to acquire, sorted.
+func (l *OwnershipLease) Pending() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	nodes := make([]string, 0, len(l.pending))
+	for node := range l.pending {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// AcquireOwnership confirms that node has taken over the hashes it gains in
+// ranges, and registers the next ring once every node has acquired all it
+// gains, reporting whether it did. Acquiring a range twice does nothing.
+//
+// It returns an error if node gains nothing by the change, if the lease was
+// released already, or if the ring of name changed since LeaseOwnership, in
+// which case the next ring is dropped. While name is frozen, see Freeze, the
+// next ring is held like any change, and dropped at Unfreeze if name changed
+// before it.
+func (l *OwnershipLease) AcquireOwnership(node string, ranges ...Range) (registered bool, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.done {
+		return false, errLeaseDone
+	}
+	if _, ok := l.gained[node]; !ok {
+		return false, fmt.Errorf("hashring: node %q gains no hashes by the lease", node)
+	}
+	left := l.pending[node]
+	for _, r := range ranges {
+		left = subtractRange(left, r)
+	}
+	if len(left) > 0 {
+		l.pending[node] = left
+		return false, nil
+	}
+	delete(l.pending, node)
+	if len(l.pending) > 0 {
+		return false, nil
+	}
+	if !l.release() {
+		return false, fmt.Errorf("hashring: ring %q changed since the lease", l.name)
+	}
+	return true, nil
+}
+
+// release registers the next ring unless the ring of name changed since the
+// lease, and reports whether it did. Held while frozen, it reports true.
+func (l *OwnershipLease) release() bool {
+	l.done = true
+	changed := false
+	Update(l.name, func(ring *HashRing) *HashRing {
+		if ring != l.base {
+			changed = true
+			return ring
+		}
+		return l.next
+	})
+	return !changed
+}
+
+// subtractRange returns the sorted, disjoint ranges without the hashes of r.
+func subtractRange(ranges []Range, r Range) []Range {
+	var left []Range
+	for _, have := range ranges {
+		if have.End < r.Start || r.End < have.Start {
+			left = append(left, have)
+			continue
+		}
+		if have.Start < r.Start {
+			left = append(left, Range{have.Start, r.Start - 1})
+		}
+		if r.End < have.End {
+			left = append(left, Range{r.End + 1, have.End})
+		}
+	}
+	return left
+}
@@ -0,0 +1,90 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOwnershipLease(t *testing.T) {
+	defer Unregister("test-lease")
+
+	ring := New([]string{"a", "b", "c"})
+	Register("test-lease", ring)
+	lease := LeaseOwnership("test-lease", func(ring *HashRing) *HashRing {
+		return ring.AddNode("d")
+	})
+	assert.Equal(t, []string{"d"}, lease.Pending(), "only the added node gains hashes")
+	gained := lease.Gained("d")
+	assert.Greater(t, len(gained), 1)
+	size := func(ranges []Range) (n HashKey64) {
+		for _, r := range ranges {
+			n += r.End - r.Start + 1
+		}
+		return n
+	}
+	lost := HashKey64(0)
+	for _, ranges := range LostRanges(ring, lease.Next()) {
+		lost += size(ranges)
+	}
+	assert.Equal(t, lost, size(gained), "d gains what the others lose")
+
+	// Readers keep the old owners while d migrates.
+	for i := 0; i < 300; i++ {
+		key := strconv.Itoa(i)
+		got, _ := Get("test-lease")
+		want, _ := ring.GetNode(key)
+		expectNode(t, got, key, want)
+	}
+
+	registered, err := lease.AcquireOwnership("d", gained[1:]...)
+	assert.NoError(t, err)
+	assert.False(t, registered)
+	assert.Equal(t, []string{"d"}, lease.Pending())
+	got, _ := Get("test-lease")
+	assert.Same(t, ring, got)
+
+	_, err = lease.AcquireOwnership("a", gained...)
+	assert.Error(t, err, "a gains nothing")
+	registered, err = lease.AcquireOwnership("d", gained[0])
+	assert.NoError(t, err)
+	assert.True(t, registered)
+	assert.Empty(t, lease.Pending())
+	got, _ = Get("test-lease")
+	assert.Same(t, lease.Next(), got)
+	_, err = lease.AcquireOwnership("d", gained...)
+	assert.Error(t, err)
+}
+
+func TestOwnershipLeaseChanged(t *testing.T) {
+	defer Unregister("test-lease-changed")
+
+	Register("test-lease-changed", New([]string{"a", "b"}))
+	lease := LeaseOwnership("test-lease-changed", func(ring *HashRing) *HashRing {
+		return ring.AddNode("c")
+	})
+	changed := Update("test-lease-changed", func(ring *HashRing) *HashRing {
+		return ring.RemoveNode("b")
+	})
+	_, err := lease.AcquireOwnership("c", lease.Gained("c")...)
+	assert.Error(t, err)
+	got, _ := Get("test-lease-changed")
+	assert.Same(t, changed, got, "the leased ring is dropped")
+
+	// A change that gives no node hashes is registered at once.
+	lease = LeaseOwnership("test-lease-changed", func(ring *HashRing) *HashRing {
+		return ring.AddWeightedNode("standby", 0)
+	})
+	assert.Empty(t, lease.Pending())
+	got, _ = Get("test-lease-changed")
+	assert.Same(t, lease.Next(), got)
+}
+
+func TestSubtractRange(t *testing.T) {
+	ranges := []Range{{0, 9}, {20, 29}, {40, 49}}
+	assert.Equal(t, []Range{{0, 4}, {26, 29}, {40, 49}}, subtractRange(ranges, Range{5, 25}))
+	assert.Equal(t, []Range{{0, 9}, {20, 24}, {26, 29}, {40, 49}}, subtractRange(ranges, Range{25, 25}))
+	assert.Empty(t, subtractRange(ranges, Range{0, 49}))
+	assert.Equal(t, ranges, subtractRange(ranges, Range{10, 19}))
+}
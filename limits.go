@@ -0,0 +1,137 @@
+package hashring
+
+import "fmt"
+
+// WithMaxNodes limits rings to n nodes, see LimitError.
+func WithMaxNodes(n int) Option {
+	return func(c *config) {
+		c.maxNodes = n
+	}
+}
+
+// WithMaxPoints limits rings to n points, virtual nodes included, see
+// LimitError. WithTargetImbalance stops growing the virtual nodes at the limit.
+func WithMaxPoints(n int) Option {
+	return func(c *config) {
+		c.maxPoints = n
+	}
+}
+
+// LimitError is returned when a ring would exceed WithMaxNodes or
+// WithMaxPoints, e.g. as a discovery bug floods membership.
+//
+// The limits are only accepted where a LimitError can be returned, before
+// any point is placed: by NewChecked, NewWithWeightsChecked and the other
+// Checked constructors, such as RingFlag.RingChecked, and on the rings they
+// create by AddNodeChecked, AddWeightedNodeChecked, UpdateWeightedNodeChecked,
+// ApplyChecked, ApplyTopologyChecked, UpdateWithWeightsContext, ApplyWeights
+// and PrepareTopology, and by ImportCSV. The constructors and changes without an error result,
+// such as New, AddNode and Apply, panic when given limits or called on a ring
+// with limits, rather than grow it past them or drop the change silently;
+// NewConsistentHasher returns an error instead. Removing and renaming nodes
+// never exceeds the limits and is always allowed.
+type LimitError struct {
+	// Limit is "nodes" or "points".
+	Limit       string
+	Max, Actual int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("hashring: %d %s exceed the limit of %d", e.Actual, e.Limit, e.Max)
+}
+
+// NewChecked creates an instance of HashRing from nodes like New, or returns
+// a LimitError.
+func NewChecked(nodes []string, opts ...Option) (*HashRing, error) {
+	config := newConfig(opts)
+	nodes, weights := countNodes(nodes, config.weightUnit())
+	if err := checkLimits(nodes, weights, config); err != nil {
+		return nil, err
+	}
+	return newHashRing(nodes, weights, config), nil
+}
+
+// NewWithWeightsChecked creates an instance of HashRing according to weights
+// map like NewWithWeights, or returns a LimitError.
+func NewWithWeightsChecked(weights map[string]int, opts ...Option) (*HashRing, error) {
+	config := newConfig(opts)
+	nodes := nodesOf(weights)
+	if err := checkLimits(nodes, weights, config); err != nil {
+		return nil, err
+	}
+	return newHashRing(nodes, weights, config), nil
+}
+
+// AddNodeChecked adds node like AddNode, or returns a LimitError with h.
+func (h *HashRing) AddNodeChecked(node string) (*HashRing, error) {
+	h = h.orEmpty()
+	return h.addWeightedNode(node, h.config.weightUnit())
+}
+
+// AddWeightedNodeChecked adds node with weight like AddWeightedNode, or
+// returns a LimitError with h.
+func (h *HashRing) AddWeightedNodeChecked(node string, weight int) (*HashRing, error) {
+	return h.orEmpty().addWeightedNode(node, weight)
+}
+
+// UpdateWeightedNodeChecked updates node with weight like
+// UpdateWeightedNode, or returns a LimitError with h.
+func (h *HashRing) UpdateWeightedNodeChecked(node string, weight int) (*HashRing, error) {
+	return h.orEmpty().updateWeightedNode(node, weight)
+}
+
+// ApplyChecked makes the changes of fn at once like Apply, or returns a
+// LimitError with h if they take it over its limits. Only the result counts
+// against the limits.
+func (h *HashRing) ApplyChecked(fn func(tx *RingTx)) (*HashRing, error) {
+	return h.orEmpty().apply(fn)
+}
+
+// limited reports whether c has limits, see LimitError.
+func (c config) limited() bool {
+	return c.maxNodes > 0 || c.maxPoints > 0
+}
+
+// mustBeUnlimited panics if c has limits, which fn cannot report.
+func (c config) mustBeUnlimited(fn string) {
+	if c.limited() {
+		panic("hashring: " + fn + " cannot report a LimitError, use the Checked functions with WithMaxNodes and WithMaxPoints")
+	}
+}
+
+// checkLimits returns a LimitError if a ring of nodes and weights would exceed
+// the limits of c.
+func checkLimits(nodes []string, weights map[string]int, c config) error {
+	if c.maxNodes > 0 {
+		if n := len(sortedNodes(nodes)); n > c.maxNodes {
+			return &LimitError{Limit: "nodes", Max: c.maxNodes, Actual: n}
+		}
+	}
+	if c.maxPoints > 0 {
+		probe := &HashRing{nodes: nodes, weights: make(map[string]int, len(weights)), config: c, replicas: c.baseReplicas()}
+		for _, node := range nodes {
+			probe.weights[node] = c.weightUnit()
+		}
+		for node, weight := range weights {
+			probe.weights[node] = weight
+		}
+		if n := probe.pointsFor(c.baseReplicas()); n > c.maxPoints {
+			return &LimitError{Limit: "points", Max: c.maxPoints, Actual: n}
+		}
+	}
+	return nil
+}
+
+// pointsFor returns the number of points h places with replicas, at most:
+// colliding points and short digests place fewer.
+func (h *HashRing) pointsFor(replicas int) int {
+	perDigest := h.pointsPerDigest()
+	probe := *h
+	probe.replicas = replicas
+	w := probe.weighting()
+	points := 0
+	for _, node := range probe.nodes {
+		points += probe.nodeFactor(node, w) * perDigest
+	}
+	return points
+}
@@ -0,0 +1,141 @@
+package hashring
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMaxNodes(t *testing.T) {
+	_, err := NewChecked([]string{"a", "b", "c"}, WithMaxNodes(2))
+	var limitErr *LimitError
+	assert.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, LimitError{Limit: "nodes", Max: 2, Actual: 3}, *limitErr)
+	assert.EqualError(t, err, "hashring: 3 nodes exceed the limit of 2")
+
+	hashRing, err := NewChecked([]string{"a", "b"}, WithMaxNodes(2))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, hashRing.Size())
+
+	// Updates over the limit return an error with the ring as is.
+	same, err := hashRing.AddNodeChecked("c")
+	assert.ErrorAs(t, err, &limitErr)
+	assert.Same(t, hashRing, same)
+	assert.ErrorAs(t, hashRing.UpdateWithWeightsContext(context.Background(), map[string]int{"a": 1, "b": 1, "c": 1}), &limitErr)
+	assert.Equal(t, 2, hashRing.Size())
+	_, err = hashRing.ApplyWeights(map[string]int{"a": 1, "b": 1, "c": 1}, true)
+	assert.Error(t, err)
+
+	replaced, err := hashRing.RemoveNode("a").AddNodeChecked("c")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, sortedNodes(replaced.nodes))
+	_, err = replaced.AddWeightedNodeChecked("d", 0)
+	assert.ErrorAs(t, err, &limitErr)
+}
+
+func TestWithMaxPoints(t *testing.T) {
+	weights := map[string]int{"a": 1, "b": 1}
+	_, err := NewWithWeightsChecked(weights, WithMaxPoints(2*defaultReplicas*3-1))
+	assert.EqualError(t, err, "hashring: 240 points exceed the limit of 239")
+
+	hashRing, err := NewWithWeightsChecked(weights, WithMaxPoints(2*defaultReplicas*3))
+	assert.NoError(t, err)
+	assert.Len(t, hashRing.sortedKeys, 240)
+	same, err := hashRing.UpdateWeightedNodeChecked("a", 2)
+	var limitErr *LimitError
+	assert.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, "points", limitErr.Limit)
+	assert.Same(t, hashRing, same)
+	lighter, err := hashRing.RemoveNode("b").UpdateWeightedNodeChecked("a", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, lighter.weights["a"])
+
+	_, err = NewWithWeightsChecked(weights, WithMaxPoints(2*defaultReplicas*2), With64BitKeys())
+	assert.NoError(t, err)
+	_, err = NewWithWeightsChecked(weights, WithMaxPoints(2*defaultReplicas*3), WithKetama())
+	assert.Error(t, err)
+
+	// Tuning stops at the limit.
+	tuned, err := NewChecked([]string{"a", "b", "c", "d"}, WithTargetImbalance(0.001), WithMaxPoints(2000))
+	assert.NoError(t, err)
+	assert.True(t, len(tuned.sortedKeys) <= 2000)
+	assert.True(t, tuned.replicas > defaultReplicas)
+}
+
+func TestLimitsNeedChecked(t *testing.T) {
+	for _, opt := range []Option{WithMaxNodes(10), WithMaxPoints(10000)} {
+		assert.Panics(t, func() { New([]string{"a"}, opt) })
+		assert.Panics(t, func() { NewWithWeights(map[string]int{"a": 1}, opt) })
+		assert.Panics(t, func() { NewWithFloatWeights(map[string]float64{"a": 1}, opt) })
+		assert.Panics(t, func() { NewWithSeed([]string{"a"}, 1, opt) })
+		assert.Panics(t, func() { NewRedisConsistentHash([]string{"a"}, nil, opt) })
+		assert.Panics(t, func() { NewHierarchical(map[string]Tier{"a": {}}, opt) })
+		_, err := NewConsistentHasher("ring", map[string]int{"a": 1}, opt)
+		assert.Error(t, err)
+
+		var flagRing RingFlag
+		assert.NoError(t, flagRing.Set("a,b"))
+		fromFlag, err := flagRing.RingChecked(opt)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, fromFlag.Size())
+		_, err = NewRedisConsistentHashChecked([]string{"a"}, nil, opt)
+		assert.NoError(t, err)
+		hierarchical, err := NewHierarchicalChecked(map[string]Tier{"r": {Children: map[string]Tier{"a": {}}}}, opt)
+		assert.NoError(t, err)
+		expectLocated(t, hierarchical, "test", "a")
+
+		// Rings with limits only change by the functions returning errors.
+		hashRing, err := NewChecked([]string{"a", "b"}, opt)
+		assert.NoError(t, err)
+		assert.Panics(t, func() { hashRing.AddNode("c") })
+		assert.Panics(t, func() { hashRing.AddWeightedNode("c", 1) })
+		assert.Panics(t, func() { hashRing.UpdateWeightedNode("a", 2) })
+		assert.Panics(t, func() { hashRing.UpdateWithWeights(map[string]int{"a": 2}) })
+		assert.Panics(t, func() { hashRing.Apply(func(tx *RingTx) { tx.AddNode("c") }) })
+		assert.Panics(t, func() { hashRing.ApplyTopology(Topology{"a": 1}, hashRing.Topology()) })
+		merged, _, err := hashRing.ApplyTopologyChecked(Topology{"a": 1}, hashRing.Topology())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a"}, merged.Nodes())
+		assert.NotPanics(t, func() { hashRing.RemoveNode("a").RenameNode("b", "c") })
+
+		added, err := hashRing.AddNodeChecked("c")
+		assert.NoError(t, err)
+		assert.Equal(t, 3, added.Size())
+		updated, err := added.UpdateWeightedNodeChecked("a", 2)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, updated.weights["a"])
+		applied, err := updated.ApplyChecked(func(tx *RingTx) { tx.RemoveNode("c") })
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, sortedNodes(applied.nodes))
+
+		_, err = applied.ApplyWeights(map[string]int{"a": 1, "b": 2, "c": 1}, true)
+		assert.NoError(t, err)
+		assert.Len(t, applied.Nodes(), 2)
+		_, err = applied.ApplyWeights(map[string]int{"a": 1, "b": 2, "c": 1}, false)
+		assert.NoError(t, err)
+		assert.Len(t, applied.Nodes(), 3)
+		_, err = applied.OptimizeWeights(map[string]float64{"a": 1, "b": 1, "c": 2}, 1)
+		assert.NoError(t, err)
+	}
+}
+
+func TestLimitErrorsOfHelpers(t *testing.T) {
+	var limitErr *LimitError
+	var flagRing RingFlag
+	assert.NoError(t, flagRing.Set("a,b,c"))
+	_, err := flagRing.RingChecked(WithMaxNodes(2))
+	assert.ErrorAs(t, err, &limitErr)
+	_, err = NewRedisConsistentHashChecked([]string{"a", "b", "c"}, nil, WithMaxNodes(2))
+	assert.ErrorAs(t, err, &limitErr)
+	_, err = NewHierarchicalChecked(map[string]Tier{"r": {Children: map[string]Tier{"a": {}, "b": {}, "c": {}}}}, WithMaxNodes(2))
+	assert.ErrorAs(t, err, &limitErr)
+
+	hashRing, err := NewChecked([]string{"a", "b"}, WithMaxNodes(2))
+	assert.NoError(t, err)
+	same, conflicts, err := hashRing.ApplyTopologyChecked(Topology{"a": 1, "b": 1, "c": 1}, hashRing.Topology())
+	assert.ErrorAs(t, err, &limitErr)
+	assert.Same(t, hashRing, same)
+	assert.Empty(t, conflicts)
+}
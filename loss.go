@@ -0,0 +1,138 @@
+package hashring
+
+import (
+	"math"
+	"sort"
+)
+
+// Range is the hashes from Start to End, both included.
+type Range struct {
+	Start, End HashKey64
+}
+
+// Contains reports whether hash is in r.
+func (r Range) Contains(hash HashKey64) bool {
+	return r.Start <= hash && hash <= r.End
+}
+
+// OwnershipLoss is the keyspace a node no longer owns after a change, see
+// WithOnOwnershipLoss.
+type OwnershipLoss struct {
+	Node string
+	// Ranges are the hashes the node lost, sorted and disjoint.
+	Ranges []Range
+
+	ring *HashRing
+}
+
+// Contains reports whether the node lost stringKey, e.g. to drain the
+// connections or invalidate the cache entries of exactly those keys.
+func (l OwnershipLoss) Contains(stringKey string) bool {
+	return inRanges(l.Ranges, l.ring.GenKey64(stringKey))
+}
+
+// WithOnOwnershipLoss calls fn for each node that loses keys when a ring is
+// derived by AddNode, RemoveNode and friends, in order of node. fn runs
+// before the derived ring is returned, so it should return quickly.
+func WithOnOwnershipLoss(fn func(OwnershipLoss)) Option {
+	return func(c *config) {
+		c.onOwnershipLoss = fn
+	}
+}
+
+// reportLosses calls the ownership loss hook of h for the change to next.
+func (h *HashRing) reportLosses(next *HashRing) {
+	fn := h.config.onOwnershipLoss
+	if fn == nil {
+		return
+	}
+	lost := LostRanges(h, next)
+	nodes := make([]string, 0, len(lost))
+	for node := range lost {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	for _, node := range nodes {
+		fn(OwnershipLoss{Node: node, Ranges: lost[node], ring: next})
+	}
+}
+
+// LostRanges returns, for each node of old that owns hashes new gives to
+// another node, or to none if new is empty, those hashes. The ranges of a
+// node are sorted and disjoint.
+func LostRanges(old, new *HashRing) map[string][]Range {
+	old, new = old.orEmpty(), new.orEmpty()
+	lost := make(map[string][]Range)
+	if len(old.sortedKeys) == 0 {
+		return lost
+	}
+
+	// The owner of a hash only changes at a start of either ring.
+	starts := append(old.ownerStarts(), new.ownerStarts()...)
+	sortKeys(starts)
+	unique := starts[:1]
+	for _, start := range starts[1:] {
+		if start != unique[len(unique)-1] {
+			unique = append(unique, start)
+		}
+	}
+
+	max := old.maxHash()
+	for i, start := range unique {
+		end := max
+		if i+1 < len(unique) {
+			end = unique[i+1] - 1
+		}
+
+		owner := old.ownerOf(start)
+		if owner == new.ownerOf(start) {
+			continue
+		}
+		ranges := lost[owner]
+		if n := len(ranges); n > 0 && ranges[n-1].End+1 == start {
+			ranges[n-1].End = end
+		} else {
+			lost[owner] = append(ranges, Range{start, end})
+		}
+	}
+	return lost
+}
+
+// ownerStarts returns the first hash of each run of hashes that belong to one
+// point of h, from 0.
+func (h *HashRing) ownerStarts() []HashKey64 {
+	starts := make([]HashKey64, 0, len(h.sortedKeys)+1)
+	starts = append(starts, 0)
+	for _, key := range h.sortedKeys {
+		if h.config.boundary == BoundaryAtOrAfter {
+			if key != h.maxHash() {
+				starts = append(starts, key+1)
+			}
+		} else {
+			starts = append(starts, key)
+		}
+	}
+	return starts
+}
+
+// maxHash returns the largest hash of h.
+func (h *HashRing) maxHash() HashKey64 {
+	if h.config.wide {
+		return math.MaxUint64
+	}
+	return math.MaxUint32
+}
+
+// inRanges reports whether hash is in one of the sorted, disjoint ranges.
+func inRanges(ranges []Range, hash HashKey64) bool {
+	i := sort.Search(len(ranges), func(i int) bool { return ranges[i].End >= hash })
+	return i < len(ranges) && ranges[i].Start <= hash
+}
+
+// KeyPredicate returns a function reporting whether a key hashes into one of
+// the sorted, disjoint ranges, such as those of LostRanges.
+func (h *HashRing) KeyPredicate(ranges []Range) func(stringKey string) bool {
+	return func(stringKey string) bool {
+		return inRanges(ranges, h.GenKey64(stringKey))
+	}
+}
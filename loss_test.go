@@ -0,0 +1,85 @@
+package hashring
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLostRanges(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithBoundary(BoundaryAtOrAfter)}, {With64BitKeys()}} {
+		old := NewWithWeights(map[string]int{"a": 1, "b": 2, "c": 1}, opts...)
+		for _, new := range []*HashRing{old.AddNode("d"), old.RemoveNode("b"), old.UpdateWeightedNode("a", 3)} {
+			lost := LostRanges(old, new)
+			for _, ranges := range lost {
+				for i, r := range ranges {
+					assert.LessOrEqual(t, r.Start, r.End)
+					if i > 0 {
+						assert.Greater(t, r.Start, ranges[i-1].End+1)
+					}
+				}
+			}
+
+			for i := 0; i < 2000; i++ {
+				key := strconv.Itoa(i)
+				before, _ := old.GetNode(key)
+				after, _ := new.GetNode(key)
+				assert.Equal(t, before != after, new.KeyPredicate(lost[before])(key), key)
+			}
+			// The points themselves follow the boundary.
+			for _, key := range old.sortedKeys {
+				before, after := old.ownerOf(key), new.ownerOf(key)
+				assert.Equal(t, before != after, inRanges(lost[before], key))
+			}
+		}
+	}
+
+	// Removing every node loses the whole keyspace.
+	old := New([]string{"a"})
+	assert.Equal(t, map[string][]Range{"a": {{0, math.MaxUint32}}}, LostRanges(old, old.RemoveNode("a")))
+	assert.Empty(t, LostRanges(nil, old))
+	assert.Empty(t, LostRanges(old, old))
+}
+
+func TestRangeContains(t *testing.T) {
+	r := Range{Start: 10, End: 20}
+	assert.True(t, r.Contains(10))
+	assert.True(t, r.Contains(20))
+	assert.False(t, r.Contains(9))
+	assert.False(t, r.Contains(21))
+	assert.True(t, inRanges([]Range{{1, 2}, {10, 20}}, 15))
+	assert.False(t, inRanges([]Range{{1, 2}, {10, 20}}, 5))
+	assert.False(t, inRanges(nil, 5))
+}
+
+func TestWithOnOwnershipLoss(t *testing.T) {
+	var losses []OwnershipLoss
+	hashRing := New([]string{"a", "b", "c"}, WithOnOwnershipLoss(func(l OwnershipLoss) { losses = append(losses, l) }))
+
+	added := hashRing.AddNode("d")
+	if assert.Len(t, losses, 3) {
+		for i, node := range []string{"a", "b", "c"} {
+			assert.Equal(t, node, losses[i].Node)
+			assert.Equal(t, LostRanges(hashRing, added)[node], losses[i].Ranges)
+		}
+	}
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		before, _ := hashRing.GetNode(key)
+		after, _ := added.GetNode(key)
+		for _, loss := range losses {
+			assert.Equal(t, before == loss.Node && after != before, loss.Contains(key), key)
+		}
+	}
+
+	// A dry run changes nothing, so nothing is lost.
+	losses = nil
+	_, err := added.ApplyWeights(map[string]int{"a": 1}, true)
+	assert.NoError(t, err)
+	assert.Empty(t, losses)
+	_, err = added.ApplyWeights(map[string]int{"a": 1}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "c", "d"}, []string{losses[0].Node, losses[1].Node, losses[2].Node})
+}
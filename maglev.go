@@ -0,0 +1,201 @@
+package hashring
+
+import "sort"
+
+// defaultMaglevSize is the number of entries of a Maglev table, a prime.
+const defaultMaglevSize = 65537
+
+// Maglev maps keys to nodes by Maglev hashing: a lookup table in which each
+// node takes entries in proportion to its weight, in an order of its own.
+// Lookups are O(1) and the nodes' shares of the table are within one entry
+// of their weight shares; a change moves a little more than the keys it
+// must. It has the Get, Add and Remove methods of HashRing and Rendezvous and
+// implements NodeLocator.
+//
+// Keys are hashed as by a HashRing of the same options, e.g. WithHasher; the
+// table has WithMaglevTableSize entries. Changes leave a Maglev and its table
+// as they are, and return a new Maglev with a table populated anew.
+type Maglev struct {
+	weights map[string]int
+	names   []string  // sorted.
+	table   []int32   // node of each entry, as an index into names.
+	hash    *HashRing // hashes keys and nodes, without nodes.
+}
+
+// WithMaglevTableSize sets the number of entries of the table of a Maglev,
+// 65537 by default, rounded up to a prime. It should be well above 100 times
+// the number of nodes, for balance.
+func WithMaglevTableSize(n int) Option {
+	return func(c *config) {
+		c.maglevSize = n
+	}
+}
+
+// NewMaglev creates a Maglev of nodes, each of weight 1.
+func NewMaglev(nodes []string, opts ...Option) *Maglev {
+	weights := make(map[string]int, len(nodes))
+	for _, node := range nodes {
+		weights[node] = 1
+	}
+	return NewMaglevWithWeights(weights, opts...)
+}
+
+// NewMaglevWithWeights creates a Maglev according to weights map. Nodes of
+// weight 0 are standbys, see HashRing.AddWeightedNode.
+func NewMaglevWithWeights(weights map[string]int, opts ...Option) *Maglev {
+	own := make(map[string]int, len(weights))
+	for node, weight := range weights {
+		if weight >= 0 {
+			own[node] = weight
+		}
+	}
+	return (&Maglev{hash: &HashRing{config: newConfig(opts)}}).with(own)
+}
+
+// with returns a Maglev of weights, hashing like m.
+func (m *Maglev) with(weights map[string]int) *Maglev {
+	next := &Maglev{weights: weights, names: nodesOf(weights), hash: m.hash}
+	sort.Strings(next.names)
+	next.populate()
+	return next
+}
+
+// orEmpty returns m, or an empty Maglev if m is nil.
+func (m *Maglev) orEmpty() *Maglev {
+	if m == nil {
+		return NewMaglev(nil)
+	}
+	return m
+}
+
+// tableSize returns the number of entries of the table of m, a prime.
+func (m *Maglev) tableSize() int {
+	n := m.hash.config.maglevSize
+	if n <= 0 {
+		return defaultMaglevSize
+	}
+	for !isPrime(n) {
+		n++
+	}
+	return n
+}
+
+// populate fills the table of m: in turns, each node takes the next free
+// entry of its permutation of the table, offset + i*skip. A node takes a
+// turn in proportion to its weight, as credit of weight per heaviest weight.
+func (m *Maglev) populate() {
+	maxWeight := 0
+	for _, weight := range m.weights {
+		if weight > maxWeight {
+			maxWeight = weight
+		}
+	}
+	if maxWeight == 0 {
+		return
+	}
+
+	size := m.tableSize()
+	offsets := make([]int, len(m.names))
+	skips := make([]int, len(m.names))
+	next := make([]int, len(m.names))
+	credits := make([]int, len(m.names))
+	for i, node := range m.names {
+		offsets[i] = int(uint64(m.hash.GenKey64(node+"\x00offset")) % uint64(size))
+		skips[i] = int(uint64(m.hash.GenKey64(node+"\x00skip"))%uint64(size-1)) + 1
+	}
+
+	m.table = make([]int32, size)
+	for i := range m.table {
+		m.table[i] = -1
+	}
+	for filled := 0; filled < size; {
+		for i, node := range m.names {
+			credits[i] += m.weights[node]
+			if credits[i] < maxWeight {
+				continue
+			}
+			credits[i] -= maxWeight
+
+			entry := (offsets[i] + next[i]*skips[i]) % size
+			for m.table[entry] >= 0 {
+				next[i]++
+				entry = (offsets[i] + next[i]*skips[i]) % size
+			}
+			m.table[entry] = int32(i)
+			next[i]++
+			if filled++; filled == size {
+				break
+			}
+		}
+	}
+}
+
+// isPrime reports whether n is prime.
+func isPrime(n int) bool {
+	if n < 2 {
+		return false
+	}
+	for d := 2; d*d <= n; d++ {
+		if n%d == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Size returns the number of nodes of m, standbys included.
+func (m *Maglev) Size() int {
+	if m == nil {
+		return 0
+	}
+	return len(m.names)
+}
+
+// Nodes returns the nodes of m, sorted.
+func (m *Maglev) Nodes() []string {
+	m = m.orEmpty()
+	return append([]string{}, m.names...)
+}
+
+// AddNode adds node with weight 1, and returns the new Maglev.
+func (m *Maglev) AddNode(node string) *Maglev {
+	return m.AddWeightedNode(node, 1)
+}
+
+// AddWeightedNode adds node with weight, and returns the new Maglev. m is
+// left as is if node is already on it or weight is negative.
+func (m *Maglev) AddWeightedNode(node string, weight int) *Maglev {
+	m = m.orEmpty()
+	if weights, ok := addWeight(m.weights, node, weight); ok {
+		return m.with(weights)
+	}
+	return m
+}
+
+// UpdateWeightedNode updates node with weight, and returns the new Maglev.
+// m is left as is if node is not on it or weight is negative.
+func (m *Maglev) UpdateWeightedNode(node string, weight int) *Maglev {
+	m = m.orEmpty()
+	if weights, ok := updateWeight(m.weights, node, weight); ok {
+		return m.with(weights)
+	}
+	return m
+}
+
+// RemoveNode removes node, and returns the new Maglev.
+func (m *Maglev) RemoveNode(node string) *Maglev {
+	m = m.orEmpty()
+	if weights, ok := removeWeight(m.weights, node); ok {
+		return m.with(weights)
+	}
+	return m
+}
+
+// GetNode returns the node stringKey belongs to. It implements NodeLocator.
+func (m *Maglev) GetNode(stringKey string) (node string, ok bool) {
+	if m == nil || len(m.table) == 0 {
+		return "", false
+	}
+	entry := uint64(m.hash.GenKey64(stringKey)) % uint64(len(m.table))
+	return m.names[m.table[entry]], true
+}
@@ -0,0 +1,79 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaglev(t *testing.T) {
+	m := NewMaglevWithWeights(map[string]int{"a": 1, "b": 1, "c": 2, "d": 0})
+	assert.Equal(t, []string{"a", "b", "c", "d"}, m.Nodes())
+	assert.Len(t, m.table, 65537)
+
+	entries := map[string]int{}
+	for _, i := range m.table {
+		entries[m.names[i]]++
+	}
+	assert.InDelta(t, 65537/4, entries["a"], 1)
+	assert.InDelta(t, 65537/2, entries["c"], 2)
+	assert.Zero(t, entries["d"])
+
+	counts := map[string]int{}
+	for i := 0; i < 4000; i++ {
+		node, ok := m.GetNode(strconv.Itoa(i))
+		assert.True(t, ok)
+		counts[node]++
+	}
+	assert.InDelta(t, 2000, counts["c"], 150)
+}
+
+func TestMaglevChanges(t *testing.T) {
+	m := NewMaglev([]string{"a", "b", "c", "d"}, WithMaglevTableSize(1000))
+	assert.Len(t, m.table, 1009)
+
+	removed := m.RemoveNode("d")
+	moved := 0
+	for i := 0; i < 4000; i++ {
+		key := strconv.Itoa(i)
+		before, _ := m.GetNode(key)
+		after, _ := removed.GetNode(key)
+		assert.NotEqual(t, "d", after)
+		if before != after {
+			moved++
+		}
+	}
+	// A quarter of the keys must move, a little more do.
+	assert.InDelta(t, 1000, moved, 300)
+
+	assert.Equal(t, m.table, removed.AddNode("d").table)
+	assert.Same(t, m, m.AddNode("a"))
+	assert.Same(t, m, m.UpdateWeightedNode("a", 1))
+	assert.Same(t, m, m.RemoveNode("e"))
+	standby := m.UpdateWeightedNode("d", 0)
+	assert.Equal(t, 4, standby.Size())
+	assert.NotContains(t, standby.table, int32(3))
+}
+
+func TestMaglevEmpty(t *testing.T) {
+	var m *Maglev
+	_, ok := m.GetNode("test")
+	assert.False(t, ok)
+	_, ok = NewMaglevWithWeights(map[string]int{"a": 0}).GetNode("test")
+	assert.False(t, ok)
+	node, _ := m.AddNode("a").GetNode("test")
+	assert.Equal(t, "a", node)
+}
+
+func BenchmarkMaglev(b *testing.B) {
+	nodes := make([]string, 300)
+	for i := range nodes {
+		nodes[i] = "node-" + strconv.Itoa(i)
+	}
+	m := NewMaglev(nodes)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.GetNode("test")
+	}
+}
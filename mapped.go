@@ -0,0 +1,272 @@
+package hashring
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// The mapped layout, all little-endian:
+//
+//	magic    [8]byte  "hashring"
+//	version  uint32   1
+//	flags    uint32   mappedWide, mappedAtOrAfter
+//	hasher   uint32   mappedMD5, mappedXXHash64, mappedCRC32 or mappedMurmur3
+//	_        uint32
+//	points   uint64   number of points P
+//	nodes    uint64   number of nodes N
+//	keys     [P]uint64 sorted points
+//	owners   [P]uint32 index of each point's node
+//	N times: weight uint32, length uint32, name [length]byte
+const (
+	mappedMagic      = "hashring"
+	mappedVersion    = 1
+	mappedHeaderSize = 40
+
+	mappedWide      = 1 << 0
+	mappedAtOrAfter = 1 << 1
+
+	mappedMD5      = 0
+	mappedXXHash64 = 1
+	mappedCRC32    = 2
+	mappedMurmur3  = 3
+)
+
+// WriteTo writes h in a flat binary layout that OpenMapped maps into memory,
+// so processes on a host can share one read-only copy of a large ring.
+//
+// Only rings hashing with md5, XXHash64, Murmur3, or crc32 as with
+// WithNginxConsistent can be written, other Hashers have no portable
+// representation.
+func (h *HashRing) WriteTo(w io.Writer) (n int64, err error) {
+	h = h.orEmpty()
+	var hasher uint32
+	switch h.config.hasher.(type) {
+	case nil:
+		hasher = mappedMD5
+	case xxHash64:
+		hasher = mappedXXHash64
+	case crc32Hasher:
+		hasher = mappedCRC32
+	case murmur3:
+		hasher = mappedMurmur3
+	default:
+		return 0, errors.New("hashring: cannot write a ring with a custom Hasher")
+	}
+	var flags uint32
+	if h.config.wide {
+		flags |= mappedWide
+	}
+	if h.config.boundary == BoundaryAtOrAfter {
+		flags |= mappedAtOrAfter
+	}
+
+	nodes := sortedNodes(h.nodes)
+	index := make(map[string]uint32, len(nodes))
+	for i, node := range nodes {
+		index[node] = uint32(i)
+	}
+
+	buf := make([]byte, mappedHeaderSize, mappedHeaderSize+12*len(h.sortedKeys))
+	copy(buf, mappedMagic)
+	binary.LittleEndian.PutUint32(buf[8:], mappedVersion)
+	binary.LittleEndian.PutUint32(buf[12:], flags)
+	binary.LittleEndian.PutUint32(buf[16:], hasher)
+	binary.LittleEndian.PutUint64(buf[24:], uint64(len(h.sortedKeys)))
+	binary.LittleEndian.PutUint64(buf[32:], uint64(len(nodes)))
+	for _, key := range h.sortedKeys {
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(key))
+	}
+	for pos := range h.sortedKeys {
+		buf = binary.LittleEndian.AppendUint32(buf, index[h.ownerAt(pos)])
+	}
+	for _, node := range nodes {
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(h.weights[node]))
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(node)))
+		buf = append(buf, node...)
+	}
+
+	written, err := w.Write(buf)
+	return int64(written), err
+}
+
+// MappedRing is a read-only ring mapped from a file written by
+// HashRing.WriteTo. Its points stay in the file's pages, shared by every
+// process mapping the file, instead of being copied into the heap.
+//
+// It routes keys exactly like the ring it was written from.
+type MappedRing struct {
+	data    []byte
+	close   func() error
+	keys    []byte // points, 8 bytes each.
+	owners  []byte // node indices, 4 bytes each.
+	points  int
+	nodes   []string
+	weights []int
+	hashing *HashRing // empty ring hashing keys like the written one.
+}
+
+// OpenMapped maps the ring written to path by HashRing.WriteTo. The ring must
+// be closed with Close after use.
+func OpenMapped(path string) (*MappedRing, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, unmap, err := mmapFile(f)
+	if err != nil {
+		return nil, err
+	}
+	m, err := parseMapped(data)
+	if err != nil {
+		unmap()
+		return nil, fmt.Errorf("hashring: %s: %v", path, err)
+	}
+	m.close = unmap
+	return m, nil
+}
+
+func parseMapped(data []byte) (*MappedRing, error) {
+	if len(data) < mappedHeaderSize || string(data[:8]) != mappedMagic {
+		return nil, errors.New("not a mapped ring")
+	}
+	if version := binary.LittleEndian.Uint32(data[8:]); version != mappedVersion {
+		return nil, fmt.Errorf("unsupported mapped ring version %d", version)
+	}
+	flags := binary.LittleEndian.Uint32(data[12:])
+	var c config
+	c.wide = flags&mappedWide != 0
+	if flags&mappedAtOrAfter != 0 {
+		c.boundary = BoundaryAtOrAfter
+	}
+	switch hasher := binary.LittleEndian.Uint32(data[16:]); hasher {
+	case mappedMD5:
+	case mappedXXHash64:
+		c.hasher = XXHash64
+	case mappedCRC32:
+		c.hasher = crc32Hasher{}
+	case mappedMurmur3:
+		c.hasher = Murmur3
+	default:
+		return nil, fmt.Errorf("unknown hasher %d", hasher)
+	}
+
+	points := binary.LittleEndian.Uint64(data[24:])
+	nodes := binary.LittleEndian.Uint64(data[32:])
+	rest := uint64(len(data) - mappedHeaderSize)
+	if points > rest/12 || nodes > rest/8 {
+		return nil, errors.New("truncated mapped ring")
+	}
+	m := &MappedRing{
+		data:    data,
+		points:  int(points),
+		keys:    data[mappedHeaderSize : mappedHeaderSize+8*points],
+		owners:  data[mappedHeaderSize+8*points : mappedHeaderSize+12*points],
+		hashing: &HashRing{config: c},
+	}
+
+	table := data[mappedHeaderSize+12*points:]
+	for i := uint64(0); i < nodes; i++ {
+		if len(table) < 8 {
+			return nil, errors.New("truncated mapped ring")
+		}
+		weight := binary.LittleEndian.Uint32(table)
+		length := binary.LittleEndian.Uint32(table[4:])
+		if uint64(len(table)-8) < uint64(length) {
+			return nil, errors.New("truncated mapped ring")
+		}
+		m.weights = append(m.weights, int(weight))
+		m.nodes = append(m.nodes, string(table[8:8+length]))
+		table = table[8+length:]
+	}
+
+	for i := 0; i < m.points; i++ {
+		if int(m.owner(i)) >= len(m.nodes) {
+			return nil, fmt.Errorf("point %d has unknown node %d", i, m.owner(i))
+		}
+		if i > 0 && m.key(i) <= m.key(i-1) {
+			return nil, errors.New("points are not sorted")
+		}
+	}
+	return m, nil
+}
+
+func (m *MappedRing) key(i int) HashKey64 {
+	return HashKey64(binary.LittleEndian.Uint64(m.keys[8*i:]))
+}
+
+func (m *MappedRing) owner(i int) uint32 {
+	return binary.LittleEndian.Uint32(m.owners[4*i:])
+}
+
+// Close unmaps the ring. It must not be used afterwards.
+func (m *MappedRing) Close() error {
+	if m.close == nil {
+		return nil
+	}
+	err := m.close()
+	m.close, m.data, m.keys, m.owners, m.points = nil, nil, nil, nil, 0
+	return err
+}
+
+// Size returns the number of nodes of the ring.
+func (m *MappedRing) Size() int {
+	return len(m.nodes)
+}
+
+// Weights returns the nodes of the ring and their weights.
+func (m *MappedRing) Weights() map[string]int {
+	weights := make(map[string]int, len(m.nodes))
+	for i, node := range m.nodes {
+		weights[node] = m.weights[i]
+	}
+	return weights
+}
+
+// posOf returns the point that key belongs to, like HashRing.keyPos.
+func (m *MappedRing) posOf(key HashKey64) (pos int, ok bool) {
+	if m.points == 0 {
+		return 0, false
+	}
+	if m.hashing.config.boundary == BoundaryAtOrAfter {
+		pos = sort.Search(m.points, func(i int) bool { return m.key(i) >= key })
+	} else {
+		pos = sort.Search(m.points, func(i int) bool { return m.key(i) > key })
+	}
+	return pos % m.points, true
+}
+
+// GetNode returns the node that stringKey belongs to.
+func (m *MappedRing) GetNode(stringKey string) (node string, ok bool) {
+	pos, ok := m.posOf(m.hashing.GenKey64(stringKey))
+	if !ok {
+		return "", false
+	}
+	return m.nodes[m.owner(pos)], true
+}
+
+// GetNodes returns size nodes for stringKey, see HashRing.GetNodes.
+func (m *MappedRing) GetNodes(stringKey string, size int) (nodes []string, ok bool) {
+	if size > m.Size() || size <= 0 {
+		return nil, false
+	}
+	pos, ok := m.posOf(m.hashing.GenKey64(stringKey))
+	if !ok {
+		return nil, false
+	}
+
+	seen := make(map[uint32]bool, size)
+	for i := pos; i < pos+m.points && len(nodes) < size; i++ {
+		owner := m.owner(i % m.points)
+		if !seen[owner] {
+			seen[owner] = true
+			nodes = append(nodes, m.nodes[owner])
+		}
+	}
+	return nodes, len(nodes) == size
+}
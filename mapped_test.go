@@ -0,0 +1,90 @@
+package hashring
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeMapped(t *testing.T, hashRing *HashRing) string {
+	var buf bytes.Buffer
+	n, err := hashRing.WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+
+	path := filepath.Join(t.TempDir(), "ring")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestOpenMapped(t *testing.T) {
+	weights := map[string]int{"a": 1, "b": 2, "c": 3, "d": 1}
+	for _, opts := range [][]Option{nil, {WithHasher(XXHash64)}, {WithHasher(Murmur3)}, {With64BitKeys()}, {WithBoundary(BoundaryAtOrAfter)}} {
+		hashRing := NewWithWeights(weights, opts...)
+		mapped, err := OpenMapped(writeMapped(t, hashRing))
+		assert.NoError(t, err)
+
+		assert.Equal(t, 4, mapped.Size())
+		assert.Equal(t, weights, mapped.Weights())
+		for i := 0; i < 1000; i++ {
+			key := strconv.Itoa(i)
+			expected, _ := hashRing.GetNode(key)
+			node, ok := mapped.GetNode(key)
+			assert.True(t, ok)
+			assert.Equal(t, expected, node, key)
+
+			expectedNodes, _ := hashRing.GetNodes(key, 3)
+			nodes, ok := mapped.GetNodes(key, 3)
+			assert.True(t, ok)
+			assert.Equal(t, expectedNodes, nodes, key)
+		}
+		// Keys on a point follow the ring's boundary.
+		point := hashRing.sortedKeys[5]
+		pos, _ := mapped.posOf(point)
+		assert.Equal(t, hashRing.ownerOf(point), mapped.nodes[mapped.owner(pos)])
+
+		_, ok := mapped.GetNodes("test", 5)
+		assert.False(t, ok)
+		assert.NoError(t, mapped.Close())
+		assert.NoError(t, mapped.Close())
+	}
+}
+
+func TestOpenMappedEmpty(t *testing.T) {
+	mapped, err := OpenMapped(writeMapped(t, nil))
+	assert.NoError(t, err)
+	defer mapped.Close()
+	_, ok := mapped.GetNode("test")
+	assert.False(t, ok)
+	assert.Equal(t, 0, mapped.Size())
+}
+
+func TestOpenMappedErrors(t *testing.T) {
+	_, err := New([]string{"a"}, WithHasher(sha256Hasher)).WriteTo(&bytes.Buffer{})
+	assert.Error(t, err)
+
+	dir := t.TempDir()
+	_, err = OpenMapped(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+
+	var buf bytes.Buffer
+	New([]string{"a", "b"}).WriteTo(&buf)
+	valid := buf.Bytes()
+	for name, data := range map[string][]byte{
+		"empty":     nil,
+		"magic":     append([]byte("xxxxxxxx"), valid[8:]...),
+		"truncated": valid[:len(valid)-1],
+		"header":    valid[:mappedHeaderSize-1],
+	} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, data, 0644)
+		_, err := OpenMapped(path)
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,58 @@
+package hashring
+
+import "time"
+
+// MetricsSink receives the metrics of a ring, see StatsdSink.
+// Implementations must be safe for concurrent use.
+type MetricsSink interface {
+	// Lookup is called with the node selected by a lookup, the first one for
+	// lookups returning several nodes.
+	Lookup(node string)
+	// Rebuild is called after the circle was generated, with its duration and
+	// the number of points on it.
+	Rebuild(d time.Duration, points int)
+}
+
+// RingMetricsSink is a MetricsSink that can tell rings apart. For a ring
+// with a name or labels, see WithName, WithMetrics reports to the sink
+// returned by ForRing instead.
+type RingMetricsSink interface {
+	MetricsSink
+	ForRing(name string, labels Labels) MetricsSink
+}
+
+// MigrationMetricsSink is a MetricsSink that also receives the progress of
+// migrations to its ring, see Migrator.
+type MigrationMetricsSink interface {
+	MetricsSink
+	// Migration is called as a Migrator advances its checkpoint, with the
+	// keys scanned and moved since the previous call.
+	Migration(scanned, moved int)
+}
+
+// WithMetrics reports lookups and rebuilds of the ring to sink.
+func WithMetrics(sink MetricsSink) Option {
+	return func(c *config) {
+		c.metrics = sink
+	}
+}
+
+// lookupAt returns the node at pos, reporting the lookup.
+func (h *HashRing) lookupAt(pos int) string {
+	node := h.ownerAt(pos)
+	if h.config.metrics != nil {
+		h.config.metrics.Lookup(node)
+	}
+	return node
+}
+
+// observeRebuild reports a rebuild started at start.
+func (h *HashRing) observeRebuild(start time.Time) {
+	d := time.Since(start)
+	if h.config.metrics != nil {
+		h.config.metrics.Rebuild(d, len(h.sortedKeys))
+	}
+	if h.intercepted() {
+		h.interceptRebuild(d)
+	}
+}
@@ -0,0 +1,57 @@
+package hashring
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSink struct {
+	mu       sync.Mutex
+	lookups  map[string]int
+	rebuilds []int
+}
+
+func (s *fakeSink) Lookup(node string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lookups == nil {
+		s.lookups = make(map[string]int)
+	}
+	s.lookups[node]++
+}
+
+func (s *fakeSink) Rebuild(d time.Duration, points int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rebuilds = append(s.rebuilds, points)
+}
+
+func TestWithMetrics(t *testing.T) {
+	sink := &fakeSink{}
+	hashRing := New([]string{"a", "b", "c"}, WithMetrics(sink))
+	assert.Equal(t, []int{360}, sink.rebuilds)
+
+	expectNodesABC(t, hashRing)
+	assert.Equal(t, map[string]int{"a": 4, "b": 3, "c": 2}, sink.lookups)
+
+	hashRing.GetNodes("test1", 2)
+	hashRing.GetNodeUint64(1)
+	assert.Equal(t, 11, sink.lookups["a"]+sink.lookups["b"]+sink.lookups["c"])
+
+	hashRing = hashRing.AddNode("d").UpdateWeightedNode("d", 2)
+	assert.Equal(t, []int{360, 480, 480}, sink.rebuilds)
+}
+
+func TestWithMetricsShare(t *testing.T) {
+	sink := &fakeSink{}
+	hashRing := NewWithWeights(map[string]int{"a": 1, "b": 3}, WithMetrics(sink))
+	for i := 0; i < 4000; i++ {
+		hashRing.GetNode(strconv.Itoa(i))
+	}
+	assert.InDelta(t, 1000, sink.lookups["a"], 200)
+	assert.InDelta(t, 3000, sink.lookups["b"], 200)
+}
@@ -0,0 +1,372 @@
+package hashring
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MoveFunc moves key from node from to node to.
+type MoveFunc func(ctx context.Context, key string, from, to string) error
+
+// Checkpoint records the progress of a Migrator.
+type Checkpoint struct {
+	// Scanned is the number of keys, counted from the start of the stream,
+	// that are fully handled: either moved or not affected by the change.
+	Scanned int
+	// Moved is the number of keys moved within Scanned.
+	Moved int
+}
+
+// Migrator drives a reshard from one ring to another: for every key of a
+// stream whose owner differs between From and To, it calls Move.
+//
+// Progress is reported as a Checkpoint. Feeding the same stream again with the
+// last checkpoint resumes where the previous run stopped. Keys after the
+// checkpoint that were already moved by concurrent workers are moved again,
+// so Move should be idempotent.
+//
+// If the MetricsSink of To, see WithMetrics, is a MigrationMetricsSink, the
+// keys scanned and moved are reported to it as the checkpoint advances.
+type Migrator struct {
+	From, To *HashRing
+	Move     MoveFunc
+
+	// Concurrency is the number of moves running at once, 1 if zero.
+	Concurrency int
+	// Rate limits moves per second, unlimited if zero.
+	Rate float64
+	// CheckpointEvery calls OnCheckpoint each time at least that many more
+	// keys are scanned, and once at the end of Run. Checkpoints are delivered
+	// in order, one at a time, skipping any that a later one overtook.
+	// OnCheckpoint may call Progress.
+	CheckpointEvery int
+	OnCheckpoint    func(Checkpoint)
+
+	// Backpressure is consulted before each move with its target node and the
+	// stats so far. It returns how long to hold off before asking again, 0 to
+	// go ahead. LoadBackpressure and ErrorRateBackpressure are common policies.
+	Backpressure func(to string, stats MigrationStats) time.Duration
+	// Retries is the number of times a failed move is retried, after
+	// consulting Backpressure, before Run gives up.
+	Retries int
+	// Total is the number of keys in the stream if known, for Progress.
+	Total int
+	// Clock paces Rate and Backpressure, SystemClock if nil.
+	Clock Clock
+
+	mu       sync.Mutex
+	progress *migrationProgress
+}
+
+// MigrationStats is the progress of a running or finished Migrator.
+type MigrationStats struct {
+	Checkpoint
+	// Remaining is Total minus Scanned, or -1 if Total is unknown.
+	Remaining int
+	// Attempts and Failures count move attempts, including retries.
+	Attempts int
+	Failures int
+}
+
+// Progress returns the stats of the current or last Run.
+func (m *Migrator) Progress() MigrationStats {
+	m.mu.Lock()
+	p := m.progress
+	m.mu.Unlock()
+	if p == nil {
+		return MigrationStats{Remaining: m.remaining(0)}
+	}
+	return p.stats()
+}
+
+func (m *Migrator) remaining(scanned int) int {
+	if m.Total <= 0 {
+		return -1
+	}
+	return m.Total - scanned
+}
+
+// LoadBackpressure holds moves to nodes whose load, as reported by load, is
+// above max, checking again after pause.
+func LoadBackpressure(load func(node string) float64, max float64, pause time.Duration) func(string, MigrationStats) time.Duration {
+	return func(to string, stats MigrationStats) time.Duration {
+		if load(to) > max {
+			return pause
+		}
+		return 0
+	}
+}
+
+// ErrorRateBackpressure holds moves for pause while more than maxRate of the
+// move attempts failed, once at least minAttempts were made.
+func ErrorRateBackpressure(maxRate float64, minAttempts int, pause time.Duration) func(string, MigrationStats) time.Duration {
+	return func(to string, stats MigrationStats) time.Duration {
+		if stats.Attempts >= minAttempts && float64(stats.Failures) > maxRate*float64(stats.Attempts) {
+			return pause
+		}
+		return 0
+	}
+}
+
+// Run migrates the keys read from keys until the channel is closed, ctx is
+// done, or a move fails after its retries. Keys before resume.Scanned are
+// skipped. A Migrator runs one Run at a time.
+//
+// It returns the checkpoint reached and the first error. Moves in flight when
+// an error occurs are waited for, but not started anew.
+func (m *Migrator) Run(ctx context.Context, keys <-chan string, resume Checkpoint) (Checkpoint, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	concurrency := m.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	p := &migrationProgress{
+		m:        m,
+		scanned:  resume.Scanned,
+		moved:    resume.Moved,
+		finished: make(map[int]bool),
+	}
+	if m.To != nil {
+		p.metrics, _ = m.To.config.metrics.(MigrationMetricsSink)
+	}
+	m.mu.Lock()
+	m.progress = p
+	m.mu.Unlock()
+	limiter := newRateLimiter(m.Rate, m.clock())
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	index := 0
+loop:
+	for {
+		var key string
+		var ok bool
+		select {
+		case <-ctx.Done():
+			break loop
+		case key, ok = <-keys:
+			if !ok {
+				break loop
+			}
+		}
+
+		i := index
+		index++
+		if i < resume.Scanned {
+			continue
+		}
+
+		from, _ := m.From.GetNode(key)
+		to, _ := m.To.GetNode(key)
+		if from == to {
+			p.finish(i, false)
+			continue
+		}
+
+		if err := limiter.wait(ctx); err != nil {
+			break loop
+		}
+		if err := m.holdOff(ctx, to, p); err != nil {
+			break loop
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break loop
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			for attempt := 0; ; attempt++ {
+				err := m.Move(ctx, key, from, to)
+				p.attempt(err)
+				if err == nil {
+					break
+				}
+				if attempt >= m.Retries || m.holdOff(ctx, to, p) != nil {
+					p.fail(err)
+					cancel()
+					return
+				}
+			}
+			p.finish(i, true)
+		}()
+	}
+	wg.Wait()
+
+	checkpoint, err := p.result()
+	if err == nil {
+		err = ctx.Err()
+	}
+	if m.OnCheckpoint != nil {
+		m.OnCheckpoint(checkpoint)
+	}
+	return checkpoint, err
+}
+
+// holdOff waits as long as Backpressure asks for moves to node to.
+func (m *Migrator) holdOff(ctx context.Context, to string, p *migrationProgress) error {
+	if m.Backpressure == nil {
+		return nil
+	}
+	for {
+		delay := m.Backpressure(to, p.stats())
+		if delay <= 0 {
+			return nil
+		}
+
+		select {
+		case <-m.clock().After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// clock returns the Clock of m.
+func (m *Migrator) clock() Clock {
+	if m.Clock != nil {
+		return m.Clock
+	}
+	return SystemClock
+}
+
+// migrationProgress tracks the contiguous prefix of finished keys.
+type migrationProgress struct {
+	m       *Migrator
+	metrics MigrationMetricsSink // nil if To's sink takes no migration metrics.
+
+	mu       sync.Mutex
+	scanned  int
+	moved    int
+	finished map[int]bool // finished keys after scanned, with whether they moved.
+	reported int
+	attempts int
+	failures int
+	err      error
+
+	// deliver orders the calls of OnCheckpoint, made without holding mu so
+	// they may read the progress.
+	deliver   sync.Mutex
+	delivered int
+}
+
+func (p *migrationProgress) finish(i int, moved bool) {
+	p.mu.Lock()
+	p.finished[i] = moved
+	before := Checkpoint{Scanned: p.scanned, Moved: p.moved}
+	for {
+		moved, ok := p.finished[p.scanned]
+		if !ok {
+			break
+		}
+		delete(p.finished, p.scanned)
+		p.scanned++
+		if moved {
+			p.moved++
+		}
+	}
+
+	report := false
+	checkpoint := Checkpoint{Scanned: p.scanned, Moved: p.moved}
+	if every := p.m.CheckpointEvery; every > 0 && p.m.OnCheckpoint != nil && p.scanned-p.reported >= every {
+		p.reported = p.scanned
+		report = true
+	}
+	p.mu.Unlock()
+
+	if p.metrics != nil && checkpoint.Scanned > before.Scanned {
+		p.metrics.Migration(checkpoint.Scanned-before.Scanned, checkpoint.Moved-before.Moved)
+	}
+	if report {
+		p.checkpoint(checkpoint)
+	}
+}
+
+// checkpoint calls OnCheckpoint with checkpoint, unless a later one was
+// delivered meanwhile, so checkpoints are delivered in order.
+func (p *migrationProgress) checkpoint(checkpoint Checkpoint) {
+	p.deliver.Lock()
+	defer p.deliver.Unlock()
+	if checkpoint.Scanned <= p.delivered {
+		return
+	}
+	p.delivered = checkpoint.Scanned
+	p.m.OnCheckpoint(checkpoint)
+}
+
+func (p *migrationProgress) attempt(err error) {
+	p.mu.Lock()
+	p.attempts++
+	if err != nil {
+		p.failures++
+	}
+	p.mu.Unlock()
+}
+
+func (p *migrationProgress) stats() MigrationStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return MigrationStats{
+		Checkpoint: Checkpoint{Scanned: p.scanned, Moved: p.moved},
+		Remaining:  p.m.remaining(p.scanned),
+		Attempts:   p.attempts,
+		Failures:   p.failures,
+	}
+}
+
+func (p *migrationProgress) fail(err error) {
+	p.mu.Lock()
+	if p.err == nil {
+		p.err = err
+	}
+	p.mu.Unlock()
+}
+
+func (p *migrationProgress) result() (Checkpoint, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return Checkpoint{Scanned: p.scanned, Moved: p.moved}, p.err
+}
+
+// rateLimiter spaces events evenly at a rate per second.
+type rateLimiter struct {
+	interval time.Duration
+	next     time.Time
+	clock    Clock
+}
+
+func newRateLimiter(rate float64, clock Clock) *rateLimiter {
+	if rate <= 0 {
+		return &rateLimiter{clock: clock}
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / rate), clock: clock}
+}
+
+func (r *rateLimiter) wait(ctx context.Context) error {
+	if r.interval == 0 {
+		return nil
+	}
+	now := r.clock.Now()
+	if r.next.Before(now) {
+		r.next = now
+	}
+	delay := r.next.Sub(now)
+	r.next = r.next.Add(r.interval)
+	if delay <= 0 {
+		return nil
+	}
+
+	select {
+	case <-r.clock.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,287 @@
+package hashring
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func keyStream(n int) <-chan string {
+	keys := make(chan string)
+	go func() {
+		defer close(keys)
+		for i := 0; i < n; i++ {
+			keys <- strconv.Itoa(i)
+		}
+	}()
+	return keys
+}
+
+type moveRecorder struct {
+	mu    sync.Mutex
+	moves map[string][2]string
+}
+
+func (r *moveRecorder) move(ctx context.Context, key string, from, to string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.moves == nil {
+		r.moves = make(map[string][2]string)
+	}
+	r.moves[key] = [2]string{from, to}
+	return nil
+}
+
+func TestMigrator(t *testing.T) {
+	from := New([]string{"a", "b", "c"})
+	to := from.AddNode("d")
+
+	var recorder moveRecorder
+	m := &Migrator{From: from, To: to, Move: recorder.move, Concurrency: 4}
+	checkpoint, err := m.Run(context.Background(), keyStream(1000), Checkpoint{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1000, checkpoint.Scanned)
+	assert.Equal(t, len(recorder.moves), checkpoint.Moved)
+
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		oldNode, _ := from.GetNode(key)
+		newNode, _ := to.GetNode(key)
+		move, ok := recorder.moves[key]
+		if oldNode == newNode {
+			assert.False(t, ok, key)
+		} else {
+			assert.Equal(t, [2]string{oldNode, newNode}, move, key)
+		}
+	}
+}
+
+func TestMigratorResume(t *testing.T) {
+	from := New([]string{"a", "b", "c"})
+	to := from.AddNode("d")
+
+	var moves []string
+	failAt := 10
+	m := &Migrator{From: from, To: to, Move: func(ctx context.Context, key string, from, to string) error {
+		if len(moves) == failAt {
+			return errors.New("node down")
+		}
+		moves = append(moves, key)
+		return nil
+	}}
+
+	checkpoint, err := m.Run(context.Background(), keyStream(1000), Checkpoint{})
+	assert.EqualError(t, err, "node down")
+	assert.Equal(t, 10, checkpoint.Moved)
+	assert.True(t, checkpoint.Scanned < 1000)
+
+	failAt = -1
+	checkpoint, err = m.Run(context.Background(), keyStream(1000), checkpoint)
+	assert.NoError(t, err)
+	assert.Equal(t, 1000, checkpoint.Scanned)
+	assert.Equal(t, len(moves), checkpoint.Moved)
+
+	seen := make(map[string]bool)
+	for _, key := range moves {
+		assert.False(t, seen[key], "%s moved twice", key)
+		seen[key] = true
+	}
+}
+
+func TestMigratorCheckpoints(t *testing.T) {
+	from := New([]string{"a", "b", "c"})
+	to := from.RemoveNode("b")
+
+	var recorder moveRecorder
+	var checkpoints []Checkpoint
+	m := &Migrator{
+		From: from, To: to, Move: recorder.move,
+		CheckpointEvery: 100,
+		OnCheckpoint:    func(c Checkpoint) { checkpoints = append(checkpoints, c) },
+	}
+	_, err := m.Run(context.Background(), keyStream(1000), Checkpoint{})
+	assert.NoError(t, err)
+	assert.True(t, len(checkpoints) >= 2)
+	for i := 1; i < len(checkpoints)-1; i++ {
+		assert.True(t, checkpoints[i].Scanned-checkpoints[i-1].Scanned >= 100)
+	}
+	assert.Equal(t, Checkpoint{Scanned: 1000, Moved: len(recorder.moves)}, checkpoints[len(checkpoints)-1])
+}
+
+func TestMigratorCheckpointsReadProgress(t *testing.T) {
+	from := New([]string{"a", "b", "c"})
+	to := from.AddNode("d")
+
+	var recorder moveRecorder
+	var checkpoints []Checkpoint
+	var m *Migrator
+	m = &Migrator{
+		From: from, To: to, Move: recorder.move, Concurrency: 8,
+		CheckpointEvery: 10,
+		OnCheckpoint: func(c Checkpoint) {
+			// Checkpoints run one at a time, and may read the progress.
+			checkpoints = append(checkpoints, c)
+			assert.GreaterOrEqual(t, m.Progress().Scanned, c.Scanned)
+		},
+	}
+	done := make(chan error)
+	go func() {
+		_, err := m.Run(context.Background(), keyStream(2000), Checkpoint{})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("OnCheckpoint calling Progress deadlocked")
+	}
+	for i := 1; i < len(checkpoints); i++ {
+		assert.GreaterOrEqual(t, checkpoints[i].Scanned, checkpoints[i-1].Scanned)
+	}
+	assert.Equal(t, 2000, checkpoints[len(checkpoints)-1].Scanned)
+}
+
+type migrationSink struct {
+	fakeSink
+	scanned, moved atomic.Int64
+}
+
+func (s *migrationSink) Migration(scanned, moved int) {
+	s.scanned.Add(int64(scanned))
+	s.moved.Add(int64(moved))
+}
+
+func TestMigratorMetrics(t *testing.T) {
+	sink := &migrationSink{}
+	from := New([]string{"a", "b", "c"})
+	to := New([]string{"a", "b", "c", "d"}, WithMetrics(sink))
+
+	var recorder moveRecorder
+	m := &Migrator{From: from, To: to, Move: recorder.move, Concurrency: 4}
+	checkpoint, err := m.Run(context.Background(), keyStream(1000), Checkpoint{Scanned: 100})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 900, sink.scanned.Load(), "keys before the resumed checkpoint are not reported")
+	assert.EqualValues(t, len(recorder.moves), sink.moved.Load())
+	assert.Equal(t, checkpoint.Moved, len(recorder.moves))
+
+	// A sink taking no migration metrics is left alone.
+	m.To = New([]string{"a", "b", "c", "d"}, WithMetrics(&fakeSink{}))
+	_, err = m.Run(context.Background(), keyStream(1000), Checkpoint{})
+	assert.NoError(t, err)
+}
+
+func TestMigratorRate(t *testing.T) {
+	from := New([]string{"a"})
+	to := New([]string{"b"})
+
+	var recorder moveRecorder
+	m := &Migrator{From: from, To: to, Move: recorder.move, Rate: 1000}
+	start := time.Now()
+	checkpoint, err := m.Run(context.Background(), keyStream(50), Checkpoint{})
+	assert.NoError(t, err)
+	assert.Equal(t, 50, checkpoint.Moved)
+	assert.True(t, time.Since(start) >= 45*time.Millisecond)
+}
+
+func TestMigratorCancel(t *testing.T) {
+	from := New([]string{"a"})
+	to := New([]string{"b"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Migrator{From: from, To: to, Move: func(ctx context.Context, key string, from, to string) error {
+		cancel()
+		return nil
+	}}
+	checkpoint, err := m.Run(ctx, keyStream(100), Checkpoint{})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 1, checkpoint.Scanned)
+}
+
+func TestMigratorProgress(t *testing.T) {
+	from := New([]string{"a", "b", "c"})
+	to := from.RemoveNode("c")
+
+	var recorder moveRecorder
+	m := &Migrator{From: from, To: to, Move: recorder.move, Total: 1000}
+	assert.Equal(t, MigrationStats{Remaining: 1000}, m.Progress())
+
+	_, err := m.Run(context.Background(), keyStream(400), Checkpoint{})
+	assert.NoError(t, err)
+	stats := m.Progress()
+	assert.Equal(t, 400, stats.Scanned)
+	assert.Equal(t, 600, stats.Remaining)
+	assert.Equal(t, len(recorder.moves), stats.Moved)
+	assert.Equal(t, stats.Moved, stats.Attempts)
+	assert.Equal(t, 0, stats.Failures)
+}
+
+func TestMigratorLoadBackpressure(t *testing.T) {
+	from := New([]string{"a"})
+	to := New([]string{"b"})
+
+	var mu sync.Mutex
+	load := 1.0
+	checks := 0
+	loadOf := func(node string) float64 {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, "b", node)
+		checks++
+		if checks == 3 {
+			load = 0.1
+		}
+		return load
+	}
+
+	var recorder moveRecorder
+	m := &Migrator{From: from, To: to, Move: recorder.move,
+		Backpressure: LoadBackpressure(loadOf, 0.8, time.Millisecond)}
+	checkpoint, err := m.Run(context.Background(), keyStream(10), Checkpoint{})
+	assert.NoError(t, err)
+	assert.Equal(t, 10, checkpoint.Moved)
+	// Held off twice while b was loaded, then once per move.
+	assert.Equal(t, 12, checks)
+}
+
+func TestMigratorRetries(t *testing.T) {
+	from := New([]string{"a"})
+	to := New([]string{"b"})
+
+	attempts := 0
+	move := func(ctx context.Context, key string, from, to string) error {
+		attempts++
+		if attempts%3 != 0 {
+			return errors.New("busy")
+		}
+		return nil
+	}
+	var holds int32
+	errorRate := ErrorRateBackpressure(0.5, 2, time.Millisecond)
+	m := &Migrator{From: from, To: to, Move: move, Retries: 2,
+		Backpressure: func(to string, stats MigrationStats) time.Duration {
+			if errorRate(to, stats) > 0 {
+				atomic.AddInt32(&holds, 1)
+			}
+			// Go ahead anyway, the error rate would never recover otherwise.
+			return 0
+		}}
+
+	checkpoint, err := m.Run(context.Background(), keyStream(5), Checkpoint{})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, checkpoint.Moved)
+	stats := m.Progress()
+	assert.Equal(t, 15, stats.Attempts)
+	assert.Equal(t, 10, stats.Failures)
+	assert.True(t, atomic.LoadInt32(&holds) > 0)
+
+	m.Retries = 0
+	attempts = 0
+	_, err = m.Run(context.Background(), keyStream(5), Checkpoint{})
+	assert.EqualError(t, err, "busy")
+}
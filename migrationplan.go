@@ -0,0 +1,135 @@
+package hashring
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// MigrationPlan moves keys from one hash configuration to another, e.g. from
+// md5 to WithHasher(XXHash64), range by range instead of all at once. The
+// keyspace of From is split into ranges; keys of a range are placed by From
+// until the range is marked migrated, then by To. Migrating a range typically
+// copies its keys, see Lookup and RangeOf, before MarkMigrated.
+//
+// A MigrationPlan is safe for concurrent use.
+type MigrationPlan struct {
+	From, To *HashRing
+
+	ranges []Range
+
+	mu       sync.Mutex
+	migrated []bool
+	count    int
+}
+
+// MigrationProgress is the progress of a MigrationPlan.
+type MigrationProgress struct {
+	Ranges, Migrated int
+}
+
+// Done reports whether all ranges are migrated.
+func (p MigrationProgress) Done() bool {
+	return p.Migrated == p.Ranges
+}
+
+// MigrationLookup is the owners of a key under both configurations of a
+// MigrationPlan.
+type MigrationLookup struct {
+	From, To string
+	// Range is the index of the range of the key, see MigrationPlan.Ranges.
+	Range int
+	// Migrated reports whether the range is migrated, so the key is To's.
+	Migrated bool
+}
+
+// Owner returns the node the key belongs to now.
+func (l MigrationLookup) Owner() string {
+	if l.Migrated {
+		return l.To
+	}
+	return l.From
+}
+
+// NewMigrationPlan creates a MigrationPlan from from to to, with the
+// keyspace of from split into parts ranges of about equal size, 1 if parts
+// is not positive.
+func NewMigrationPlan(from, to *HashRing, parts int) *MigrationPlan {
+	from = from.orEmpty()
+	if parts <= 0 {
+		parts = 1
+	}
+	width := from.maxHash() / HashKey64(parts)
+	ranges := make([]Range, parts)
+	for i := range ranges {
+		ranges[i] = Range{Start: HashKey64(i) * width, End: HashKey64(i+1)*width - 1}
+	}
+	ranges[parts-1].End = from.maxHash()
+	return &MigrationPlan{From: from, To: to, ranges: ranges, migrated: make([]bool, parts)}
+}
+
+// Ranges returns the ranges of the keyspace of From, in order.
+func (p *MigrationPlan) Ranges() []Range {
+	return append([]Range{}, p.ranges...)
+}
+
+// RangeOf returns the index of the range stringKey hashes into under From.
+func (p *MigrationPlan) RangeOf(stringKey string) int {
+	hash := p.From.GenKey64(stringKey)
+	return sort.Search(len(p.ranges)-1, func(i int) bool { return p.ranges[i].End >= hash })
+}
+
+// Lookup returns the owners of stringKey under From and To, e.g. to read from
+// both while its range is being migrated.
+func (p *MigrationPlan) Lookup(stringKey string) MigrationLookup {
+	i := p.RangeOf(stringKey)
+	l := MigrationLookup{Range: i, Migrated: p.isMigrated(i)}
+	l.From, _ = p.From.GetNode(stringKey)
+	l.To, _ = p.To.GetNode(stringKey)
+	return l
+}
+
+// GetNode returns the node stringKey belongs to now: its owner under To if
+// its range is migrated, under From otherwise. It implements NodeLocator.
+func (p *MigrationPlan) GetNode(stringKey string) (node string, ok bool) {
+	if p.isMigrated(p.RangeOf(stringKey)) {
+		return p.To.GetNode(stringKey)
+	}
+	return p.From.GetNode(stringKey)
+}
+
+func (p *MigrationPlan) isMigrated(i int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.migrated[i]
+}
+
+// MarkMigrated marks range i migrated, so its keys are placed by To.
+func (p *MigrationPlan) MarkMigrated(i int) error {
+	if i < 0 || i >= len(p.ranges) {
+		return fmt.Errorf("hashring: no migration range %d of %d", i, len(p.ranges))
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.migrated[i] {
+		p.migrated[i] = true
+		p.count++
+	}
+	return nil
+}
+
+// Progress returns the number of ranges migrated.
+func (p *MigrationPlan) Progress() MigrationProgress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return MigrationProgress{Ranges: len(p.ranges), Migrated: p.count}
+}
+
+// Cutover returns To once all ranges are migrated, to use as is from then
+// on, or an error if some are not.
+func (p *MigrationPlan) Cutover() (*HashRing, error) {
+	if progress := p.Progress(); !progress.Done() {
+		return nil, fmt.Errorf("hashring: %d of %d migration ranges not migrated", progress.Ranges-progress.Migrated, progress.Ranges)
+	}
+	return p.To, nil
+}
@@ -0,0 +1,62 @@
+package hashring
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrationPlan(t *testing.T) {
+	nodes := []string{"a", "b", "c"}
+	from, to := New(nodes), New(nodes, WithHasher(XXHash64))
+	plan := NewMigrationPlan(from, to, 4)
+
+	ranges := plan.Ranges()
+	assert.Len(t, ranges, 4)
+	assert.Equal(t, HashKey64(0), ranges[0].Start)
+	assert.Equal(t, HashKey64(math.MaxUint32), ranges[3].End)
+	for i := 1; i < len(ranges); i++ {
+		assert.Equal(t, ranges[i-1].End+1, ranges[i].Start)
+	}
+
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		l := plan.Lookup(key)
+		assert.False(t, l.Migrated)
+		assert.True(t, ranges[l.Range].Contains(from.GenKey64(key)))
+		expectNode(t, from, key, l.From)
+		expectNode(t, to, key, l.To)
+		node, _ := plan.GetNode(key)
+		assert.Equal(t, l.From, node)
+	}
+
+	_, err := plan.Cutover()
+	assert.Error(t, err)
+	assert.NoError(t, plan.MarkMigrated(1))
+	assert.NoError(t, plan.MarkMigrated(1))
+	assert.Error(t, plan.MarkMigrated(4))
+	assert.Equal(t, MigrationProgress{Ranges: 4, Migrated: 1}, plan.Progress())
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		l := plan.Lookup(key)
+		assert.Equal(t, l.Range == 1, l.Migrated)
+		node, _ := plan.GetNode(key)
+		assert.Equal(t, l.Owner(), node)
+	}
+
+	for i := range ranges {
+		assert.NoError(t, plan.MarkMigrated(i))
+	}
+	assert.True(t, plan.Progress().Done())
+	ring, err := plan.Cutover()
+	assert.NoError(t, err)
+	assert.Same(t, to, ring)
+}
+
+func TestMigrationPlanWide(t *testing.T) {
+	plan := NewMigrationPlan(New([]string{"a"}, With64BitKeys()), New([]string{"a"}), 0)
+	assert.Equal(t, []Range{{0, math.MaxUint64}}, plan.Ranges())
+	assert.Equal(t, 0, plan.RangeOf("test"))
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package hashring
+
+import (
+	"io"
+	"os"
+)
+
+// mmapFile reads f into memory where mmap is not available.
+func mmapFile(f *os.File) (data []byte, unmap func() error, err error) {
+	data, err = io.ReadAll(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
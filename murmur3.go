@@ -0,0 +1,98 @@
+package hashring
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// Murmur3 is a Hasher using the x64 128-bit MurmurHash3 (seed 0), the hash of
+// Cassandra's and Scylla's Murmur3Partitioner and of many other systems:
+//
+//	ring := hashring.New(nodes, hashring.WithHasher(hashring.Murmur3), hashring.With64BitKeys())
+//
+// Its digest is the 16 bytes of the hash, h1 then h2, little-endian. With
+// 64-bit keys a key is placed at h1, the Cassandra token of the key: tokens
+// are signed, but the ring order of signed and unsigned positions is the same
+// up to a rotation, so keys follow the same points.
+var Murmur3 Hasher = murmur3{}
+
+type murmur3 struct{}
+
+func (murmur3) Hash(key []byte) []byte {
+	h1, h2 := murmur3x64(key, 0)
+	b := make([]byte, 16)
+	binary.LittleEndian.PutUint64(b, h1)
+	binary.LittleEndian.PutUint64(b[8:], h2)
+	return b
+}
+
+const (
+	murmurC1 uint64 = 0x87c37b91114253d5
+	murmurC2 uint64 = 0x4cf5ad432745937f
+)
+
+// murmur3x64 returns the x64 128-bit MurmurHash3 of data.
+func murmur3x64(data []byte, seed uint32) (h1, h2 uint64) {
+	h1, h2 = uint64(seed), uint64(seed)
+	n := len(data)
+
+	for ; len(data) >= 16; data = data[16:] {
+		k1 := binary.LittleEndian.Uint64(data)
+		k2 := binary.LittleEndian.Uint64(data[8:])
+
+		h1 ^= murmurMix1(k1)
+		h1 = bits.RotateLeft64(h1, 27)
+		h1 += h2
+		h1 = h1*5 + 0x52dce729
+
+		h2 ^= murmurMix2(k2)
+		h2 = bits.RotateLeft64(h2, 31)
+		h2 += h1
+		h2 = h2*5 + 0x38495ab5
+	}
+
+	var k1, k2 uint64
+	for i := len(data) - 1; i >= 8; i-- {
+		k2 = k2<<8 | uint64(data[i])
+	}
+	for i := min(len(data), 8) - 1; i >= 0; i-- {
+		k1 = k1<<8 | uint64(data[i])
+	}
+	if len(data) > 8 {
+		h2 ^= murmurMix2(k2)
+	}
+	if len(data) > 0 {
+		h1 ^= murmurMix1(k1)
+	}
+
+	h1 ^= uint64(n)
+	h2 ^= uint64(n)
+	h1 += h2
+	h2 += h1
+	h1 = murmurFmix(h1)
+	h2 = murmurFmix(h2)
+	h1 += h2
+	h2 += h1
+	return h1, h2
+}
+
+func murmurMix1(k uint64) uint64 {
+	k *= murmurC1
+	k = bits.RotateLeft64(k, 31)
+	return k * murmurC2
+}
+
+func murmurMix2(k uint64) uint64 {
+	k *= murmurC2
+	k = bits.RotateLeft64(k, 33)
+	return k * murmurC1
+}
+
+func murmurFmix(k uint64) uint64 {
+	k ^= k >> 33
+	k *= 0xff51afd7ed558ccd
+	k ^= k >> 33
+	k *= 0xc4ceb9fe1a85ec53
+	k ^= k >> 33
+	return k
+}
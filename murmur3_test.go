@@ -0,0 +1,54 @@
+package hashring
+
+import (
+	"encoding/binary"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMurmur3(t *testing.T) {
+	// Vectors of Guava's Murmur3_128HashFunction tests.
+	tt := []struct {
+		key    string
+		seed   uint32
+		h1, h2 uint64
+	}{
+		{"", 0, 0, 0},
+		{"hell", 0, 0x629942693e10f867, 0x92db0b82baeb5347},
+		{"hello", 1, 0xa78ddff5adae8d10, 0x128900ef20900135},
+		{"hello ", 2, 0x8a486b23f422e826, 0xf962a2c58947765f},
+		{"hello w", 3, 0x2ea59f466f6bed8c, 0xc610990acc428a17},
+		{"hello wo", 4, 0x79f6305a386c572c, 0x46305aed3483b94e},
+		{"hello wor", 5, 0xc2219d213ec1f1b5, 0xa1d8e2e0a52785bd},
+		{"The quick brown fox jumps over the lazy dog", 0, 0xe34bbc7bbc071b6c, 0x7a433ca9c49a9347},
+		{"The quick brown fox jumps over the lazy cog", 0, 0x658ca970ff85269a, 0x43fee3eaa68e5c3e},
+	}
+	for _, tc := range tt {
+		h1, h2 := murmur3x64([]byte(tc.key), tc.seed)
+		assert.Equal(t, tc.h1, h1, tc.key)
+		assert.Equal(t, tc.h2, h2, tc.key)
+		if tc.seed == 0 {
+			digest := Murmur3.Hash([]byte(tc.key))
+			assert.Equal(t, tc.h1, binary.LittleEndian.Uint64(digest))
+			assert.Equal(t, tc.h2, binary.LittleEndian.Uint64(digest[8:]))
+		}
+	}
+}
+
+func TestWithHasherMurmur3(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"}, WithHasher(Murmur3), With64BitKeys())
+	assert.NoError(t, hashRing.Validate())
+	h1, _ := murmur3x64([]byte("test"), 0)
+	assert.Equal(t, HashKey64(h1), hashRing.GenKey64("test"))
+
+	counts := make(map[string]int)
+	for i := 0; i < 3000; i++ {
+		node, _ := hashRing.GetNode(strconv.Itoa(i))
+		counts[node]++
+	}
+	for _, node := range []string{"a", "b", "c"} {
+		assert.InDelta(t, 1000, counts[node], 250, node)
+	}
+}
@@ -0,0 +1,34 @@
+package hashring
+
+// WithName names the ring. The name, and the labels of WithRingLabels, are
+// included in the metrics of a RingMetricsSink and on the Dashboard, so the
+// telemetry of several rings in a service can be told apart.
+func WithName(name string) Option {
+	return func(c *config) {
+		c.name = name
+	}
+}
+
+// WithRingLabels attaches labels to the ring, see WithName.
+// Unlike WithNodeLabels, they describe the ring as a whole.
+func WithRingLabels(labels Labels) Option {
+	return func(c *config) {
+		c.ringLabels = labels
+	}
+}
+
+// Name returns the name of the ring, see WithName.
+func (h *HashRing) Name() string {
+	if h == nil {
+		return ""
+	}
+	return h.config.name
+}
+
+// RingLabels returns the labels of the ring, see WithRingLabels.
+func (h *HashRing) RingLabels() Labels {
+	if h == nil {
+		return nil
+	}
+	return h.config.ringLabels
+}
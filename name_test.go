@@ -0,0 +1,48 @@
+package hashring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ringSink struct {
+	fakeSink
+	name   string
+	labels Labels
+}
+
+func (s *ringSink) ForRing(name string, labels Labels) MetricsSink {
+	return &ringSink{name: name, labels: labels}
+}
+
+func TestWithName(t *testing.T) {
+	labels := Labels{"team": "storage"}
+	hashRing := New([]string{"a"}, WithName("sessions"), WithRingLabels(labels))
+	assert.Equal(t, "sessions", hashRing.Name())
+	assert.Equal(t, labels, hashRing.RingLabels())
+
+	derived := hashRing.AddNode("b")
+	assert.Equal(t, "sessions", derived.Name())
+	assert.Equal(t, labels, derived.RingLabels())
+
+	var nilRing *HashRing
+	assert.Equal(t, "", nilRing.Name())
+	assert.Nil(t, nilRing.RingLabels())
+}
+
+func TestWithNameMetrics(t *testing.T) {
+	sink := &ringSink{}
+	hashRing := New([]string{"a"}, WithMetrics(sink), WithName("sessions"), WithRingLabels(Labels{"team": "storage"}))
+	bound := hashRing.config.metrics.(*ringSink)
+	assert.Equal(t, "sessions", bound.name)
+	assert.Equal(t, Labels{"team": "storage"}, bound.labels)
+
+	hashRing.GetNode("test")
+	assert.Equal(t, map[string]int{"a": 1}, bound.lookups)
+	assert.Empty(t, sink.lookups)
+
+	// Unnamed rings report to the sink itself.
+	New([]string{"a"}, WithMetrics(sink)).GetNode("test")
+	assert.Equal(t, map[string]int{"a": 1}, sink.lookups)
+}
@@ -0,0 +1,76 @@
+package hashring
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"strings"
+)
+
+// nginxPointsPerWeight is the number of points nginx places per unit of weight.
+const nginxPointsPerWeight = 160
+
+// WithNginxConsistent places nodes and keys as nginx's "hash $key consistent"
+// does, so a router built on the ring picks the same upstream as nginx:
+//
+//   - a server "host:port" of weight w places 160*w points, each the crc32 of
+//     host, a zero byte, port and the previous point, little-endian (zero for
+//     the first); "unix:path" servers hash the path with no port, and servers
+//     without a port hash port "80";
+//   - a key belongs to the first point at or after the crc32 of the key.
+//
+// Nodes must be named as their server directives, e.g. "10.0.0.1:8080".
+//
+// WithNginxConsistent overrides WithHasher, With64BitKeys, WithSeed,
+// WithBoundary and WithTargetImbalance.
+func WithNginxConsistent() Option {
+	return func(c *config) {
+		c.placement = placementNginx
+	}
+}
+
+// crc32Hasher is the Hasher of WithNginxConsistent, the 4 little-endian bytes
+// of the IEEE crc32.
+type crc32Hasher struct{}
+
+func (crc32Hasher) Hash(key []byte) []byte {
+	return binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE(key))
+}
+
+// nginxPoints returns the points of server from index from up to to
+// (exclusive). A point depends on the previous one, so all points up to to are
+// computed.
+func nginxPoints(server string, from, to int) []HashKey64 {
+	var base string
+	if len(server) >= 5 && strings.EqualFold(server[:5], "unix:") {
+		base = server[5:] + "\x00"
+	} else {
+		base = nginxHostPort(server)
+	}
+	baseHash := crc32.ChecksumIEEE([]byte(base))
+
+	points := make([]HashKey64, 0)
+	var prev [4]byte
+	for j := 0; j < to; j++ {
+		hash := crc32.Update(baseHash, crc32.IEEETable, prev[:])
+		if j >= from {
+			points = append(points, HashKey64(hash))
+		}
+		binary.LittleEndian.PutUint32(prev[:], hash)
+	}
+	return points
+}
+
+// nginxHostPort returns host, a zero byte and port of server, port "80" if
+// server has none.
+func nginxHostPort(server string) string {
+	for j := len(server) - 1; j >= 0; j-- {
+		c := server[j]
+		if c == ':' {
+			return server[:j] + "\x00" + server[j+1:]
+		}
+		if c < '0' || c > '9' {
+			break
+		}
+	}
+	return server + "\x0080"
+}
@@ -0,0 +1,58 @@
+package hashring
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNginxPoints(t *testing.T) {
+	first := crc32.ChecksumIEEE([]byte("10.0.0.1\x008080\x00\x00\x00\x00"))
+	second := crc32.ChecksumIEEE(binary.LittleEndian.AppendUint32([]byte("10.0.0.1\x008080"), first))
+	assert.Equal(t, []HashKey64{HashKey64(first), HashKey64(second)}, nginxPoints("10.0.0.1:8080", 0, 2))
+	assert.Equal(t, []HashKey64{HashKey64(second)}, nginxPoints("10.0.0.1:8080", 1, 2))
+
+	assert.Equal(t, "backend\x0080", nginxHostPort("backend"))
+	assert.Equal(t, "[::1]\x00443", nginxHostPort("[::1]:443"))
+	assert.Equal(t, "backend:x\x0080", nginxHostPort("backend:x"))
+	assert.Equal(t, HashKey64(crc32.ChecksumIEEE([]byte("/tmp/sock\x00\x00\x00\x00\x00"))), nginxPoints("UNIX:/tmp/sock", 0, 1)[0])
+}
+
+func TestWithNginxConsistent(t *testing.T) {
+	weights := map[string]int{"10.0.0.1:8080": 1, "10.0.0.2:8080": 2, "backend": 1}
+	hashRing := NewWithWeights(weights, WithNginxConsistent(), With64BitKeys())
+	assert.NoError(t, hashRing.Validate())
+	assert.Equal(t, "nginx", hashRing.Audit().Placement)
+	assert.Equal(t, "crc32", hashRing.Audit().Hasher)
+
+	owners := make(map[uint32]string)
+	var points []uint32
+	for server, weight := range weights {
+		for _, point := range nginxPoints(server, 0, weight*160) {
+			points = append(points, uint32(point))
+			owners[uint32(point)] = server
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i] < points[j] })
+	assert.Len(t, hashRing.sortedKeys, len(points))
+
+	for i := 0; i < 2000; i++ {
+		key := "/path/" + strconv.Itoa(i)
+		hash := crc32.ChecksumIEEE([]byte(key))
+		pos := sort.Search(len(points), func(i int) bool { return points[i] >= hash }) % len(points)
+
+		assert.Equal(t, HashKey(hash), hashRing.GenKey(key))
+		node, ok := hashRing.GetNode(key)
+		assert.True(t, ok)
+		assert.Equal(t, owners[points[pos]], node, key)
+	}
+
+	// Weight changes only place the points of the change.
+	updated := hashRing.UpdateWeightedNode("backend", 3)
+	assert.Len(t, updated.sortedKeys, len(points)+2*160)
+	assert.NoError(t, updated.Validate())
+}
@@ -0,0 +1,60 @@
+package hashring
+
+import "encoding/binary"
+
+// WithUint64Mixer sets the function GetNodeUint64 uses to turn integer keys
+// into HashKeys. By default the 8 little-endian bytes of the key are hashed
+// like string keys. Mix64 is a faster alternative.
+func WithUint64Mixer(mix func(k uint64) HashKey) Option {
+	return func(c *config) {
+		c.uint64Mixer = mix
+	}
+}
+
+// Mix64 mixes k with the splitmix64 finalizer and folds it to a HashKey.
+// It is much cheaper than a digest, and spreads sequential IDs well.
+func Mix64(k uint64) HashKey {
+	k ^= k >> 30
+	k *= 0xbf58476d1ce4e5b9
+	k ^= k >> 27
+	k *= 0x94d049bb133111eb
+	k ^= k >> 31
+	return HashKey(k ^ k>>32)
+}
+
+// GenKeyUint64 generates HashKey of integer key k without formatting it.
+func (h *HashRing) GenKeyUint64(k uint64) HashKey {
+	if h != nil && h.config.uint64Mixer != nil {
+		return h.config.uint64Mixer(k)
+	}
+	return h.narrow(h.keyUint64(k))
+}
+
+// keyUint64 returns the position of integer key k on ring. The HashKey of a
+// mixer is the upper half of the position with 64-bit keys.
+func (h *HashRing) keyUint64(k uint64) HashKey64 {
+	if h != nil && h.config.uint64Mixer != nil {
+		return h.widen(h.config.uint64Mixer(k))
+	}
+	if h != nil && h.config.hasher != nil {
+		b := make([]byte, 8)
+		binary.LittleEndian.PutUint64(b, k)
+		return h.position(h.config.hasher.Hash(b))
+	}
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], k)
+	bKey := hashDigestBytes(b[:])
+	return h.position(bKey[:])
+}
+
+// GetNodeUint64 returns the node that integer key k belongs to.
+func (h *HashRing) GetNodeUint64(k uint64) (node string, ok bool) {
+	if h.single() {
+		return h.lookupAt(0), true
+	}
+	pos, ok := h.keyPos(h.keyUint64(k))
+	if !ok {
+		return "", false
+	}
+	return h.lookupAt(pos), true
+}
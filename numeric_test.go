@@ -0,0 +1,66 @@
+package hashring
+
+import (
+	"encoding/binary"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNodeUint64(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"})
+	for i := uint64(0); i < 100; i++ {
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], i)
+		expected, _ := hashRing.GetNode(string(b[:]))
+
+		node, ok := hashRing.GetNodeUint64(i)
+		assert.True(t, ok)
+		assert.Equal(t, expected, node)
+	}
+
+	_, ok := New([]string{}).GetNodeUint64(1)
+	assert.False(t, ok)
+}
+
+func TestGetNodeUint64Mixer(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"}, WithUint64Mixer(Mix64))
+	counts := make(map[string]int)
+	for i := uint64(0); i < 3000; i++ {
+		assert.Equal(t, Mix64(i), hashRing.GenKeyUint64(i))
+		node, ok := hashRing.GetNodeUint64(i)
+		assert.True(t, ok)
+		counts[node]++
+	}
+	for _, node := range []string{"a", "b", "c"} {
+		assert.InDelta(t, 1000, counts[node], 300, node)
+	}
+
+	// The mixer survives topology changes.
+	assert.Equal(t, Mix64(42), hashRing.AddNode("d").GenKeyUint64(42))
+}
+
+func TestGetNodeUint64Allocs(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"})
+	allocs := testing.AllocsPerRun(100, func() {
+		hashRing.GetNodeUint64(12345)
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func BenchmarkGetNodeUint64(b *testing.B) {
+	hashRing := New([]string{"a", "b", "c", "d", "e", "f", "g"})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hashRing.GetNodeUint64(uint64(i))
+	}
+}
+
+func BenchmarkGetNodeFormattedUint64(b *testing.B) {
+	hashRing := New([]string{"a", "b", "c", "d", "e", "f", "g"})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hashRing.GetNode(strconv.FormatUint(uint64(i), 10))
+	}
+}
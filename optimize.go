@@ -0,0 +1,106 @@
+package hashring
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// optimizeSteps is the number of steps OptimizeWeights divides the way
+	// from the current weights to the target in.
+	optimizeSteps = 16
+	// optimizeResolution is the least total weight OptimizeWeights expresses
+	// the target shares in.
+	optimizeResolution = 1000
+)
+
+// WeightRecommendation is the result of OptimizeWeights.
+type WeightRecommendation struct {
+	// Weights are the recommended weights, to apply with ApplyWeights.
+	Weights map[string]int
+	// Churn is the fraction of the keyspace applying Weights moves.
+	Churn float64
+	// Error is the largest difference between the keyspace share of a node
+	// under Weights and its target share.
+	Error float64
+	// Reached reports whether Weights go all the way to the target, rather
+	// than part of the way within the churn bound.
+	Reached bool
+}
+
+// OptimizeWeights recommends integer weights giving the nodes of target the
+// keyspace shares of target, relative to its sum, while moving at most
+// maxChurn of the keyspace, see TopologyDiff.Churn. Nodes of h missing from
+// target get weight 0, as standbys, see AddWeightedNode.
+//
+// If going all the way to target would move more than maxChurn, the weights
+// are those of the furthest of 16 steps from the current weights
+// towards target that does not, or the current weights if none. Each step
+// tried costs a rebuild of the ring; the ring is left as is.
+func (h *HashRing) OptimizeWeights(target map[string]float64, maxChurn float64) (WeightRecommendation, error) {
+	h = h.orEmpty()
+	sum := 0.0
+	for node, share := range target {
+		if share < 0 || math.IsNaN(share) || math.IsInf(share, 0) {
+			return WeightRecommendation{}, fmt.Errorf("hashring: invalid target share %v of node %q", share, node)
+		}
+		sum += share
+	}
+	if sum == 0 {
+		return WeightRecommendation{}, fmt.Errorf("hashring: target has no shares")
+	}
+
+	// Scaling the current weights by a power of 2 keeps their ratios, and so
+	// the keys, while giving finer target shares, where the points of a node
+	// follow its share of the weight. With nginx placement they follow its
+	// weight itself, so scaling would add points and move keys: the weights
+	// keep their total there.
+	current := h.weighting().total
+	total := optimizeResolution
+	if current > 0 {
+		for total = current; total < optimizeResolution && h.config.placement != placementNginx; {
+			total *= 2
+		}
+	}
+	nodes := h.Nodes()
+	for node := range target {
+		nodes = append(nodes, node)
+	}
+	nodes = sortedNodes(nodes)
+	weightsAt := func(step int) map[string]int {
+		alpha := float64(step) / optimizeSteps
+		weights := make(map[string]int, len(nodes))
+		for _, node := range nodes {
+			from := 0.0
+			if current > 0 {
+				from = float64(h.weights[node]) / float64(current)
+			}
+			share := (1-alpha)*from + alpha*target[node]/sum
+			weights[node] = int(math.Round(share * float64(total)))
+		}
+		return weights
+	}
+
+	for step := optimizeSteps; step > 0; step-- {
+		weights := weightsAt(step)
+		d, err := h.ApplyWeights(weights, true)
+		if err != nil {
+			return WeightRecommendation{}, err
+		}
+		if d.Churn > maxChurn {
+			continue
+		}
+		rec := WeightRecommendation{Weights: weights, Churn: d.Churn, Reached: step == optimizeSteps}
+		for _, o := range d.Ownership {
+			rec.Error = math.Max(rec.Error, math.Abs(o.New-target[o.Node]/sum))
+		}
+		return rec, nil
+	}
+
+	rec := WeightRecommendation{Weights: h.Topology()}
+	ownership := h.ownership()
+	for _, node := range nodes {
+		rec.Error = math.Max(rec.Error, math.Abs(ownership[node]-target[node]/sum))
+	}
+	return rec, nil
+}
@@ -0,0 +1,70 @@
+package hashring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptimizeWeights(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c", "d"})
+	target := map[string]float64{"a": 2, "b": 1, "c": 1, "d": 1, "e": 1}
+
+	rec, err := hashRing.OptimizeWeights(target, 1)
+	assert.NoError(t, err)
+	assert.True(t, rec.Reached)
+	assert.Equal(t, map[string]int{"a": 341, "b": 171, "c": 171, "d": 171, "e": 171}, rec.Weights)
+	d, _ := hashRing.ApplyWeights(rec.Weights, true)
+	assert.Equal(t, d.Churn, rec.Churn)
+	assert.Less(t, rec.Error, 0.05)
+
+	// A tight bound goes part of the way.
+	reweight := map[string]float64{"a": 2, "b": 1, "c": 1, "d": 1}
+	full, err := hashRing.OptimizeWeights(reweight, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"a": 410, "b": 205, "c": 205, "d": 205}, full.Weights)
+	partial, err := hashRing.OptimizeWeights(reweight, full.Churn/2)
+	assert.NoError(t, err)
+	assert.False(t, partial.Reached)
+	assert.LessOrEqual(t, partial.Churn, full.Churn/2)
+	assert.Greater(t, partial.Weights["a"], partial.Weights["b"])
+	assert.Less(t, partial.Weights["a"], 2*partial.Weights["b"])
+
+	// No bound at all keeps the current weights.
+	none, err := hashRing.OptimizeWeights(target, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, hashRing.Topology(), Topology(none.Weights))
+	assert.Zero(t, none.Churn)
+	assert.False(t, none.Reached)
+	assert.Len(t, hashRing.Nodes(), 4)
+}
+
+func TestOptimizeWeightsStandby(t *testing.T) {
+	hashRing := New([]string{"a", "b"})
+	rec, err := hashRing.OptimizeWeights(map[string]float64{"a": 1}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"a": 1024, "b": 0}, rec.Weights)
+	assert.InDelta(t, 0.5, rec.Churn, 0.1)
+	assert.Zero(t, rec.Error)
+
+	rec, err = New(nil).OptimizeWeights(map[string]float64{"a": 1, "b": 3}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"a": 250, "b": 750}, rec.Weights)
+}
+
+func TestOptimizeWeightsInvalid(t *testing.T) {
+	hashRing := New([]string{"a", "b"})
+	for _, target := range []map[string]float64{nil, {"a": 0}, {"a": -1}} {
+		_, err := hashRing.OptimizeWeights(target, 1)
+		assert.Error(t, err, target)
+	}
+}
+
+func TestOptimizeWeightsNginx(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"}, WithNginxConsistent())
+	rec, err := hashRing.OptimizeWeights(map[string]float64{"a": 1, "b": 1, "c": 1}, 0.01)
+	assert.NoError(t, err)
+	assert.True(t, rec.Reached)
+	assert.Zero(t, rec.Churn)
+	assert.Equal(t, hashRing.Topology(), Topology(rec.Weights))
+}
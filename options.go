@@ -0,0 +1,150 @@
+package hashring
+
+import (
+	"context"
+	"math"
+	"sort"
+)
+
+const (
+	// defaultReplicas is the number of virtual nodes of a node with average weight.
+	defaultReplicas = 40
+	// maxTunedReplicas bounds the replicas chosen by WithTargetImbalance.
+	maxTunedReplicas = 1280
+)
+
+// Option configures a HashRing at construction.
+// Rings derived by AddNode, RemoveNode and friends keep the options.
+type Option func(*config)
+
+type config struct {
+	targetImbalance float64
+	labels          map[string]Labels
+	uint64Mixer     func(uint64) HashKey
+	metrics         MetricsSink
+	readSpread      int
+	boundary        Boundary
+	hasher          Hasher
+	wide            bool
+	name            string
+	ringLabels      Labels
+	seed            uint64
+	placement       placement
+	pointsPerDigest int
+	replicaFactor   int
+	maxNodes        int
+	maxPoints       int
+	latency         *latencyGuard
+	weightScale     int
+	onOwnershipLoss func(OwnershipLoss)
+	onDrift         func(HealthWarning)
+	bounded         *loadBound
+	tenants         map[string]map[string]int
+	maxShare        float64
+	maglevSize      int
+	clock           Clock
+	interceptors    []Interceptor
+}
+
+func newConfig(opts []Option) config {
+	var c config
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.placement != placementDefault {
+		c.hasher, c.wide, c.seed, c.targetImbalance, c.pointsPerDigest = nil, false, 0, 0, 0
+		c.boundary = BoundaryAtOrAfter
+		if c.placement == placementNginx {
+			c.hasher = crc32Hasher{}
+		}
+	}
+	if sink, ok := c.metrics.(RingMetricsSink); ok && (c.name != "" || len(c.ringLabels) > 0) {
+		c.metrics = sink.ForRing(c.name, c.ringLabels)
+	}
+	return c
+}
+
+// WithReplicaFactor sets the number of virtual nodes of a node of average
+// weight, 40 by default. More virtual nodes balance keys better, at the cost
+// of memory and rebuild time. Changing it moves most keys.
+func WithReplicaFactor(n int) Option {
+	return func(c *config) {
+		c.replicaFactor = n
+	}
+}
+
+// baseReplicas returns the number of virtual nodes of a node of average
+// weight before tuning, see WithReplicaFactor.
+func (c config) baseReplicas() int {
+	if c.replicaFactor > 0 {
+		return c.replicaFactor
+	}
+	return defaultReplicas
+}
+
+// WithTargetImbalance chooses the number of virtual nodes automatically, so
+// that Imbalance() of the ring is at most p (e.g. 0.05 for 5%).
+//
+// The ring starts from the default, or WithReplicaFactor, and grows the number of virtual nodes
+// until the target is met, up to a limit. If the limit cannot meet the target,
+// the most balanced ring tried is used.
+func WithTargetImbalance(p float64) Option {
+	return func(c *config) {
+		c.targetImbalance = p
+	}
+}
+
+// tuneReplicas grows h.replicas until the ring meets the target imbalance.
+// Imbalance falls roughly with the square root of the number of points, which
+// is used to estimate the next attempt.
+// It returns ctx.Err() if ctx is done first.
+func (h *HashRing) tuneReplicas(ctx context.Context) error {
+	target := h.config.targetImbalance
+
+	best, bestImbalance := h.replicas, h.Imbalance()
+	for imbalance := bestImbalance; imbalance > target && h.replicas < maxTunedReplicas; {
+		estimate := int(math.Ceil(float64(h.replicas) * (imbalance / target) * (imbalance / target)))
+		replicas := h.replicas * 2
+		if estimate > replicas {
+			replicas = estimate
+		}
+		if replicas > maxTunedReplicas {
+			replicas = maxTunedReplicas
+		}
+		if h.config.maxPoints > 0 && h.pointsFor(replicas) > h.config.maxPoints {
+			// The most replicas within the limit.
+			replicas = h.replicas + sort.Search(replicas-h.replicas, func(i int) bool {
+				return h.pointsFor(h.replicas+i+1) > h.config.maxPoints
+			})
+			if replicas == h.replicas {
+				break
+			}
+		}
+
+		h.replicas = replicas
+		if err := h.placePoints(ctx); err != nil {
+			return err
+		}
+		imbalance = h.Imbalance()
+		if imbalance < bestImbalance {
+			best, bestImbalance = replicas, imbalance
+		}
+	}
+
+	if h.replicas != best {
+		h.replicas = best
+		return h.placePoints(ctx)
+	}
+	return nil
+}
+
+// WithNodeLabels attaches labels to nodes, such as {"zone": "us-east-1a"},
+// for placement constraints. Nodes without labels have none.
+func WithNodeLabels(labels map[string]Labels) Option {
+	return func(c *config) {
+		c.labels = make(map[string]Labels, len(labels))
+		for node, l := range labels {
+			c.labels[node] = l
+		}
+	}
+}
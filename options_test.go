@@ -0,0 +1,60 @@
+package hashring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTargetImbalance(t *testing.T) {
+	nodes := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	plain := New(nodes)
+	assert.Equal(t, defaultReplicas, plain.replicas)
+
+	hashRing := New(nodes, WithTargetImbalance(0.05))
+	assert.True(t, hashRing.replicas > defaultReplicas)
+	assert.True(t, hashRing.Imbalance() <= 0.05, "imbalance %v", hashRing.Imbalance())
+	assert.True(t, hashRing.Imbalance() < plain.Imbalance())
+}
+
+func TestWithTargetImbalanceAlreadyMet(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"}, WithTargetImbalance(1))
+	assert.Equal(t, defaultReplicas, hashRing.replicas)
+	expectNodesABC(t, hashRing)
+}
+
+func TestWithTargetImbalanceUnreachable(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"}, WithTargetImbalance(1e-9))
+	assert.True(t, hashRing.replicas <= maxTunedReplicas)
+	assert.True(t, hashRing.Imbalance() < New([]string{"a", "b", "c"}).Imbalance())
+}
+
+func TestWithTargetImbalanceDerived(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"}, WithTargetImbalance(0.05))
+	hashRing = hashRing.AddNode("d").RemoveNode("a").AddWeightedNode("e", 2)
+	assert.True(t, hashRing.Imbalance() <= 0.05, "imbalance %v", hashRing.Imbalance())
+
+	hashRing.UpdateWithWeights(map[string]int{"a": 1, "b": 3})
+	assert.True(t, hashRing.Imbalance() <= 0.05, "imbalance %v", hashRing.Imbalance())
+}
+
+func TestWithReplicaFactor(t *testing.T) {
+	nodes := []string{"a", "b", "c", "d"}
+	small := New(nodes, WithReplicaFactor(10))
+	assert.Equal(t, 10, small.replicas)
+	assert.Len(t, small.sortedKeys, len(nodes)*10*3)
+	assert.NoError(t, small.Validate())
+
+	large := New(nodes, WithReplicaFactor(200))
+	assert.Len(t, large.sortedKeys, len(nodes)*200*3)
+	assert.True(t, large.Imbalance() < small.Imbalance())
+
+	// Derived rings keep the factor.
+	updated := small.UpdateWeightedNode("a", 2)
+	assert.Equal(t, 10, updated.replicas)
+	assert.Equal(t, NewWithWeights(map[string]int{"a": 2, "b": 1, "c": 1, "d": 1}, WithReplicaFactor(10)).sortedKeys, updated.sortedKeys)
+	assert.Equal(t, New(append(nodes, "e"), WithReplicaFactor(10)).sortedKeys, small.AddNode("e").sortedKeys)
+
+	assert.Equal(t, New(nodes).sortedKeys, New(nodes, WithReplicaFactor(0)).sortedKeys)
+	assert.Equal(t, 10, small.Audit().Replicas)
+}
@@ -0,0 +1,64 @@
+package hashring
+
+// IsOwner reports whether node is the node stringKey belongs to, see
+// GetNode, without allocating, e.g. to filter the requests of a node.
+func (h *HashRing) IsOwner(stringKey, node string) bool {
+	pos, ok := h.GetNodePos(stringKey)
+	return ok && h.ownerAt(pos) == node
+}
+
+// OwnerAmongReplicas reports whether node is one of the n nodes of
+// stringKey, see GetNodes, without allocating for n up to 16. Unlike
+// GetNodes, it is not an error for the ring to have fewer than n nodes.
+func (h *HashRing) OwnerAmongReplicas(stringKey, node string, n int) bool {
+	pos, ok := h.GetNodePos(stringKey)
+	return ok && h.amongReplicasAt(pos, node, n)
+}
+
+// GetNodeWithPreference returns preferred if it is one of the k nodes of
+// stringKey, see OwnerAmongReplicas, and otherwise the node stringKey belongs
+// to, e.g. for a node to serve a key locally whenever it legitimately holds
+// a replica of it.
+func (h *HashRing) GetNodeWithPreference(stringKey, preferred string, k int) (node string, ok bool) {
+	pos, ok := h.GetNodePos(stringKey)
+	if !ok {
+		return "", false
+	}
+	if h.amongReplicasAt(pos, preferred, k) {
+		return preferred, true
+	}
+	return h.ownerAt(pos), true
+}
+
+// amongReplicasAt reports whether node is one of the n nodes of the walk
+// from pos, see OwnerAmongReplicas.
+func (h *HashRing) amongReplicasAt(pos int, node string, n int) bool {
+	if n <= 0 {
+		return false
+	}
+
+	var buf [16]int32
+	seen := buf[:0]
+	skips := len(h.skips) == len(h.sortedKeys)
+walk:
+	for i, step := pos, 1; i < pos+len(h.sortedKeys); i += step {
+		j := i % len(h.sortedKeys)
+		if skips {
+			step = int(h.skips[j])
+		}
+		owner := h.owners[j]
+		for _, s := range seen {
+			if s == owner {
+				continue walk
+			}
+		}
+		if h.names[owner] == node {
+			return true
+		}
+		seen = append(seen, owner)
+		if len(seen) == n {
+			return false
+		}
+	}
+	return false
+}
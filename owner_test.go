@@ -0,0 +1,60 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsOwner(t *testing.T) {
+	hashRing := NewWithWeights(map[string]int{"a": 1, "b": 2, "c": 1, "d": 1})
+	for i := 0; i < 300; i++ {
+		key := strconv.Itoa(i)
+		owner, _ := hashRing.GetNode(key)
+		replicas, _ := hashRing.GetNodes(key, 3)
+		for _, node := range []string{"a", "b", "c", "d", "unknown"} {
+			assert.Equal(t, node == owner, hashRing.IsOwner(key, node), key)
+			assert.Equal(t, node == owner, hashRing.OwnerAmongReplicas(key, node, 1), key)
+			in := false
+			for _, replica := range replicas {
+				in = in || replica == node
+			}
+			assert.Equal(t, in, hashRing.OwnerAmongReplicas(key, node, 3), key)
+			assert.Equal(t, node != "unknown", hashRing.OwnerAmongReplicas(key, node, 10), key)
+		}
+	}
+
+	assert.False(t, hashRing.OwnerAmongReplicas("key", "a", 0))
+	assert.False(t, New(nil).IsOwner("key", "a"))
+	assert.False(t, New(nil).OwnerAmongReplicas("key", "a", 1))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		hashRing.IsOwner("key", "a")
+		hashRing.OwnerAmongReplicas("key", "unknown", 3)
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func TestGetNodeWithPreference(t *testing.T) {
+	hashRing := NewWithWeights(map[string]int{"a": 1, "b": 2, "c": 1, "d": 1})
+	for i := 0; i < 300; i++ {
+		key := strconv.Itoa(i)
+		owner, _ := hashRing.GetNode(key)
+		replicas, _ := hashRing.GetNodes(key, 2)
+		for _, preferred := range []string{"a", "b", "c", "d", "unknown"} {
+			want := owner
+			if preferred == replicas[0] || preferred == replicas[1] {
+				want = preferred
+			}
+			node, ok := hashRing.GetNodeWithPreference(key, preferred, 2)
+			assert.True(t, ok)
+			assert.Equal(t, want, node, key)
+			node, _ = hashRing.GetNodeWithPreference(key, preferred, 0)
+			assert.Equal(t, owner, node, key)
+		}
+	}
+
+	_, ok := New(nil).GetNodeWithPreference("key", "a", 1)
+	assert.False(t, ok)
+}
@@ -0,0 +1,61 @@
+package hashring
+
+import "reflect"
+
+// OwnedRange is a range of hashes with the nodes keeping its keys: Owner,
+// where GetNode puts them, and Replicas, the nodes after it in GetNodes.
+type OwnedRange struct {
+	Range
+	Owner    string
+	Replicas []string
+}
+
+// OwnershipTable returns the keyspace of h as sorted, disjoint ranges
+// covering every hash, each with its replica set of size nodes as GetNodes
+// returns them, or all nodes if fewer are on the ring. Adjacent ranges with
+// the same replica set are merged, except across the wrap-around.
+func (h *HashRing) OwnershipTable(size int) []OwnedRange {
+	h = h.orEmpty()
+	if len(h.sortedKeys) == 0 || size <= 0 {
+		return nil
+	}
+
+	starts := h.ownerStarts()
+	unique := starts[:1]
+	for _, start := range starts[1:] {
+		if start != unique[len(unique)-1] {
+			unique = append(unique, start)
+		}
+	}
+
+	var table []OwnedRange
+	max := h.maxHash()
+	for i, start := range unique {
+		end := max
+		if i+1 < len(unique) {
+			end = unique[i+1] - 1
+		}
+		pos, _ := h.keyPos(start)
+		nodes, _ := h.walk(pos, size)
+		if n := len(table); n > 0 && table[n-1].Owner == nodes[0] && reflect.DeepEqual(table[n-1].Replicas, nodes[1:]) {
+			table[n-1].End = end
+			continue
+		}
+		table = append(table, OwnedRange{Range: Range{start, end}, Owner: nodes[0], Replicas: nodes[1:]})
+	}
+	return table
+}
+
+// KeyRangesForNode returns the hashes whose keys node owns, see GetNode, as
+// sorted, disjoint ranges, e.g. to plan the backfill of a node joining. An
+// arc wrapping around the keyspace is split in two, one ending at the
+// largest hash and one starting at 0.
+func (h *HashRing) KeyRangesForNode(node string) []Range {
+	var ranges []Range
+	for _, r := range h.OwnershipTable(1) {
+		if r.Owner == node {
+			ranges = append(ranges, r.Range)
+		}
+	}
+	return ranges
+}
@@ -0,0 +1,82 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOwnershipTable(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithBoundary(BoundaryAtOrAfter)}, {With64BitKeys()}} {
+		hashRing := NewWithWeights(map[string]int{"a": 1, "b": 2, "c": 1}, opts...)
+		table := hashRing.OwnershipTable(2)
+
+		// The ranges cover the keyspace, each with its GetNodes.
+		assert.Equal(t, HashKey64(0), table[0].Start)
+		assert.Equal(t, hashRing.maxHash(), table[len(table)-1].End)
+		for i := 1; i < len(table); i++ {
+			assert.Equal(t, table[i-1].End+1, table[i].Start)
+		}
+		for i := 0; i < 500; i++ {
+			key := strconv.Itoa(i)
+			hash := hashRing.GenKey64(key)
+			r := table[0]
+			for _, r = range table {
+				if r.Contains(hash) {
+					break
+				}
+			}
+			nodes, _ := hashRing.GetNodes(key, 2)
+			assert.Equal(t, nodes[0], r.Owner, key)
+			assert.Equal(t, nodes[1:], r.Replicas, key)
+		}
+
+		// Adjacent ranges differ in their nodes.
+		for i := 1; i < len(table); i++ {
+			assert.False(t, table[i-1].Owner == table[i].Owner &&
+				assert.ObjectsAreEqual(table[i-1].Replicas, table[i].Replicas))
+		}
+	}
+
+	// Fewer nodes than size give all of them.
+	table := New([]string{"a"}).OwnershipTable(3)
+	assert.Equal(t, []OwnedRange{{Range: Range{0, 1<<32 - 1}, Owner: "a", Replicas: []string{}}}, table)
+	assert.Empty(t, New(nil).OwnershipTable(1))
+	assert.Empty(t, New([]string{"a"}).OwnershipTable(0))
+}
+
+func TestKeyRangesForNode(t *testing.T) {
+	hashRing := NewWithWeights(map[string]int{"a": 1, "b": 2, "c": 1})
+	ownership := hashRing.ownership()
+	covered := 0.0
+	for _, node := range []string{"a", "b", "c"} {
+		ranges := hashRing.KeyRangesForNode(node)
+		size := 0.0
+		for i, r := range ranges {
+			assert.LessOrEqual(t, r.Start, r.End)
+			if i > 0 {
+				assert.Greater(t, r.Start, ranges[i-1].End+1)
+			}
+			size += float64(r.End-r.Start) + 1
+		}
+		assert.InDelta(t, ownership[node], size/hashRing.keyspace(), 1e-9, node)
+		covered += size
+
+		for i := 0; i < 300; i++ {
+			key := strconv.Itoa(i)
+			owner, _ := hashRing.GetNode(key)
+			assert.Equal(t, owner == node, inRanges(ranges, hashRing.GenKey64(key)), key)
+		}
+	}
+	assert.Equal(t, hashRing.keyspace(), covered)
+
+	// The owner of the wrap-around arc gets both ends.
+	wrap := hashRing.ownerOf(0)
+	ranges := hashRing.KeyRangesForNode(wrap)
+	assert.Equal(t, HashKey64(0), ranges[0].Start)
+	assert.Equal(t, hashRing.maxHash(), ranges[len(ranges)-1].End)
+
+	assert.Empty(t, hashRing.KeyRangesForNode("unknown"))
+	assert.Empty(t, New(nil).KeyRangesForNode("a"))
+}
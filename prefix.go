@@ -0,0 +1,80 @@
+package hashring
+
+import "sort"
+
+// FixedNode is a NodeLocator placing every key on one node.
+type FixedNode string
+
+// GetNode implements NodeLocator.
+func (n FixedNode) GetNode(stringKey string) (node string, ok bool) {
+	return string(n), n != ""
+}
+
+// PrefixRouter places keys by the longest prefix they match in a routing
+// table, and keys matching none by a fallback, usually a HashRing. It lets a
+// few special tenants be placed explicitly, on a node or a sub-ring of their
+// own, while every other key stays hashed:
+//
+//	router := hashring.NewPrefixRouter(ring, map[string]hashring.NodeLocator{
+//		"tenant-big/":  hashring.FixedNode("db-7"),
+//		"tenant-huge/": hashring.New([]string{"db-8", "db-9"}),
+//	})
+//
+// A PrefixRouter does not change once created and is safe for concurrent use
+// as long as its locators are.
+type PrefixRouter struct {
+	fallback NodeLocator
+	routes   map[string]NodeLocator
+	lengths  []int // distinct prefix lengths, longest first.
+}
+
+// NewPrefixRouter creates a PrefixRouter with routes from prefixes to
+// locators, falling back to fallback.
+func NewPrefixRouter(fallback NodeLocator, routes map[string]NodeLocator) *PrefixRouter {
+	r := &PrefixRouter{fallback: fallback, routes: make(map[string]NodeLocator, len(routes))}
+	seen := make(map[int]bool)
+	for prefix, locator := range routes {
+		r.routes[prefix] = locator
+		if !seen[len(prefix)] {
+			seen[len(prefix)] = true
+			r.lengths = append(r.lengths, len(prefix))
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(r.lengths)))
+	return r
+}
+
+// Match returns the longest prefix of stringKey in the routing table.
+func (r *PrefixRouter) Match(stringKey string) (prefix string, ok bool) {
+	for _, n := range r.lengths {
+		if n > len(stringKey) {
+			continue
+		}
+		if _, ok := r.routes[stringKey[:n]]; ok {
+			return stringKey[:n], true
+		}
+	}
+	return "", false
+}
+
+// GetNode implements NodeLocator. A key matching a prefix is placed by its
+// route only, even if the route finds no node.
+func (r *PrefixRouter) GetNode(stringKey string) (node string, ok bool) {
+	if prefix, ok := r.Match(stringKey); ok {
+		return r.routes[prefix].GetNode(stringKey)
+	}
+	if r.fallback == nil {
+		return "", false
+	}
+	return r.fallback.GetNode(stringKey)
+}
+
+// Prefixes returns the prefixes of the routing table, sorted.
+func (r *PrefixRouter) Prefixes() []string {
+	prefixes := make([]string, 0, len(r.routes))
+	for prefix := range r.routes {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	return prefixes
+}
@@ -0,0 +1,47 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefixRouter(t *testing.T) {
+	ring := New([]string{"a", "b", "c"})
+	sub := New([]string{"x", "y"})
+	router := NewPrefixRouter(ring, map[string]NodeLocator{
+		"tenant-1/":     FixedNode("d"),
+		"tenant-1/big/": sub,
+		"tenant-2/":     FixedNode(""),
+	})
+	assert.Equal(t, []string{"tenant-1/", "tenant-1/big/", "tenant-2/"}, router.Prefixes())
+
+	node, ok := router.GetNode("tenant-1/object")
+	assert.True(t, ok)
+	assert.Equal(t, "d", node)
+
+	for i := 0; i < 100; i++ {
+		key := "tenant-1/big/" + strconv.Itoa(i)
+		prefix, _ := router.Match(key)
+		assert.Equal(t, "tenant-1/big/", prefix)
+		expected, _ := sub.GetNode(key)
+		node, ok := router.GetNode(key)
+		assert.True(t, ok)
+		assert.Equal(t, expected, node)
+
+		key = "tenant-3/" + strconv.Itoa(i)
+		expected, _ = ring.GetNode(key)
+		node, _ = router.GetNode(key)
+		assert.Equal(t, expected, node)
+	}
+
+	// A matched route does not fall back.
+	_, ok = router.GetNode("tenant-2/object")
+	assert.False(t, ok)
+	_, ok = router.Match("tenant")
+	assert.False(t, ok)
+
+	_, ok = NewPrefixRouter(nil, nil).GetNode("test")
+	assert.False(t, ok)
+}
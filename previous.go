@@ -0,0 +1,100 @@
+package hashring
+
+// Previous returns the ring as it was before the last change, nil for a ring
+// that was created rather than derived by AddNode, RemoveNode,
+// UpdateWeightedNode or UpdateWithWeights.
+//
+// Only one level is kept: the previous ring has no Previous itself.
+func (h *HashRing) Previous() *HashRing {
+	if h == nil {
+		return nil
+	}
+	return h.prev
+}
+
+// derive records h as the previous state of next, a ring derived from h,
+// and when it changed, carries over the tombstones that still apply, and reports the ownership
+// lost and any drift, see WithOnOwnershipLoss and WithConsistencyCheck. It
+// returns next.
+func (h *HashRing) derive(next *HashRing) *HashRing {
+	next.prev = h.retained()
+	next.changed = h.config.clockOrSystem().Now()
+	next.tombstones = h.liveTombstones(next)
+	h.reportLosses(next)
+	next.checkDrift()
+	return next
+}
+
+// retained returns a copy of h to keep as the previous state of a derived ring.
+// The maps are shared, they are never modified once the ring is built.
+func (h *HashRing) retained() *HashRing {
+	prev := *h
+	prev.prev = nil
+	prev.tombstones = nil
+	return &prev
+}
+
+// ReplicaDivergenceCandidates returns the nodes that may hold a diverging copy
+// of stringKey after the last change: its size nodes on the ring, see
+// GetNodes, followed by those it had on the previous ring. Storage layers
+// reconcile these nodes after a reshard.
+//
+// Either ring may have fewer than size nodes, in which case all of its nodes
+// are used.
+func (h *HashRing) ReplicaDivergenceCandidates(stringKey string, size int) []string {
+	seen := make(map[string]bool)
+	candidates := make([]string, 0, size)
+	for _, ring := range []*HashRing{h, h.Previous()} {
+		for _, node := range ring.replicaSet(stringKey, size) {
+			if !seen[node] {
+				seen[node] = true
+				candidates = append(candidates, node)
+			}
+		}
+	}
+	return candidates
+}
+
+// replicaSet returns up to size nodes of stringKey, without reporting a lookup.
+func (h *HashRing) replicaSet(stringKey string, size int) []string {
+	pos, ok := h.GetNodePos(stringKey)
+	if !ok || size <= 0 {
+		return nil
+	}
+	if unique := len(sortedNodes(h.nodes)); size > unique {
+		size = unique
+	}
+	nodes, _ := h.walk(pos, size)
+	return nodes
+}
+
+// GetPreviousOwner returns the node stringKey belonged to before the last
+// change, so that a cache can fall back to reading from it on a miss right
+// after a reshard.
+//
+// A key moved by the last change returns its former node. Otherwise, a
+// soft-removed node that owned it is returned, see RemoveNodeSoft, and then
+// its unchanged node. Nodes removed with RemoveNode are not returned, neither
+// are soft-removed ones past their grace period. ok is false if there is no
+// such node, e.g. for a ring that was created rather than derived.
+func (h *HashRing) GetPreviousOwner(stringKey string) (node string, ok bool) {
+	if h == nil {
+		return "", false
+	}
+	key := h.GenKey64(stringKey)
+
+	previous := ""
+	if h.prev != nil {
+		previous = h.prev.ownerOf(key)
+		if _, member := h.weights[previous]; !member && !h.tombstoned(previous) {
+			previous = ""
+		}
+	}
+	if previous != "" && previous != h.ownerOf(key) {
+		return previous, true
+	}
+	if node := h.tombstoneOwner(key); node != "" {
+		return node, true
+	}
+	return previous, previous != ""
+}
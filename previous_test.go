@@ -0,0 +1,93 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrevious(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"})
+	assert.Nil(t, hashRing.Previous())
+
+	added := hashRing.AddNode("d")
+	assert.Equal(t, hashRing.sortedKeys, added.Previous().sortedKeys)
+	assert.Equal(t, pointsOf(hashRing), pointsOf(added.Previous()))
+
+	// Only one level is kept.
+	removed := added.RemoveNode("a")
+	assert.Equal(t, added.sortedKeys, removed.Previous().sortedKeys)
+	assert.Nil(t, removed.Previous().Previous())
+
+	updated := removed.UpdateWeightedNode("b", 3)
+	assert.Equal(t, pointsOf(removed), pointsOf(updated.Previous()))
+
+	before := updated.sortedKeys
+	updated.UpdateWithWeights(map[string]int{"b": 1, "c": 1})
+	assert.Equal(t, before, updated.Previous().sortedKeys)
+
+	// A change that is a no-op returns the same ring.
+	assert.Equal(t, hashRing, hashRing.RemoveNode("e"))
+	assert.Nil(t, (*HashRing)(nil).Previous())
+}
+
+func TestReplicaDivergenceCandidates(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c", "d"})
+	added := hashRing.AddNode("e")
+
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		current, _ := added.GetNodes(key, 2)
+		previous, _ := hashRing.GetNodes(key, 2)
+
+		candidates := added.ReplicaDivergenceCandidates(key, 2)
+		assert.Equal(t, current, candidates[:2])
+		assert.Subset(t, candidates, previous)
+		assert.True(t, len(candidates) <= 4)
+	}
+
+	// Without a previous ring, only the current nodes are candidates.
+	nodes, _ := hashRing.GetNodes("test", 2)
+	assert.Equal(t, nodes, hashRing.ReplicaDivergenceCandidates("test", 2))
+
+	// Sizes beyond a ring's nodes use all of them.
+	grown := New([]string{"a"}).AddNode("b")
+	assert.ElementsMatch(t, []string{"a", "b"}, grown.ReplicaDivergenceCandidates("test", 3))
+	assert.Empty(t, New([]string{}).ReplicaDivergenceCandidates("test", 1))
+}
+
+func TestGetPreviousOwner(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"})
+	_, ok := hashRing.GetPreviousOwner("test")
+	assert.False(t, ok)
+
+	added := hashRing.AddNode("d")
+	moved := 0
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		expected, _ := hashRing.GetNode(key)
+		previous, ok := added.GetPreviousOwner(key)
+		assert.True(t, ok)
+		assert.Equal(t, expected, previous)
+		if current, _ := added.GetNode(key); current != previous {
+			assert.Equal(t, "d", current)
+			moved++
+		}
+	}
+	assert.True(t, moved > 0)
+
+	// A node removed for good is not a previous owner.
+	removed := hashRing.RemoveNode("b")
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		previous, ok := removed.GetPreviousOwner(key)
+		if owner, _ := hashRing.GetNode(key); owner == "b" {
+			assert.False(t, ok)
+			continue
+		}
+		current, _ := removed.GetNode(key)
+		assert.Equal(t, current, previous)
+		assert.True(t, ok)
+	}
+}
@@ -0,0 +1,73 @@
+package hashring
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// QuorumError is returned by DoQuorum when fewer than the required nodes
+// acknowledged.
+type QuorumError struct {
+	Acks, Needed int
+	// Errors holds the error of every node that failed.
+	Errors map[string]error
+}
+
+func (e *QuorumError) Error() string {
+	nodes := make([]string, 0, len(e.Errors))
+	for node := range e.Errors {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	failures := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		failures = append(failures, node+": "+e.Errors[node].Error())
+	}
+	return fmt.Sprintf("hashring: quorum not reached, %d of %d acks (%s)", e.Acks, e.Needed, strings.Join(failures, "; "))
+}
+
+// DoQuorum calls f concurrently for each of the n nodes of stringKey, see
+// GetNodes, and returns nil as soon as w of them returned nil.
+//
+// Once the quorum is reached or can no longer be reached, DoQuorum returns
+// without waiting for the remaining calls, which keep running. If the quorum
+// cannot be reached, the error is a *QuorumError with the failures so far.
+func (h *HashRing) DoQuorum(stringKey string, n, w int, f func(node string) error) error {
+	if w <= 0 || w > n {
+		return fmt.Errorf("hashring: quorum of %d out of %d nodes", w, n)
+	}
+	nodes, ok := h.GetNodes(stringKey, n)
+	if !ok {
+		return h.notEnoughNodes(n)
+	}
+
+	type result struct {
+		node string
+		err  error
+	}
+	results := make(chan result, len(nodes))
+	for _, node := range nodes {
+		go func(node string) {
+			results <- result{node, f(node)}
+		}(node)
+	}
+
+	qerr := &QuorumError{Needed: w, Errors: make(map[string]error)}
+	for range nodes {
+		r := <-results
+		if r.err != nil {
+			qerr.Errors[r.node] = r.err
+		} else {
+			qerr.Acks++
+		}
+		if qerr.Acks >= w {
+			return nil
+		}
+		if len(qerr.Errors) > n-w {
+			return qerr
+		}
+	}
+	return qerr
+}
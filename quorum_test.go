@@ -0,0 +1,81 @@
+package hashring
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoQuorum(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c", "d"})
+	replicas, _ := hashRing.GetNodes("test", 3)
+
+	var mu sync.Mutex
+	called := make(map[string]bool)
+	err := hashRing.DoQuorum("test", 3, 3, func(node string) error {
+		mu.Lock()
+		called[node] = true
+		mu.Unlock()
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, called, 3)
+	for _, node := range replicas {
+		assert.True(t, called[node])
+	}
+
+	// One failure still meets a quorum of 2.
+	err = hashRing.DoQuorum("test", 3, 2, func(node string) error {
+		if node == replicas[0] {
+			return errors.New("down")
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestDoQuorumFailure(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c", "d"})
+	replicas, _ := hashRing.GetNodes("test", 3)
+
+	down := errors.New("down")
+	err := hashRing.DoQuorum("test", 3, 2, func(node string) error {
+		if node != replicas[2] {
+			return down
+		}
+		return nil
+	})
+	var qerr *QuorumError
+	assert.True(t, errors.As(err, &qerr))
+	assert.Equal(t, 2, qerr.Needed)
+	assert.True(t, qerr.Acks <= 1)
+	assert.Len(t, qerr.Errors, 2)
+	assert.Equal(t, down, qerr.Errors[replicas[0]])
+	assert.Contains(t, err.Error(), replicas[0]+": down")
+}
+
+func TestDoQuorumReturnsEarly(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"})
+	replicas, _ := hashRing.GetNodes("test", 3)
+
+	release := make(chan struct{})
+	defer close(release)
+	err := hashRing.DoQuorum("test", 3, 2, func(node string) error {
+		if node == replicas[2] {
+			<-release
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestDoQuorumInvalid(t *testing.T) {
+	hashRing := New([]string{"a", "b"})
+	noop := func(string) error { return nil }
+	assert.Error(t, hashRing.DoQuorum("test", 2, 3, noop))
+	assert.Error(t, hashRing.DoQuorum("test", 2, 0, noop))
+	assert.Error(t, hashRing.DoQuorum("test", 3, 2, noop))
+	assert.Error(t, New([]string{}).DoQuorum("test", 1, 1, noop))
+}
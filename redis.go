@@ -0,0 +1,51 @@
+package hashring
+
+// RedisConsistentHash adapts HashRing to the ConsistentHash interface of
+// go-redis's Ring, so its shards are placed by this package:
+//
+//	ring := redis.NewRing(&redis.RingOptions{
+//		Addrs: map[string]string{"shard1": ":7000", "shard2": ":7001"},
+//		NewConsistentHash: func(shards []string) redis.ConsistentHash {
+//			return hashring.NewRedisConsistentHash(shards, map[string]int{"shard1": 2})
+//		},
+//	})
+type RedisConsistentHash struct {
+	ring *HashRing
+}
+
+// NewRedisConsistentHash creates a RedisConsistentHash over shards.
+// weights gives the weight of shards, shards missing from it get 1. It
+// panics given limits, see NewRedisConsistentHashChecked.
+func NewRedisConsistentHash(shards []string, weights map[string]int, opts ...Option) *RedisConsistentHash {
+	return &RedisConsistentHash{ring: NewWithWeights(shardWeights(shards, weights), opts...)}
+}
+
+// NewRedisConsistentHashChecked creates a RedisConsistentHash like
+// NewRedisConsistentHash, or returns a LimitError.
+func NewRedisConsistentHashChecked(shards []string, weights map[string]int, opts ...Option) (*RedisConsistentHash, error) {
+	ring, err := NewWithWeightsChecked(shardWeights(shards, weights), opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisConsistentHash{ring: ring}, nil
+}
+
+// shardWeights returns the weight of each of shards, 1 unless weights gives
+// a positive one.
+func shardWeights(shards []string, weights map[string]int) map[string]int {
+	shardWeights := make(map[string]int, len(shards))
+	for _, shard := range shards {
+		weight, ok := weights[shard]
+		if !ok || weight <= 0 {
+			weight = 1
+		}
+		shardWeights[shard] = weight
+	}
+	return shardWeights
+}
+
+// Get returns the shard key belongs to, or "" if there are no shards.
+func (r *RedisConsistentHash) Get(key string) string {
+	shard, _ := r.ring.GetNode(key)
+	return shard
+}
@@ -0,0 +1,32 @@
+package hashring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// redisConsistentHash mirrors go-redis's ConsistentHash interface.
+type redisConsistentHash interface {
+	Get(string) string
+}
+
+func TestRedisConsistentHash(t *testing.T) {
+	var newConsistentHash func(shards []string) redisConsistentHash = func(shards []string) redisConsistentHash {
+		return NewRedisConsistentHash(shards, map[string]int{"b": 2})
+	}
+	hash := newConsistentHash([]string{"a", "b", "c"})
+
+	// Same placement as TestNewWeighted.
+	assert.Equal(t, "b", hash.Get("test"))
+	assert.Equal(t, "c", hash.Get("test3"))
+	assert.Equal(t, "a", hash.Get("bbbb"))
+}
+
+func TestRedisConsistentHashIgnoresUnknownShards(t *testing.T) {
+	hash := NewRedisConsistentHash([]string{"a", "b", "c"}, map[string]int{"d": 5, "a": 0})
+	assert.Equal(t, map[string]int{"a": 1, "b": 1, "c": 1}, hash.ring.weights)
+	assert.Equal(t, "a", hash.Get("test"))
+
+	assert.Equal(t, "", NewRedisConsistentHash(nil, nil).Get("test"))
+}
@@ -0,0 +1,151 @@
+package hashring
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// registry holds the rings of Register by name.
+var registry sync.Map // name to *registrySlot.
+
+// registrySlot is the ring registered under a name. Gets load ring without
+// locking; changes lock mu.
+type registrySlot struct {
+	ring atomic.Pointer[HashRing]
+
+	mu     sync.Mutex
+	frozen bool
+	queued []func(ring *HashRing) *HashRing // changes made while frozen, in order.
+}
+
+func registered(name string) *registrySlot {
+	slot, _ := registry.LoadOrStore(name, new(registrySlot))
+	return slot.(*registrySlot)
+}
+
+// lookup returns the slot of name, and false if name was never registered or
+// frozen.
+func lookup(name string) (*registrySlot, bool) {
+	slot, ok := registry.Load(name)
+	if !ok {
+		return nil, false
+	}
+	return slot.(*registrySlot), true
+}
+
+// Register makes ring the ring named name in the package registry, replacing
+// any ring registered under name, so libraries can resolve a shared ring by
+// name, see Get, instead of having it passed down. Replacing a ring is
+// atomic: concurrent Gets see either the old or the new ring.
+//
+// The registry is optional and global to the process; rings need not be
+// registered. A registered ring is shared by every caller of Get, so it must
+// only be changed through Update, by methods that derive a new ring, e.g.
+// AddNode or ApplyWeights; UpdateWithWeights, UpdateWithWeightsContext and
+// UpdateWithFloatWeights change the ring in place, racing with lookups of
+// other callers and bypassing Freeze.
+func Register(name string, ring *HashRing) {
+	Update(name, func(*HashRing) *HashRing { return ring })
+}
+
+// Get returns the ring registered under name, and false if none is. The ring
+// is shared; see Register for how it may be changed.
+//
+// Get never waits: an Update whose fn fetches a remote topology, or rebuilds
+// a large ring, holds back other changes of name but not Gets, which keep
+// returning the ring as of before it. Lookups on the ring are in-memory reads
+// too, so registered rings need no context-aware lookups.
+func Get(name string) (*HashRing, bool) {
+	slot, ok := lookup(name)
+	if !ok {
+		return nil, false
+	}
+	ring := slot.ring.Load()
+	return ring, ring != nil
+}
+
+// Update replaces the ring registered under name by fn of it, nil if none
+// is, and returns the new ring. fn should only derive the ring, e.g.
+//
+//	hashring.Update("cache", func(ring *hashring.HashRing) *hashring.HashRing {
+//		return ring.AddNode("10.0.0.5:11211")
+//	})
+//
+// While name is frozen, see Freeze, fn is queued instead and the current ring
+// is returned.
+func Update(name string, fn func(ring *HashRing) *HashRing) *HashRing {
+	slot := registered(name)
+	slot.mu.Lock()
+	defer slot.mu.Unlock()
+	if slot.frozen {
+		slot.queued = append(slot.queued, fn)
+		return slot.ring.Load()
+	}
+	next := fn(slot.ring.Load())
+	slot.ring.Store(next)
+	return next
+}
+
+// Freeze holds the changes of the ring registered under name, by Register,
+// Update and Unregister, until Unfreeze, e.g. to keep the topology stable
+// during a sale. Gets keep returning the ring as of Freeze. Freezing a frozen
+// name does nothing; freezing a name with no ring holds its Register.
+func Freeze(name string) {
+	slot := registered(name)
+	slot.mu.Lock()
+	slot.frozen = true
+	slot.mu.Unlock()
+}
+
+// Unfreeze applies the changes held since Freeze, in order, and returns the
+// resulting ring. They are applied at once: concurrent Gets see either the
+// frozen ring or the ring after all of them. The rings they derive report to
+// the interceptors of the ring like any change, see WithInterceptors.
+// Unfreezing a name never frozen or registered returns nil.
+func Unfreeze(name string) *HashRing {
+	slot, ok := lookup(name)
+	if !ok {
+		return nil
+	}
+	slot.mu.Lock()
+	defer slot.mu.Unlock()
+	ring := slot.ring.Load()
+	for _, fn := range slot.queued {
+		ring = fn(ring)
+	}
+	slot.frozen, slot.queued = false, nil
+	slot.ring.Store(ring)
+	return ring
+}
+
+// Frozen reports whether name is frozen, and the number of changes held.
+func Frozen(name string) (frozen bool, queued int) {
+	slot, ok := lookup(name)
+	if !ok {
+		return false, 0
+	}
+	slot.mu.Lock()
+	defer slot.mu.Unlock()
+	return slot.frozen, len(slot.queued)
+}
+
+// Unregister removes the ring registered under name.
+func Unregister(name string) {
+	if _, ok := lookup(name); ok {
+		Update(name, func(*HashRing) *HashRing { return nil })
+	}
+}
+
+// Registered returns the names of the registered rings, sorted.
+func Registered() []string {
+	var names []string
+	registry.Range(func(name, slot any) bool {
+		if slot.(*registrySlot).ring.Load() != nil {
+			names = append(names, name.(string))
+		}
+		return true
+	})
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,126 @@
+package hashring
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	defer Unregister("test-cache")
+	defer Unregister("test-sessions")
+
+	_, ok := Get("test-cache")
+	assert.False(t, ok)
+
+	cache := New([]string{"a", "b"})
+	Register("test-cache", cache)
+	Register("test-sessions", New([]string{"c"}))
+	ring, ok := Get("test-cache")
+	assert.True(t, ok)
+	assert.Equal(t, cache, ring)
+	assert.Subset(t, Registered(), []string{"test-cache", "test-sessions"})
+
+	replaced := cache.AddNode("c")
+	Register("test-cache", replaced)
+	ring, _ = Get("test-cache")
+	assert.Equal(t, replaced, ring)
+
+	Unregister("test-sessions")
+	_, ok = Get("test-sessions")
+	assert.False(t, ok)
+	assert.NotContains(t, Registered(), "test-sessions")
+}
+
+func TestRegistryUpdate(t *testing.T) {
+	defer Unregister("test-update")
+
+	// Concurrent updates are all applied.
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			Update("test-update", func(ring *HashRing) *HashRing {
+				return ring.AddNode(strconv.Itoa(i))
+			})
+		}(i)
+	}
+	wg.Wait()
+	ring, ok := Get("test-update")
+	assert.True(t, ok)
+	assert.Equal(t, 20, ring.Size())
+}
+
+func TestRegistryFreeze(t *testing.T) {
+	defer Unregister("test-frozen")
+
+	rebuilds := 0
+	ring := New([]string{"a", "b"}, WithInterceptors(Interceptor{
+		OnRebuild: func(RebuildEvent) { rebuilds++ },
+	}))
+	Register("test-frozen", ring)
+	Freeze("test-frozen")
+	Freeze("test-frozen")
+	rebuilds = 0
+	assert.Equal(t, ring, Update("test-frozen", func(ring *HashRing) *HashRing {
+		return ring.AddNode("c")
+	}))
+	Update("test-frozen", func(ring *HashRing) *HashRing {
+		return ring.RemoveNode("a")
+	})
+	frozen, queued := Frozen("test-frozen")
+	assert.True(t, frozen)
+	assert.Equal(t, 2, queued)
+	got, _ := Get("test-frozen")
+	assert.Equal(t, ring, got, "changes are held while frozen")
+	assert.Zero(t, rebuilds)
+
+	unfrozen := Unfreeze("test-frozen")
+	assert.Equal(t, []string{"b", "c"}, sortedNodes(unfrozen.nodes), "held changes apply in order")
+	assert.Equal(t, 2, rebuilds)
+	got, _ = Get("test-frozen")
+	assert.Equal(t, unfrozen, got)
+	frozen, queued = Frozen("test-frozen")
+	assert.False(t, frozen)
+	assert.Zero(t, queued)
+
+	// Unregistering is held too.
+	Freeze("test-frozen")
+	Unregister("test-frozen")
+	_, ok := Get("test-frozen")
+	assert.True(t, ok)
+	assert.Nil(t, Unfreeze("test-frozen"))
+	_, ok = Get("test-frozen")
+	assert.False(t, ok)
+}
+
+func TestRegistryUnknownNames(t *testing.T) {
+	frozen, queued := Frozen("test-unknown")
+	assert.False(t, frozen)
+	assert.Zero(t, queued)
+	assert.Nil(t, Unfreeze("test-unknown"))
+	Unregister("test-unknown")
+	_, ok := registry.Load("test-unknown")
+	assert.False(t, ok, "read-only calls allocate no slot")
+}
+
+func TestRegistryGetDuringUpdate(t *testing.T) {
+	defer Unregister("test-slow")
+
+	ring := New([]string{"a"})
+	Register("test-slow", ring)
+	fetching, fetched := make(chan struct{}), make(chan struct{})
+	go Update("test-slow", func(ring *HashRing) *HashRing {
+		close(fetching)
+		<-fetched // e.g. a remote topology fetch.
+		return ring.AddNode("b")
+	})
+	<-fetching
+	got, ok := Get("test-slow")
+	assert.True(t, ok)
+	assert.Same(t, ring, got, "Gets do not wait for an Update")
+	close(fetched)
+}
@@ -0,0 +1,103 @@
+package hashring
+
+// RenameNode gives node old the name new, and returns the new HashRing. The
+// points of old stay where they are under new, so new owns exactly the
+// keyspace of old, e.g. when a host is replaced by another with the same
+// data. Removing old and adding new would move all of old's keys instead.
+//
+// The labels and tenant weights of old, see WithNodeLabels and
+// WithTenantWeights, are new's, and new keeps the tenant keys of old. new
+// keeps placing its virtual nodes as old did when its weight changes.
+// Adding a node named old again while new is on the ring places both on the
+// same points, so rename new back instead.
+//
+// The ring is left as is if old is not on it or new already is.
+func (h *HashRing) RenameNode(old, new string) *HashRing {
+	h = h.orEmpty()
+	if _, ok := h.weights[old]; !ok {
+		return h
+	}
+	if _, ok := h.weights[new]; ok {
+		return h
+	}
+
+	next := &HashRing{
+		nodes:      make([]string, len(h.nodes)),
+		weights:    make(map[string]int, len(h.weights)),
+		config:     h.config,
+		replicas:   h.replicas,
+		factors:    make(map[string]int, len(h.factors)),
+		sortedKeys: h.sortedKeys,
+		owners:     h.owners,
+		names:      make([]string, len(h.names)),
+		skips:      h.skips,
+		aliases:    make(map[string]string, len(h.aliases)+1),
+		collisions: h.collisions,
+	}
+	rename := func(node string) string {
+		if node == old {
+			return new
+		}
+		return node
+	}
+	for i, node := range h.nodes {
+		next.nodes[i] = rename(node)
+	}
+	for node, weight := range h.weights {
+		next.weights[rename(node)] = weight
+	}
+	for node, factor := range h.factors {
+		next.factors[rename(node)] = factor
+	}
+	for i, node := range h.names {
+		next.names[i] = rename(node)
+	}
+	for node, identity := range h.aliases {
+		if node != old {
+			next.aliases[node] = identity
+		}
+	}
+	if identity := h.identity(old); identity != new {
+		next.aliases[new] = identity
+	}
+	if _, ok := h.config.labels[old]; ok {
+		next.config.labels = make(map[string]Labels, len(h.config.labels))
+		for node, l := range h.config.labels {
+			next.config.labels[rename(node)] = l
+		}
+	}
+	if len(h.config.tenants) > 0 {
+		next.config.tenants = make(map[string]map[string]int, len(h.config.tenants))
+		for tenant, weights := range h.config.tenants {
+			renamed := make(map[string]int, len(weights))
+			for node, weight := range weights {
+				renamed[rename(node)] = weight
+			}
+			next.config.tenants[tenant] = renamed
+		}
+	}
+	return h.derive(next)
+}
+
+// identity returns the name node places its virtual nodes by, its own unless
+// it was renamed, see RenameNode.
+func (h *HashRing) identity(node string) string {
+	if identity, ok := h.aliases[node]; ok {
+		return identity
+	}
+	return node
+}
+
+// aliasesOf returns the aliases of h that apply to nodes.
+func (h *HashRing) aliasesOf(nodes []string) map[string]string {
+	if len(h.aliases) == 0 {
+		return nil
+	}
+	aliases := make(map[string]string)
+	for _, node := range nodes {
+		if identity, ok := h.aliases[node]; ok {
+			aliases[node] = identity
+		}
+	}
+	return aliases
+}
@@ -0,0 +1,93 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenameNode(t *testing.T) {
+	hashRing := NewWithWeights(map[string]int{"a": 1, "b": 2, "c": 1})
+	renamed := hashRing.RenameNode("b", "d")
+	assert.Equal(t, map[string]int{"a": 1, "c": 1, "d": 2}, renamed.weights)
+
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		before, _ := hashRing.GetNode(key)
+		after, _ := renamed.GetNode(key)
+		if before == "b" {
+			before = "d"
+		}
+		assert.Equal(t, before, after, key)
+
+		beforeNodes, _ := hashRing.GetNodes(key, 2)
+		afterNodes, _ := renamed.GetNodes(key, 2)
+		for j := range beforeNodes {
+			if beforeNodes[j] == "b" {
+				beforeNodes[j] = "d"
+			}
+		}
+		assert.Equal(t, beforeNodes, afterNodes, key)
+	}
+
+	// The renamed node keeps placing its virtual nodes as b.
+	expectSameCircle(t, renamed.UpdateWeightedNode("d", 3).RenameNode("d", "b"), hashRing.UpdateWeightedNode("b", 3))
+	expectSameCircle(t, renamed.AddNode("e").RenameNode("d", "b"), hashRing.AddNode("e"))
+
+	updated := &HashRing{}
+	*updated = *renamed
+	updated.UpdateWithWeights(map[string]int{"a": 1, "c": 1, "d": 4})
+	expectSameCircle(t, updated.RenameNode("d", "b"), hashRing.UpdateWeightedNode("b", 4))
+
+	// Renaming back drops the alias.
+	assert.Empty(t, renamed.RenameNode("d", "b").aliases)
+	assert.Equal(t, hashRing.Audit(), renamed.RenameNode("d", "b").Audit())
+	// A removed node's alias does not apply to a node added under its name.
+	expectSameCircle(t, renamed.RemoveNode("d").AddWeightedNode("d", 2), NewWithWeights(map[string]int{"a": 1, "c": 1, "d": 2}))
+
+	assert.Same(t, hashRing, hashRing.RenameNode("x", "y"))
+	assert.Same(t, hashRing, hashRing.RenameNode("a", "c"))
+	assert.Equal(t, hashRing, renamed.Previous())
+}
+
+func TestRenameNodeAudit(t *testing.T) {
+	hashRing := New([]string{"a", "b"})
+	renamed := hashRing.RenameNode("a", "z")
+	assert.Equal(t, "a", renamed.Audit().Nodes[1].PlacedAs)
+	assert.Contains(t, renamed.Audit().String(), "node \"z\" 1 40 as \"a\"\n")
+	assert.NotEqual(t, New([]string{"b", "z"}).Audit().Fingerprint(), renamed.Audit().Fingerprint())
+}
+
+func TestRenameNodeLabelsAndTenants(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"},
+		WithNodeLabels(map[string]Labels{"a": {ZoneLabel: "z1"}, "b": {ZoneLabel: "z2"}}),
+		WithTenantWeights("x", map[string]int{"a": 2, "b": 1, "c": 1}))
+	renamed := hashRing.RenameNode("a", "z")
+	assert.Equal(t, Labels{ZoneLabel: "z1"}, renamed.nodeLabels("z"))
+	assert.Empty(t, renamed.nodeLabels("a"))
+	assert.Equal(t, Labels{ZoneLabel: "z1"}, hashRing.nodeLabels("a"), "the old ring keeps its labels")
+	assert.Equal(t, map[string]int{"z": 2, "b": 1, "c": 1}, renamed.TenantWeights("x"))
+
+	rename := func(node string) string {
+		if node == "a" {
+			return "z"
+		}
+		return node
+	}
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		before, _ := hashRing.GetNodeForTenant("x", key)
+		after, _ := renamed.GetNodeForTenant("x", key)
+		assert.Equal(t, rename(before), after, key)
+
+		before, _ = hashRing.GetNodeFromWeighted(key, []string{"a", "b"})
+		after, _ = renamed.GetNodeFromWeighted(key, []string{"z", "b"})
+		assert.Equal(t, rename(before), after, key)
+
+		zones, _ := hashRing.GetNodesInZones(key, []string{"z1", "z2"})
+		renamedZones, _ := renamed.GetNodesInZones(key, []string{"z1", "z2"})
+		assert.Equal(t, []string{"z", "b"}, renamedZones, key)
+		assert.Equal(t, []string{"a", "b"}, zones, key)
+	}
+}
@@ -0,0 +1,195 @@
+package hashring
+
+import (
+	"math"
+	"sort"
+)
+
+// Rendezvous maps keys to nodes by weighted rendezvous, or
+// highest-random-weight, hashing: each key goes to the node scoring highest
+// for it. It has the Get, Add and Remove methods of HashRing and implements
+// NodeLocator.
+//
+// Without virtual nodes, a change moves exactly the keys it must, and
+// memory is a map of weights, at the cost of scoring every node on lookup.
+// It suits small numbers of nodes, HashRing large ones.
+//
+// Keys are hashed as by a HashRing of the same options, e.g. WithHasher;
+// options about virtual nodes have no effect. A Rendezvous is never changed
+// in place: AddNode and the other changes return a new one.
+type Rendezvous struct {
+	nodes   []string // sorted.
+	weights map[string]int
+	hash    *HashRing // hashes keys, without nodes.
+}
+
+// NewRendezvous creates a Rendezvous of nodes, each of weight 1.
+func NewRendezvous(nodes []string, opts ...Option) *Rendezvous {
+	weights := make(map[string]int, len(nodes))
+	for _, node := range nodes {
+		weights[node] = 1
+	}
+	return NewRendezvousWithWeights(weights, opts...)
+}
+
+// NewRendezvousWithWeights creates a Rendezvous according to weights map.
+// Nodes of weight 0 are standbys, see HashRing.AddWeightedNode.
+func NewRendezvousWithWeights(weights map[string]int, opts ...Option) *Rendezvous {
+	own := make(map[string]int, len(weights))
+	for node, weight := range weights {
+		if weight >= 0 {
+			own[node] = weight
+		}
+	}
+	nodes := nodesOf(own)
+	sort.Strings(nodes)
+	return &Rendezvous{nodes: nodes, weights: own, hash: &HashRing{config: newConfig(opts)}}
+}
+
+// with returns a copy of r with weights.
+func (r *Rendezvous) with(weights map[string]int) *Rendezvous {
+	nodes := nodesOf(weights)
+	sort.Strings(nodes)
+	return &Rendezvous{nodes: nodes, weights: weights, hash: r.hash}
+}
+
+// orEmpty returns r, or an empty Rendezvous if r is nil.
+func (r *Rendezvous) orEmpty() *Rendezvous {
+	if r == nil {
+		return NewRendezvous(nil)
+	}
+	return r
+}
+
+// Size returns the number of nodes of r, standbys included.
+func (r *Rendezvous) Size() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.nodes)
+}
+
+// Nodes returns the nodes of r, sorted.
+func (r *Rendezvous) Nodes() []string {
+	r = r.orEmpty()
+	return append([]string{}, r.nodes...)
+}
+
+// AddNode adds node with weight 1, and returns the new Rendezvous.
+func (r *Rendezvous) AddNode(node string) *Rendezvous {
+	return r.AddWeightedNode(node, 1)
+}
+
+// AddWeightedNode adds node with weight, and returns the new Rendezvous. Only
+// keys moving to node move. r is left as is if node is already on it or
+// weight is negative.
+func (r *Rendezvous) AddWeightedNode(node string, weight int) *Rendezvous {
+	r = r.orEmpty()
+	if weights, ok := addWeight(r.weights, node, weight); ok {
+		return r.with(weights)
+	}
+	return r
+}
+
+// UpdateWeightedNode updates node with weight, and returns the new
+// Rendezvous. Only keys moving to or from node move. r is left as is if node
+// is not on it or weight is negative.
+func (r *Rendezvous) UpdateWeightedNode(node string, weight int) *Rendezvous {
+	r = r.orEmpty()
+	if weights, ok := updateWeight(r.weights, node, weight); ok {
+		return r.with(weights)
+	}
+	return r
+}
+
+// RemoveNode removes node, and returns the new Rendezvous. Only the keys of
+// node move.
+func (r *Rendezvous) RemoveNode(node string) *Rendezvous {
+	r = r.orEmpty()
+	if weights, ok := removeWeight(r.weights, node); ok {
+		return r.with(weights)
+	}
+	return r
+}
+
+// GetNode returns the node stringKey belongs to. It implements NodeLocator.
+func (r *Rendezvous) GetNode(stringKey string) (node string, ok bool) {
+	if r == nil {
+		return "", false
+	}
+	best := math.Inf(-1)
+	for _, n := range r.nodes {
+		weight := r.weights[n]
+		if weight == 0 {
+			continue
+		}
+		if score := r.hash.rendezvousScore(stringKey, n, weight); !ok || score > best {
+			node, best, ok = n, score, true
+		}
+	}
+	return node, ok
+}
+
+// GetNodes returns size nodes for stringKey, from the highest score down, so
+// the first is GetNode's.
+func (r *Rendezvous) GetNodes(stringKey string, size int) (nodes []string, ok bool) {
+	if r == nil || size <= 0 {
+		return nil, false
+	}
+	scores := make(map[string]float64, len(r.nodes))
+	for _, n := range r.nodes {
+		if weight := r.weights[n]; weight > 0 {
+			scores[n] = r.hash.rendezvousScore(stringKey, n, weight)
+			nodes = append(nodes, n)
+		}
+	}
+	if size > len(nodes) {
+		return nil, false
+	}
+	sort.SliceStable(nodes, func(i, j int) bool { return scores[nodes[i]] > scores[nodes[j]] })
+	return nodes[:size], true
+}
+
+// addWeight returns a copy of weights with node of weight added, or false if
+// node is in weights or weight is negative. It is AddWeightedNode for the
+// node locators without a ring, such as Rendezvous.
+func addWeight(weights map[string]int, node string, weight int) (map[string]int, bool) {
+	if _, ok := weights[node]; ok || weight < 0 {
+		return nil, false
+	}
+	next := copyWeights(weights)
+	next[node] = weight
+	return next, true
+}
+
+// updateWeight returns a copy of weights with the weight of node changed to
+// weight, or false if node is not in weights, already has weight, or weight
+// is negative.
+func updateWeight(weights map[string]int, node string, weight int) (map[string]int, bool) {
+	if old, ok := weights[node]; !ok || old == weight || weight < 0 {
+		return nil, false
+	}
+	next := copyWeights(weights)
+	next[node] = weight
+	return next, true
+}
+
+// removeWeight returns a copy of weights without node, or false if node is
+// not in weights.
+func removeWeight(weights map[string]int, node string) (map[string]int, bool) {
+	if _, ok := weights[node]; !ok {
+		return nil, false
+	}
+	next := copyWeights(weights)
+	delete(next, node)
+	return next, true
+}
+
+// copyWeights returns a copy of weights.
+func copyWeights(weights map[string]int) map[string]int {
+	next := make(map[string]int, len(weights)+1)
+	for node, weight := range weights {
+		next[node] = weight
+	}
+	return next
+}
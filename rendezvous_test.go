@@ -0,0 +1,80 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRendezvous(t *testing.T) {
+	r := NewRendezvousWithWeights(map[string]int{"a": 1, "b": 1, "c": 2})
+	assert.Equal(t, []string{"a", "b", "c"}, r.Nodes())
+	assert.Equal(t, 3, r.Size())
+
+	counts := map[string]int{}
+	for i := 0; i < 4000; i++ {
+		node, ok := r.GetNode(strconv.Itoa(i))
+		assert.True(t, ok)
+		counts[node]++
+	}
+	assert.InDelta(t, 2000, counts["c"], 150)
+	assert.InDelta(t, 1000, counts["a"], 150)
+
+	nodes, ok := r.GetNodes("test", 3)
+	assert.True(t, ok)
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, nodes)
+	node, _ := r.GetNode("test")
+	assert.Equal(t, node, nodes[0])
+	_, ok = r.GetNodes("test", 4)
+	assert.False(t, ok)
+
+	// Same keys as GetNodeFromWeighted of a ring with the same weights.
+	ring := NewWithWeights(map[string]int{"a": 1, "b": 1, "c": 2})
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		expected, _ := ring.GetNodeFromWeighted(key, []string{"a", "b", "c"})
+		node, _ := r.GetNode(key)
+		assert.Equal(t, expected, node)
+	}
+}
+
+func TestRendezvousMinimalMovement(t *testing.T) {
+	r := NewRendezvous([]string{"a", "b", "c"})
+	changes := map[string]*Rendezvous{
+		"d": r.AddNode("d"),
+		"b": r.UpdateWeightedNode("b", 3),
+		"c": r.RemoveNode("c"),
+	}
+	for changed, next := range changes {
+		for i := 0; i < 1000; i++ {
+			key := strconv.Itoa(i)
+			before, _ := r.GetNode(key)
+			after, _ := next.GetNode(key)
+			if before != after {
+				assert.True(t, before == changed || after == changed, changed)
+			}
+		}
+	}
+
+	// Changes that do nothing leave r as is.
+	assert.Same(t, r, r.AddNode("a"))
+	assert.Same(t, r, r.AddWeightedNode("d", -1))
+	assert.Same(t, r, r.UpdateWeightedNode("d", 1))
+	assert.Same(t, r, r.RemoveNode("d"))
+}
+
+func TestRendezvousEmpty(t *testing.T) {
+	var r *Rendezvous
+	_, ok := r.GetNode("test")
+	assert.False(t, ok)
+	_, ok = NewRendezvous(nil).GetNode("test")
+	assert.False(t, ok)
+	_, ok = NewRendezvousWithWeights(map[string]int{"a": 0}).GetNode("test")
+	assert.False(t, ok)
+
+	r = r.AddNode("a")
+	node, ok := r.GetNode("test")
+	assert.True(t, ok)
+	assert.Equal(t, "a", node)
+}
@@ -0,0 +1,201 @@
+package hashring
+
+import (
+	"context"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RepairTask is the repair of the keys of a range between its owner and
+// replicas, e.g. by comparing Merkle trees of the range on each node.
+type RepairTask struct {
+	OwnedRange
+	// Round counts the passes over the keyspace, from 0.
+	Round int
+}
+
+// RepairFunc repairs the range of task.
+type RepairFunc func(ctx context.Context, task RepairTask) error
+
+// RepairScheduler runs anti-entropy repairs over a ring: every round, it
+// calls Repair once for each range of the ownership table, see
+// OwnershipTable, spreading the tasks over Interval with some jitter, while no
+// node takes part in more than MaxPerNode repairs at once.
+type RepairScheduler struct {
+	// Ring returns the ring to repair, read at the start of each round, e.g.
+	// the Load of an atomic.Pointer updated on changes.
+	Ring   func() *HashRing
+	Repair RepairFunc
+
+	// Replicas is the size of the replica set of a range, owner included.
+	Replicas int
+	// Interval is the length of a round. Its tasks start evenly spread over
+	// it, and the next round starts when it is over, or when all tasks are
+	// done if they take longer. Rounds follow each other at once if zero,
+	// except that a round without tasks, e.g. of an empty ring, is followed
+	// by a wait of a second.
+	Interval time.Duration
+	// Jitter delays each task by a random duration up to Jitter, so the
+	// repairs of several schedulers do not line up.
+	Jitter time.Duration
+	// MaxPerNode is the number of repairs a node takes part in at once, as
+	// owner or replica, 1 if zero.
+	MaxPerNode int
+	// OnError is called with the tasks that failed, which are retried next
+	// round.
+	OnError func(RepairTask, error)
+	// Clock paces the rounds, SystemClock if nil.
+	Clock Clock
+}
+
+// idleRepairWait is the wait after a round without tasks when Interval is
+// zero, so that Run does not spin while the ring is empty.
+const idleRepairWait = time.Second
+
+// Run repairs round after round until ctx is done, and returns ctx.Err().
+func (s *RepairScheduler) Run(ctx context.Context) error {
+	for round := 0; ; round++ {
+		start := s.clock().Now()
+		tasks, err := s.runRound(ctx, round)
+		if err != nil {
+			return err
+		}
+		wait := start.Add(s.Interval).Sub(s.clock().Now())
+		if tasks == 0 && wait < idleRepairWait {
+			wait = idleRepairWait
+		}
+		if wait > 0 {
+			select {
+			case <-s.clock().After(wait):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// RunRound schedules the tasks of one round and waits for them. It returns
+// ctx.Err() if ctx is done first, after waiting for the tasks started.
+func (s *RepairScheduler) RunRound(ctx context.Context, round int) error {
+	_, err := s.runRound(ctx, round)
+	return err
+}
+
+// runRound is RunRound, returning the number of tasks of the round too.
+func (s *RepairScheduler) runRound(ctx context.Context, round int) (int, error) {
+	size := s.Replicas
+	if size <= 0 {
+		size = 1
+	}
+	table := s.Ring().OwnershipTable(size)
+	max := s.MaxPerNode
+	if max <= 0 {
+		max = 1
+	}
+	limiter := &nodeLimiter{max: max, busy: make(map[string]int), released: make(chan struct{})}
+
+	tasks := make([]scheduledRepair, len(table))
+	for i, r := range table {
+		delay := time.Duration(0)
+		if s.Interval > 0 {
+			delay = time.Duration(int64(s.Interval) * int64(i) / int64(len(table)))
+		}
+		if s.Jitter > 0 {
+			delay += time.Duration(rand.Int64N(int64(s.Jitter)))
+		}
+		tasks[i] = scheduledRepair{RepairTask{OwnedRange: r, Round: round}, delay}
+	}
+	sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].delay < tasks[j].delay })
+
+	var wg sync.WaitGroup
+	start := s.clock().Now()
+	for _, task := range tasks {
+		if wait := start.Add(task.delay).Sub(s.clock().Now()); wait > 0 {
+			select {
+			case <-s.clock().After(wait):
+			case <-ctx.Done():
+				wg.Wait()
+				return len(tasks), ctx.Err()
+			}
+		}
+		nodes := append([]string{task.Owner}, task.Replicas...)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := limiter.acquire(ctx, nodes); err != nil {
+				return
+			}
+			defer limiter.release(nodes)
+			if err := s.Repair(ctx, task.RepairTask); err != nil && s.OnError != nil {
+				s.OnError(task.RepairTask, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return len(tasks), ctx.Err()
+}
+
+// clock returns the Clock of s.
+func (s *RepairScheduler) clock() Clock {
+	if s.Clock != nil {
+		return s.Clock
+	}
+	return SystemClock
+}
+
+// scheduledRepair is a task due delay after the start of its round.
+type scheduledRepair struct {
+	RepairTask
+	delay time.Duration
+}
+
+// nodeLimiter bounds the tasks each node takes part in at once.
+type nodeLimiter struct {
+	max int
+
+	mu       sync.Mutex
+	busy     map[string]int
+	released chan struct{} // closed and replaced on every release.
+}
+
+// acquire waits until every node of nodes has room for a task, and takes it.
+func (l *nodeLimiter) acquire(ctx context.Context, nodes []string) error {
+	for {
+		l.mu.Lock()
+		free := true
+		for _, node := range nodes {
+			if l.busy[node] >= l.max {
+				free = false
+				break
+			}
+		}
+		if free {
+			for _, node := range nodes {
+				l.busy[node]++
+			}
+			l.mu.Unlock()
+			return nil
+		}
+		released := l.released
+		l.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// release gives back the room of a task on nodes.
+func (l *nodeLimiter) release(nodes []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, node := range nodes {
+		l.busy[node]--
+	}
+	close(l.released)
+	l.released = make(chan struct{})
+}
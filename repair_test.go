@@ -0,0 +1,174 @@
+package hashring
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// repairRecorder records the tasks repaired and the most repairs a node took
+// part in at once.
+type repairRecorder struct {
+	mu      sync.Mutex
+	tasks   []RepairTask
+	busy    map[string]int
+	maxBusy int
+}
+
+func (r *repairRecorder) repair(ctx context.Context, task RepairTask) error {
+	nodes := append([]string{task.Owner}, task.Replicas...)
+	r.mu.Lock()
+	if r.busy == nil {
+		r.busy = make(map[string]int)
+	}
+	for _, node := range nodes {
+		r.busy[node]++
+		if r.busy[node] > r.maxBusy {
+			r.maxBusy = r.busy[node]
+		}
+	}
+	r.tasks = append(r.tasks, task)
+	r.mu.Unlock()
+
+	runtime.Gosched()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, node := range nodes {
+		r.busy[node]--
+	}
+	return nil
+}
+
+func TestRepairSchedulerRound(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c", "d"})
+	for _, max := range []int{1, 2} {
+		var r repairRecorder
+		s := &RepairScheduler{
+			Ring:       func() *HashRing { return hashRing },
+			Repair:     r.repair,
+			Replicas:   2,
+			MaxPerNode: max,
+		}
+		assert.NoError(t, s.RunRound(context.Background(), 3))
+
+		// Every range is repaired once.
+		table := hashRing.OwnershipTable(2)
+		assert.Len(t, r.tasks, len(table))
+		repaired := make(map[Range]RepairTask)
+		for _, task := range r.tasks {
+			repaired[task.Range] = task
+		}
+		for _, owned := range table {
+			assert.Equal(t, RepairTask{OwnedRange: owned, Round: 3}, repaired[owned.Range])
+		}
+		assert.LessOrEqual(t, r.maxBusy, max)
+	}
+}
+
+func TestRepairSchedulerInterval(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := NewManualClock(start)
+	hashRing := New([]string{"a", "b"})
+	n := len(hashRing.OwnershipTable(1))
+
+	var r repairRecorder
+	var rounds []time.Time
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := &RepairScheduler{
+		Ring: func() *HashRing {
+			// Read before any wait of the round, so the clock has not moved on.
+			rounds = append(rounds, clock.Now())
+			return hashRing
+		},
+		Replicas: 1,
+		Interval: time.Duration(n) * time.Second,
+		Clock:    clock,
+		Repair: func(ctx context.Context, task RepairTask) error {
+			if task.Round == 2 {
+				cancel()
+				return nil
+			}
+			return r.repair(ctx, task)
+		},
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.ErrorIs(t, s.Run(ctx), context.Canceled)
+	}()
+	advanceWhileWaiting(clock, time.Second, done)
+
+	// Tasks are a second apart, and rounds last the interval.
+	assert.Len(t, r.tasks, 2*n)
+	assert.Equal(t, 2*time.Duration(n)*time.Second, rounds[2].Sub(start))
+}
+
+func TestRepairSchedulerErrors(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"})
+	var mu sync.Mutex
+	var failed []RepairTask
+	boom := errors.New("boom")
+	s := &RepairScheduler{
+		Ring:   func() *HashRing { return hashRing },
+		Jitter: time.Millisecond,
+		Repair: func(ctx context.Context, task RepairTask) error {
+			if task.Owner == "b" {
+				return boom
+			}
+			return nil
+		},
+		OnError: func(task RepairTask, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(t, boom, err)
+			failed = append(failed, task)
+		},
+	}
+	assert.NoError(t, s.RunRound(context.Background(), 0))
+	assert.NotEmpty(t, failed)
+	for _, task := range failed {
+		assert.Equal(t, "b", task.Owner)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, s.RunRound(ctx, 0), context.Canceled)
+}
+
+func TestRepairSchedulerIdle(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	var rounds atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &RepairScheduler{
+		Ring: func() *HashRing {
+			rounds.Add(1)
+			return nil
+		},
+		Repair: func(context.Context, RepairTask) error { return nil },
+		Clock:  clock,
+	}
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	// A round of an empty ring waits instead of spinning.
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, int32(1), rounds.Load())
+	clock.Advance(idleRepairWait)
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, int32(2), rounds.Load())
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
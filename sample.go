@@ -0,0 +1,54 @@
+package hashring
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+)
+
+// SampleKeyspace returns n keys owned by node, for canary jobs probing the
+// keyspace of a node.
+//
+// The keys only depend on node and n and are taken in a fixed order, so they
+// are the same across runs and processes while the ring is unchanged, and
+// after a change the keys still owned by node stay in the sample. Being
+// hashed, they spread over all of the node's arcs in proportion to their
+// length. It returns nil if node owns no part of the ring.
+func (h *HashRing) SampleKeyspace(node string, n int) []string {
+	share := h.orEmpty().ownership()[node]
+	if share == 0 || n <= 0 {
+		return nil
+	}
+
+	// Give up well after the expected number of attempts, n/share.
+	attempts := math.Min(100*float64(n)/share+100, math.MaxInt32)
+	keys := make([]string, 0, n)
+	for i := 0; len(keys) < n && i < int(attempts); i++ {
+		key := node + "#sample-" + strconv.Itoa(i)
+		if h.ownerOf(h.GenKey64(key)) == node {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// GenerateKeyForNode returns a random key owned by node, for load and
+// integration tests targeting a shard. Keys are drawn uniformly from the
+// keyspace until one falls on node, so they spread over the node's arcs in
+// proportion to their length. It returns false if node owns no part of the
+// ring.
+func (h *HashRing) GenerateKeyForNode(node string) (key string, ok bool) {
+	share := h.orEmpty().ownership()[node]
+	if share == 0 {
+		return "", false
+	}
+
+	attempts := math.Min(100/share+100, math.MaxInt32)
+	for i := 0; i < int(attempts); i++ {
+		key := "key-" + strconv.FormatUint(rand.Uint64(), 36)
+		if h.ownerOf(h.GenKey64(key)) == node {
+			return key, true
+		}
+	}
+	return "", false
+}
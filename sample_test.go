@@ -0,0 +1,61 @@
+package hashring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampleKeyspace(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"})
+	keys := hashRing.SampleKeyspace("b", 50)
+	assert.Len(t, keys, 50)
+	for _, key := range keys {
+		node, _ := hashRing.GetNode(key)
+		assert.Equal(t, "b", node)
+	}
+	assert.Equal(t, keys, New([]string{"c", "b", "a"}).SampleKeyspace("b", 50))
+	assert.Equal(t, keys[:10], hashRing.SampleKeyspace("b", 10))
+
+	// The sample covers many of the node's arcs.
+	arcs := make(map[int]bool)
+	for _, key := range keys {
+		pos, _ := hashRing.GetNodePos(key)
+		arcs[pos] = true
+	}
+	assert.True(t, len(arcs) > 25)
+
+	// Keys still owned after a change stay in the sample.
+	added := hashRing.AddNode("d")
+	kept := make(map[string]bool)
+	for _, key := range added.SampleKeyspace("b", 50) {
+		kept[key] = true
+	}
+	for _, key := range keys {
+		if node, _ := added.GetNode(key); node == "b" {
+			assert.True(t, kept[key], key)
+		}
+	}
+
+	assert.Nil(t, hashRing.SampleKeyspace("z", 10))
+	assert.Nil(t, hashRing.SampleKeyspace("a", 0))
+	assert.Nil(t, (*HashRing)(nil).SampleKeyspace("a", 10))
+}
+
+func TestGenerateKeyForNode(t *testing.T) {
+	hashRing := NewWithWeights(map[string]int{"a": 1, "b": 1, "c": 8})
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		key, ok := hashRing.GenerateKeyForNode("a")
+		assert.True(t, ok)
+		node, _ := hashRing.GetNode(key)
+		assert.Equal(t, "a", node)
+		seen[key] = true
+	}
+	assert.True(t, len(seen) > 90)
+
+	_, ok := hashRing.GenerateKeyForNode("x")
+	assert.False(t, ok)
+	_, ok = (*HashRing)(nil).GenerateKeyForNode("a")
+	assert.False(t, ok)
+}
@@ -0,0 +1,19 @@
+package hashring
+
+// WithSeed salts the placement of virtual nodes with seed, so rings of the
+// same nodes but different seeds place their points, and so their keys,
+// independently. Use a seed per cluster to keep clusters sharing node names
+// from failing the same keys together.
+//
+// Keys are hashed as without a seed. Seed 0 is the unsalted ring.
+func WithSeed(seed uint64) Option {
+	return func(c *config) {
+		c.seed = seed
+	}
+}
+
+// NewWithSeed creates an instance of HashRing from nodes, salted with seed,
+// see WithSeed.
+func NewWithSeed(nodes []string, seed uint64, opts ...Option) *HashRing {
+	return New(nodes, append([]Option{WithSeed(seed)}, opts...)...)
+}
@@ -0,0 +1,35 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithSeed(t *testing.T) {
+	nodes := []string{"a", "b", "c", "d"}
+	assert.Equal(t, New(nodes).sortedKeys, New(nodes, WithSeed(0)).sortedKeys)
+
+	first, second := NewWithSeed(nodes, 1), NewWithSeed(nodes, 2)
+	assert.NoError(t, first.Validate())
+	assert.NotEqual(t, New(nodes).sortedKeys, first.sortedKeys)
+	assert.NotEqual(t, first.sortedKeys, second.sortedKeys)
+	assert.Equal(t, first.sortedKeys, NewWithSeed(nodes, 1).sortedKeys)
+	assert.Equal(t, New(nodes).GenKey("test"), first.GenKey("test"))
+
+	// About 3/4 of the keys of 4 nodes land on another node in an independent ring.
+	moved := 0
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		a, _ := first.GetNode(key)
+		b, _ := second.GetNode(key)
+		if a != b {
+			moved++
+		}
+	}
+	assert.InDelta(t, 750, moved, 100)
+
+	// Derived rings keep the seed.
+	assert.Equal(t, NewWithSeed(append(nodes, "e"), 1).sortedKeys, first.AddNode("e").sortedKeys)
+}
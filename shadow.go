@@ -0,0 +1,87 @@
+package hashring
+
+import "sync"
+
+// NodeLocator finds the node a key belongs to. *HashRing implements it.
+type NodeLocator interface {
+	GetNode(stringKey string) (node string, ok bool)
+}
+
+// ShadowRing routes lookups with a primary locator while asking a candidate
+// too, and records how often they agree. It lets a new ring, with other
+// weights or hashing, be evaluated against live traffic before switching.
+type ShadowRing struct {
+	primary, candidate NodeLocator
+
+	mu    sync.Mutex
+	stats ShadowStats
+}
+
+// ShadowStats counts the lookups of a ShadowRing.
+type ShadowStats struct {
+	Lookups    int
+	Agreements int
+	// Disagreements counts the lookups the locators answered differently, by
+	// answer. A locator that finds no node answers "".
+	Disagreements map[ShadowDecision]int
+}
+
+// ShadowDecision is the pair of answers to a lookup.
+type ShadowDecision struct {
+	Primary, Candidate string
+}
+
+// Agreement returns the share of lookups the locators agreed on, 1 if there
+// were none.
+func (s ShadowStats) Agreement() float64 {
+	if s.Lookups == 0 {
+		return 1
+	}
+	return float64(s.Agreements) / float64(s.Lookups)
+}
+
+// NewShadowRing creates a ShadowRing routing with primary and comparing with candidate.
+func NewShadowRing(primary, candidate NodeLocator) *ShadowRing {
+	return &ShadowRing{
+		primary:   primary,
+		candidate: candidate,
+		stats:     ShadowStats{Disagreements: make(map[ShadowDecision]int)},
+	}
+}
+
+// GetNode returns the primary's node for stringKey, recording whether the
+// candidate agrees.
+func (s *ShadowRing) GetNode(stringKey string) (node string, ok bool) {
+	node, ok = s.primary.GetNode(stringKey)
+	shadow, _ := s.candidate.GetNode(stringKey)
+
+	s.mu.Lock()
+	s.stats.Lookups++
+	if node == shadow {
+		s.stats.Agreements++
+	} else {
+		s.stats.Disagreements[ShadowDecision{Primary: node, Candidate: shadow}]++
+	}
+	s.mu.Unlock()
+	return node, ok
+}
+
+// Stats returns the counts so far.
+func (s *ShadowRing) Stats() ShadowStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.stats
+	stats.Disagreements = make(map[ShadowDecision]int, len(s.stats.Disagreements))
+	for d, n := range s.stats.Disagreements {
+		stats.Disagreements[d] = n
+	}
+	return stats
+}
+
+// Reset clears the counts.
+func (s *ShadowRing) Reset() {
+	s.mu.Lock()
+	s.stats = ShadowStats{Disagreements: make(map[ShadowDecision]int)}
+	s.mu.Unlock()
+}
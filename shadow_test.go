@@ -0,0 +1,65 @@
+package hashring
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShadowRing(t *testing.T) {
+	primary := New([]string{"a", "b", "c"})
+	candidate := primary.AddNode("d")
+	shadow := NewShadowRing(primary, candidate)
+
+	disagreements, disagreed := make(map[ShadowDecision]int), 0
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		expected, _ := primary.GetNode(key)
+		other, _ := candidate.GetNode(key)
+		if expected != other {
+			disagreements[ShadowDecision{Primary: expected, Candidate: other}]++
+			disagreed++
+		}
+
+		node, ok := shadow.GetNode(key)
+		assert.True(t, ok)
+		assert.Equal(t, expected, node)
+	}
+
+	stats := shadow.Stats()
+	assert.Equal(t, 1000, stats.Lookups)
+	assert.Equal(t, 1000-disagreed, stats.Agreements)
+	assert.Equal(t, disagreements, stats.Disagreements)
+	assert.InDelta(t, 1-Diff(primary, candidate).Churn, stats.Agreement(), 0.05)
+
+	shadow.Reset()
+	assert.Equal(t, 1.0, shadow.Stats().Agreement())
+	assert.Empty(t, shadow.Stats().Disagreements)
+}
+
+func TestShadowRingEmptyCandidate(t *testing.T) {
+	shadow := NewShadowRing(New([]string{"a"}), New([]string{}))
+	node, ok := shadow.GetNode("test")
+	assert.True(t, ok)
+	assert.Equal(t, "a", node)
+	assert.Equal(t, map[ShadowDecision]int{{Primary: "a", Candidate: ""}: 1}, shadow.Stats().Disagreements)
+}
+
+func TestShadowRingConcurrent(t *testing.T) {
+	shadow := NewShadowRing(New([]string{"a", "b"}), New([]string{"a", "b"}))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				shadow.GetNode(strconv.Itoa(j))
+				shadow.Stats()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 800, shadow.Stats().Agreements)
+}
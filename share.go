@@ -0,0 +1,72 @@
+package hashring
+
+import (
+	"math"
+	"sort"
+)
+
+// WithMaxShare caps the weight share of every node at max, e.g. 0.25, so
+// that a node given a huge weight by mistake cannot own most keys. A node
+// over the cap places the virtual nodes of the cap, and the share it loses
+// goes to the other nodes in proportion to their weights. As for weights,
+// the keyspace share follows the virtual nodes up to the balance of the
+// ring, see Imbalance.
+//
+// With fewer than 1/max nodes the cap cannot hold, and all nodes get equal
+// shares.
+func WithMaxShare(max float64) Option {
+	return func(c *config) {
+		c.maxShare = max
+	}
+}
+
+// capShares returns the weights of the nodes of h over the cap of
+// WithMaxShare lowered to the cap, or nil if none is.
+//
+// A node of weight w is over the cap if w > e, for e the weight whose share
+// is max with every node over the cap at e: e = max * (u + k*e), for u the
+// weight of the k nodes under it, so e = max*u / (1 - k*max). Capping a node
+// lowers e, so nodes are capped until none is over e.
+func (h *HashRing) capShares() map[string]int {
+	max := h.config.maxShare
+	if max <= 0 || max >= 1 {
+		return nil
+	}
+	var nodes []string
+	for _, node := range sortedNodes(h.nodes) {
+		if h.weights[node] > 0 {
+			nodes = append(nodes, node)
+		}
+	}
+	// Heaviest first, so the nodes over the cap are a prefix.
+	sort.SliceStable(nodes, func(i, j int) bool { return h.weights[nodes[i]] > h.weights[nodes[j]] })
+	under := 0
+	for _, node := range nodes {
+		under += h.weights[node]
+	}
+
+	k, e := 0, 0.0
+	for ; k < len(nodes) && float64(k)*max < 1; k++ {
+		e = max * float64(under) / (1 - float64(k)*max)
+		if float64(h.weights[nodes[k]]) <= e {
+			break
+		}
+		under -= h.weights[nodes[k]]
+	}
+	if k == 0 {
+		return nil
+	}
+
+	capped := make(map[string]int, len(nodes))
+	if k == len(nodes) || float64(k)*max >= 1 {
+		// The cap cannot hold: all nodes get the weight of the lightest.
+		for _, node := range nodes {
+			capped[node] = h.weights[nodes[len(nodes)-1]]
+		}
+		return capped
+	}
+	for _, node := range nodes[:k] {
+		capped[node] = int(math.Max(1, math.Floor(e)))
+	}
+	return capped
+}
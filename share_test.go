@@ -0,0 +1,45 @@
+package hashring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMaxShare(t *testing.T) {
+	weights := map[string]int{"a": 100, "b": 1, "c": 1, "d": 1, "e": 1}
+	hashRing := NewWithWeights(weights, WithMaxShare(0.4))
+
+	// a is capped at 0.4 of the weight: 4*0.4/0.6, i.e. 2 of 6.
+	assert.Equal(t, map[string]int{"a": 2}, hashRing.capShares())
+	assert.Equal(t, weights, hashRing.weights)
+	assert.InDelta(t, 2*hashRing.factors["b"], hashRing.factors["a"], 1)
+	expectSameCircle(t, hashRing, NewWithWeights(map[string]int{"a": 2, "b": 1, "c": 1, "d": 1, "e": 1}))
+	assert.Less(t, hashRing.ownership()["a"], 0.45)
+	assert.Less(t, hashRing.Imbalance(), 0.5)
+
+	// Nodes under the cap are placed as without it.
+	expectSameCircle(t, New([]string{"a", "b", "c"}, WithMaxShare(0.4)), New([]string{"a", "b", "c"}))
+
+	// Changes keep the cap.
+	added := hashRing.AddWeightedNode("f", 50)
+	assert.Equal(t, map[string]int{"a": 8, "f": 8}, added.capShares())
+	expectSameCircle(t, added, NewWithWeights(map[string]int{"a": 100, "b": 1, "c": 1, "d": 1, "e": 1, "f": 50}, WithMaxShare(0.4)))
+	assert.NoError(t, added.CheckConsistency())
+}
+
+func TestWithMaxShareCascade(t *testing.T) {
+	// Capping a lowers the cap, which b is then over too.
+	hashRing := NewWithWeights(map[string]int{"a": 100, "b": 25, "c": 10, "d": 10, "e": 10, "f": 10}, WithMaxShare(0.25))
+	assert.Equal(t, map[string]int{"a": 20, "b": 20}, hashRing.capShares())
+}
+
+func TestWithMaxShareUnreachable(t *testing.T) {
+	// Two nodes cannot each own at most a third.
+	hashRing := NewWithWeights(map[string]int{"a": 5, "b": 2}, WithMaxShare(0.3))
+	assert.Equal(t, map[string]int{"a": 2, "b": 2}, hashRing.capShares())
+	expectSameCircle(t, hashRing, New([]string{"a", "b"}))
+
+	assert.Nil(t, NewWithWeights(map[string]int{"a": 5, "b": 2}, WithMaxShare(1)).capShares())
+	assert.Nil(t, NewWithWeights(map[string]int{"a": 5, "b": 2}).capShares())
+}
@@ -0,0 +1,374 @@
+package hashring
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DefaultSlots is the number of slots of a SlotMap by default, as in Redis
+// Cluster.
+const DefaultSlots = 16384
+
+// SlotMap maps keys to a fixed number of slots, and slots to nodes, as Redis
+// Cluster does: a key always hashes to the same slot, and a slot belongs to
+// the node it is assigned to. Slots only change owner when reassigned,
+// explicitly by AssignSlots or MoveSlots, or in bounded steps by Rebalance,
+// so a rebalance can move part of the keyspace at a time. It implements
+// NodeLocator.
+//
+// Keys are hashed as by a HashRing of the same options, e.g. WithHasher,
+// the slot of a key being its 64-bit HashKey modulo the number of slots.
+// Changes, reassignments included, return a new SlotMap, leaving the one
+// they were made on untouched.
+type SlotMap struct {
+	owners  []int32  // node of each slot as an index in names, -1 if none.
+	names   []string // sorted.
+	weights map[string]int
+	hash    *HashRing // hashes keys, without nodes.
+}
+
+// SlotMove is the reassignment of a slot, From "" if it had no owner.
+type SlotMove struct {
+	Slot     int
+	From, To string
+}
+
+// NewSlotMap creates a SlotMap of slots slots, DefaultSlots if slots is not
+// positive, spread over the nodes of weights in proportion to their weight,
+// each node taking a contiguous range in order of node. Nodes of weight 0 are
+// standbys and take no slots, see HashRing.AddWeightedNode.
+func NewSlotMap(slots int, weights map[string]int, opts ...Option) (*SlotMap, error) {
+	if slots <= 0 {
+		slots = DefaultSlots
+	}
+	own := make(map[string]int, len(weights))
+	for node, weight := range weights {
+		if weight < 0 {
+			return nil, fmt.Errorf("hashring: node %q has negative weight %d", node, weight)
+		}
+		own[node] = weight
+	}
+	m := &SlotMap{
+		owners:  make([]int32, slots),
+		weights: own,
+		hash:    &HashRing{config: newConfig(opts)},
+	}
+	m.names = nodesOf(own)
+	sort.Strings(m.names)
+
+	slot := 0
+	for i, target := range m.targets() {
+		for end := slot + target; slot < end; slot++ {
+			m.owners[slot] = int32(i)
+		}
+	}
+	for ; slot < slots; slot++ {
+		m.owners[slot] = -1
+	}
+	return m, nil
+}
+
+// targets returns the number of slots each node of names should own by
+// weight, rounding by largest remainder.
+func (m *SlotMap) targets() []int {
+	total := 0
+	for _, weight := range m.weights {
+		total += weight
+	}
+	targets := make([]int, len(m.names))
+	if total == 0 {
+		return targets
+	}
+	slots := len(m.owners)
+	remainders := make([]int, len(m.names))
+	left := slots
+	for i, node := range m.names {
+		share := slots * m.weights[node]
+		targets[i], remainders[i] = share/total, share%total
+		left -= targets[i]
+	}
+	byRemainder := make([]int, len(m.names))
+	for i := range byRemainder {
+		byRemainder[i] = i
+	}
+	sort.SliceStable(byRemainder, func(a, b int) bool {
+		return remainders[byRemainder[a]] > remainders[byRemainder[b]]
+	})
+	for _, i := range byRemainder[:left] {
+		targets[i]++
+	}
+	return targets
+}
+
+// clone returns a copy of m with its own owners and weights.
+func (m *SlotMap) clone() *SlotMap {
+	next := &SlotMap{
+		owners:  append([]int32{}, m.owners...),
+		names:   m.names,
+		weights: copyWeights(m.weights),
+		hash:    m.hash,
+	}
+	return next
+}
+
+// withNames re-indexes the owners of m for the sorted nodes of its weights.
+func (m *SlotMap) withNames() {
+	names := nodesOf(m.weights)
+	sort.Strings(names)
+	index := make(map[string]int32, len(names))
+	for i, node := range names {
+		index[node] = int32(i)
+	}
+	for slot, owner := range m.owners {
+		if owner >= 0 {
+			m.owners[slot] = index[m.names[owner]]
+		}
+	}
+	m.names = names
+}
+
+// Slots returns the number of slots of m.
+func (m *SlotMap) Slots() int {
+	return len(m.owners)
+}
+
+// Size returns the number of nodes of m, standbys included.
+func (m *SlotMap) Size() int {
+	return len(m.names)
+}
+
+// Nodes returns the nodes of m, sorted.
+func (m *SlotMap) Nodes() []string {
+	return append([]string{}, m.names...)
+}
+
+// Weight returns the weight of node, and whether node is on m.
+func (m *SlotMap) Weight(node string) (int, bool) {
+	weight, ok := m.weights[node]
+	return weight, ok
+}
+
+// SlotOf returns the slot of key.
+func (m *SlotMap) SlotOf(stringKey string) int {
+	return int(uint64(m.hash.GenKey64(stringKey)) % uint64(len(m.owners)))
+}
+
+// SlotOwner returns the node slot is assigned to, and false if it has none
+// or slot is out of range.
+func (m *SlotMap) SlotOwner(slot int) (string, bool) {
+	if slot < 0 || slot >= len(m.owners) || m.owners[slot] < 0 {
+		return "", false
+	}
+	return m.names[m.owners[slot]], true
+}
+
+// GetNode returns the node of the slot of key, and false if the slot has no
+// owner.
+func (m *SlotMap) GetNode(stringKey string) (string, bool) {
+	return m.SlotOwner(m.SlotOf(stringKey))
+}
+
+// SlotsOf returns the slots assigned to node, in order.
+func (m *SlotMap) SlotsOf(node string) []int {
+	var slots []int
+	for slot, owner := range m.owners {
+		if owner >= 0 && m.names[owner] == node {
+			slots = append(slots, slot)
+		}
+	}
+	return slots
+}
+
+// SlotRanges returns the slots assigned to node as ranges of consecutive
+// slots, both ends included, as Redis's CLUSTER SLOTS lists them.
+func (m *SlotMap) SlotRanges(node string) [][2]int {
+	var ranges [][2]int
+	for _, slot := range m.SlotsOf(node) {
+		if n := len(ranges); n > 0 && ranges[n-1][1]+1 == slot {
+			ranges[n-1][1] = slot
+		} else {
+			ranges = append(ranges, [2]int{slot, slot})
+		}
+	}
+	return ranges
+}
+
+// Unassigned returns the slots without an owner, in order.
+func (m *SlotMap) Unassigned() []int {
+	var slots []int
+	for slot, owner := range m.owners {
+		if owner < 0 {
+			slots = append(slots, slot)
+		}
+	}
+	return slots
+}
+
+// AddWeightedNode adds node with weight, owning no slots until assigned some
+// or rebalanced, and returns the new SlotMap. It returns an error if node is
+// already on m or weight is negative.
+func (m *SlotMap) AddWeightedNode(node string, weight int) (*SlotMap, error) {
+	if weight < 0 {
+		return nil, fmt.Errorf("hashring: node %q has negative weight %d", node, weight)
+	}
+	if _, ok := m.weights[node]; ok {
+		return nil, fmt.Errorf("hashring: node %q is already on the slot map", node)
+	}
+	next := m.clone()
+	next.weights[node] = weight
+	next.withNames()
+	return next, nil
+}
+
+// UpdateWeightedNode sets the weight of node, which only moves slots on
+// Rebalance, and returns the new SlotMap.
+func (m *SlotMap) UpdateWeightedNode(node string, weight int) (*SlotMap, error) {
+	if weight < 0 {
+		return nil, fmt.Errorf("hashring: node %q has negative weight %d", node, weight)
+	}
+	if _, ok := m.weights[node]; !ok {
+		return nil, fmt.Errorf("hashring: node %q is not on the slot map", node)
+	}
+	next := m.clone()
+	next.weights[node] = weight
+	return next, nil
+}
+
+// RemoveNode removes node, leaving its slots without an owner, and returns
+// the new SlotMap. Move the slots away first, e.g. by setting its weight to 0
+// and rebalancing, to keep its keys served.
+func (m *SlotMap) RemoveNode(node string) (*SlotMap, error) {
+	i := sort.SearchStrings(m.names, node)
+	if i == len(m.names) || m.names[i] != node {
+		return nil, fmt.Errorf("hashring: node %q is not on the slot map", node)
+	}
+	next := m.clone()
+	for slot, owner := range next.owners {
+		if owner == int32(i) {
+			next.owners[slot] = -1
+		}
+	}
+	delete(next.weights, node)
+	next.withNames()
+	return next, nil
+}
+
+// AssignSlots assigns slots to node, and returns the new SlotMap with the
+// moves made. Slots node already owns are not moved.
+func (m *SlotMap) AssignSlots(node string, slots ...int) (*SlotMap, []SlotMove, error) {
+	i := sort.SearchStrings(m.names, node)
+	if i == len(m.names) || m.names[i] != node {
+		return nil, nil, fmt.Errorf("hashring: node %q is not on the slot map", node)
+	}
+	for _, slot := range slots {
+		if slot < 0 || slot >= len(m.owners) {
+			return nil, nil, fmt.Errorf("hashring: slot %d out of range [0, %d)", slot, len(m.owners))
+		}
+	}
+	next := m.clone()
+	var moves []SlotMove
+	for _, slot := range slots {
+		if next.owners[slot] == int32(i) {
+			continue
+		}
+		from, _ := next.SlotOwner(slot)
+		next.owners[slot] = int32(i)
+		moves = append(moves, SlotMove{Slot: slot, From: from, To: node})
+	}
+	return next, moves, nil
+}
+
+// MoveSlots moves n slots from node from to node to, the highest first, as
+// a resharding of Redis Cluster does, and returns the new SlotMap with the
+// moves made. Fewer are moved if from owns fewer.
+func (m *SlotMap) MoveSlots(from, to string, n int) (*SlotMap, []SlotMove, error) {
+	if _, ok := m.weights[from]; !ok {
+		return nil, nil, fmt.Errorf("hashring: node %q is not on the slot map", from)
+	}
+	slots := m.SlotsOf(from)
+	if n < len(slots) {
+		slots = slots[len(slots)-n:]
+	}
+	return m.AssignSlots(to, slots...)
+}
+
+// Rebalance moves up to maxMoves slots, all that are needed if maxMoves is
+// not positive, towards the share of each node by weight, and returns the new
+// SlotMap with the moves made. Unassigned slots are assigned first, then the
+// slots of the nodes furthest over their share move to the nodes furthest
+// under it, so calling Rebalance repeatedly with a small maxMoves converges
+// in controlled steps. A node of weight 0 gives up all its slots.
+func (m *SlotMap) Rebalance(maxMoves int) (*SlotMap, []SlotMove) {
+	targets := m.targets()
+	owned := make([]int, len(m.names))
+	for _, owner := range m.owners {
+		if owner >= 0 {
+			owned[owner]++
+		}
+	}
+	deficit := func(i int) int { return targets[i] - owned[i] }
+
+	// The node furthest under its share, -1 if none is.
+	neediest := func() int {
+		best := -1
+		for i := range m.names {
+			if deficit(i) > 0 && (best < 0 || deficit(i) > deficit(best)) {
+				best = i
+			}
+		}
+		return best
+	}
+	// The node furthest over its share, -1 if none is.
+	richest := func() int {
+		best := -1
+		for i := range m.names {
+			if deficit(i) < 0 && (best < 0 || deficit(i) < deficit(best)) {
+				best = i
+			}
+		}
+		return best
+	}
+	budget := func(moves []SlotMove) bool {
+		return maxMoves <= 0 || len(moves) < maxMoves
+	}
+
+	next := m.clone()
+	var moves []SlotMove
+	for slot, owner := range next.owners {
+		if !budget(moves) {
+			return next, moves
+		}
+		to := neediest()
+		if to < 0 {
+			break
+		}
+		if owner < 0 {
+			next.owners[slot] = int32(to)
+			owned[to]++
+			moves = append(moves, SlotMove{Slot: slot, To: m.names[to]})
+		}
+	}
+
+	// Slots of each node are given up from its highest. Owned slots are
+	// collected once, as moves only take slots away from over nodes.
+	bySlot := make([][]int, len(m.names))
+	for slot, owner := range next.owners {
+		if owner >= 0 {
+			bySlot[owner] = append(bySlot[owner], slot)
+		}
+	}
+	for budget(moves) {
+		to, from := neediest(), richest()
+		if to < 0 || from < 0 {
+			break
+		}
+		slots := bySlot[from]
+		slot := slots[len(slots)-1]
+		bySlot[from] = slots[:len(slots)-1]
+		next.owners[slot] = int32(to)
+		owned[from]--
+		owned[to]++
+		moves = append(moves, SlotMove{Slot: slot, From: m.names[from], To: m.names[to]})
+	}
+	return next, moves
+}
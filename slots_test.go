@@ -0,0 +1,169 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSlotMap(t *testing.T) {
+	m, err := NewSlotMap(0, map[string]int{"a": 1, "b": 2, "c": 1, "s": 0})
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultSlots, m.Slots())
+	assert.Equal(t, 4, m.Size())
+	assert.Equal(t, []string{"a", "b", "c", "s"}, m.Nodes())
+	assert.Equal(t, [][2]int{{0, 4095}}, m.SlotRanges("a"))
+	assert.Equal(t, [][2]int{{4096, 12287}}, m.SlotRanges("b"))
+	assert.Equal(t, [][2]int{{12288, 16383}}, m.SlotRanges("c"))
+	assert.Empty(t, m.SlotsOf("s"))
+	assert.Empty(t, m.Unassigned())
+
+	// Remainders go to the largest fractions, all slots are assigned.
+	m, err = NewSlotMap(10, map[string]int{"a": 1, "b": 1, "c": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3}, m.SlotsOf("a"))
+	assert.Equal(t, []int{4, 5, 6}, m.SlotsOf("b"))
+	assert.Equal(t, []int{7, 8, 9}, m.SlotsOf("c"))
+
+	_, err = NewSlotMap(10, map[string]int{"a": -1})
+	assert.Error(t, err)
+	empty, err := NewSlotMap(10, nil)
+	assert.NoError(t, err)
+	_, ok := empty.GetNode("key")
+	assert.False(t, ok)
+	assert.Len(t, empty.Unassigned(), 10)
+}
+
+func TestSlotMapGetNode(t *testing.T) {
+	m, err := NewSlotMap(0, map[string]int{"a": 1, "b": 1, "c": 1})
+	assert.NoError(t, err)
+	var locator NodeLocator = m
+	counts := make(map[string]int)
+	for i := 0; i < 3000; i++ {
+		key := strconv.Itoa(i)
+		node, ok := locator.GetNode(key)
+		assert.True(t, ok)
+		owner, _ := m.SlotOwner(m.SlotOf(key))
+		assert.Equal(t, owner, node)
+		counts[node]++
+	}
+	for _, node := range m.Nodes() {
+		assert.InDelta(t, 1000, counts[node], 150, node)
+	}
+	_, ok := m.SlotOwner(-1)
+	assert.False(t, ok)
+	_, ok = m.SlotOwner(DefaultSlots)
+	assert.False(t, ok)
+
+	// Keys hash as on a ring of the same options.
+	wide, _ := NewSlotMap(0, map[string]int{"a": 1}, With64BitKeys())
+	ring := New(nil, With64BitKeys())
+	assert.Equal(t, int(uint64(ring.GenKey64("key"))%DefaultSlots), wide.SlotOf("key"))
+}
+
+func TestSlotMapAssign(t *testing.T) {
+	m, _ := NewSlotMap(8, map[string]int{"a": 1, "b": 1})
+	next, moves, err := m.AssignSlots("b", 0, 1, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []SlotMove{{Slot: 0, From: "a", To: "b"}, {Slot: 1, From: "a", To: "b"}}, moves)
+	assert.Equal(t, []int{2, 3}, next.SlotsOf("a"))
+	assert.Equal(t, [][2]int{{0, 1}, {4, 7}}, next.SlotRanges("b"))
+	// m is left as is.
+	assert.Equal(t, []int{0, 1, 2, 3}, m.SlotsOf("a"))
+
+	_, _, err = m.AssignSlots("c", 0)
+	assert.Error(t, err)
+	_, _, err = m.AssignSlots("a", 8)
+	assert.Error(t, err)
+
+	next, moves, err = m.MoveSlots("a", "b", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []SlotMove{{1, "a", "b"}, {2, "a", "b"}, {3, "a", "b"}}, moves)
+	assert.Equal(t, []int{0}, next.SlotsOf("a"))
+	_, moves, _ = next.MoveSlots("a", "b", 3)
+	assert.Len(t, moves, 1)
+	_, _, err = m.MoveSlots("c", "b", 1)
+	assert.Error(t, err)
+}
+
+func TestSlotMapChanges(t *testing.T) {
+	m, _ := NewSlotMap(12, map[string]int{"b": 1, "d": 1})
+	added, err := m.AddWeightedNode("a", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "d"}, added.Nodes())
+	// Adding moves no slots.
+	for slot := 0; slot < 12; slot++ {
+		before, _ := m.SlotOwner(slot)
+		after, _ := added.SlotOwner(slot)
+		assert.Equal(t, before, after)
+	}
+	_, err = added.AddWeightedNode("a", 1)
+	assert.Error(t, err)
+	_, err = added.AddWeightedNode("e", -1)
+	assert.Error(t, err)
+
+	removed, err := added.RemoveNode("b")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "d"}, removed.Nodes())
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5}, removed.Unassigned())
+	assert.Equal(t, []int{6, 7, 8, 9, 10, 11}, removed.SlotsOf("d"))
+	_, err = removed.RemoveNode("b")
+	assert.Error(t, err)
+
+	updated, err := added.UpdateWeightedNode("d", 2)
+	assert.NoError(t, err)
+	weight, ok := updated.Weight("d")
+	assert.True(t, ok)
+	assert.Equal(t, 2, weight)
+	assert.Equal(t, m.SlotsOf("d"), updated.SlotsOf("d"))
+	_, err = added.UpdateWeightedNode("e", 1)
+	assert.Error(t, err)
+	_, err = added.UpdateWeightedNode("d", -1)
+	assert.Error(t, err)
+}
+
+func TestSlotMapRebalance(t *testing.T) {
+	m, _ := NewSlotMap(0, map[string]int{"a": 1, "b": 1, "c": 1})
+	m, _ = m.AddWeightedNode("d", 1)
+
+	// Partial rebalances move at most the given number of slots each, and
+	// converge to the full one.
+	step, moves := m.Rebalance(1000)
+	assert.Len(t, moves, 1000)
+	for _, move := range moves {
+		assert.Equal(t, "d", move.To)
+	}
+	for {
+		var more []SlotMove
+		step, more = step.Rebalance(1000)
+		moves = append(moves, more...)
+		if len(more) < 1000 {
+			break
+		}
+	}
+	full, fullMoves := m.Rebalance(0)
+	assert.Equal(t, len(fullMoves), len(moves))
+	assert.Equal(t, DefaultSlots/4, len(moves))
+	for _, node := range m.Nodes() {
+		assert.Len(t, full.SlotsOf(node), DefaultSlots/4, node)
+		assert.Equal(t, full.SlotsOf(node), step.SlotsOf(node), node)
+	}
+	_, moves = full.Rebalance(0)
+	assert.Empty(t, moves)
+
+	// A standby gives up its slots, unassigned slots are assigned.
+	drained, _ := full.UpdateWeightedNode("a", 0)
+	drained, _ = drained.Rebalance(0)
+	assert.Empty(t, drained.SlotsOf("a"))
+	removed, _ := drained.RemoveNode("a")
+	assert.Empty(t, removed.Unassigned())
+
+	lost, _ := full.RemoveNode("a")
+	lost, moves = lost.Rebalance(0)
+	assert.Empty(t, lost.Unassigned())
+	assert.Len(t, moves, DefaultSlots/4)
+	for _, move := range moves {
+		assert.Equal(t, "", move.From)
+	}
+}
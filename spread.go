@@ -0,0 +1,38 @@
+package hashring
+
+// WithReadSpread makes GetReadNode spread reads over the first k nodes of a
+// key, see GetNodes.
+func WithReadSpread(k int) Option {
+	return func(c *config) {
+		c.readSpread = k
+	}
+}
+
+// GetReadNode returns the node to read stringKey from for the client
+// identified by token.
+//
+// With WithReadSpread(k), it picks one of the first k nodes of stringKey by a
+// hash of stringKey and token: different clients spread over the replicas of
+// a key, while a given client always reads a key from the same node as long
+// as the ring does not change. Without it, it returns GetNode(stringKey).
+func (h *HashRing) GetReadNode(stringKey, token string) (node string, ok bool) {
+	pos, ok := h.GetNodePos(stringKey)
+	if !ok {
+		return "", false
+	}
+
+	k := h.config.readSpread
+	if unique := len(sortedNodes(h.nodes)); k > unique {
+		k = unique
+	}
+	if k <= 1 {
+		return h.lookupAt(pos), true
+	}
+
+	nodes, _ := h.walk(pos, k)
+	node = nodes[int(uint32(h.GenKey(token+"\x00"+stringKey))%uint32(len(nodes)))]
+	if h.config.metrics != nil {
+		h.config.metrics.Lookup(node)
+	}
+	return node, true
+}
@@ -0,0 +1,58 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetReadNode(t *testing.T) {
+	nodes := []string{"a", "b", "c", "d", "e"}
+	hashRing := New(nodes, WithReadSpread(3))
+
+	replicas, _ := hashRing.GetNodes("test", 3)
+	seen := make(map[string]int)
+	for i := 0; i < 300; i++ {
+		token := "client-" + strconv.Itoa(i)
+		node, ok := hashRing.GetReadNode("test", token)
+		assert.True(t, ok)
+		assert.Contains(t, replicas, node)
+		seen[node]++
+
+		again, _ := hashRing.GetReadNode("test", token)
+		assert.Equal(t, node, again)
+	}
+	assert.Len(t, seen, 3)
+	for _, n := range seen {
+		assert.InDelta(t, 100, n, 40)
+	}
+
+	// The option is kept by derived rings.
+	node, _ := hashRing.AddNode("f").GetReadNode("test", "client-1")
+	replicas, _ = hashRing.AddNode("f").GetNodes("test", 3)
+	assert.Contains(t, replicas, node)
+}
+
+func TestGetReadNodeDefault(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"})
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		expected, _ := hashRing.GetNode(key)
+		node, ok := hashRing.GetReadNode(key, "client")
+		assert.True(t, ok)
+		assert.Equal(t, expected, node)
+	}
+
+	// k is capped at the number of nodes.
+	small := New([]string{"a", "b"}, WithReadSpread(5))
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		node, _ := small.GetReadNode("test", strconv.Itoa(i))
+		seen[node] = true
+	}
+	assert.Len(t, seen, 2)
+
+	_, ok := New([]string{}, WithReadSpread(3)).GetReadNode("test", "client")
+	assert.False(t, ok)
+}
@@ -0,0 +1,98 @@
+package hashring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// StagedTopology is a ring built in the background by PrepareTopology, to
+// switch to with Commit or drop with Abort.
+type StagedTopology struct {
+	from   *HashRing
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// Set before done is closed.
+	next *HashRing
+	err  error
+
+	once sync.Once
+}
+
+// PrepareTopology starts building the HashRing of h with the nodes and
+// weights of desired, like ApplyWeights, in the background, e.g. to build a
+// big change ahead of the maintenance window and switch to it instantly:
+//
+//	staged := ring.PrepareTopology(desired)
+//	// ... later
+//	if next, err := staged.Commit(); err == nil {
+//		ring = next
+//	}
+//
+// h is left as is. Hooks such as WithOnOwnershipLoss run on Commit, when the
+// change takes effect.
+func (h *HashRing) PrepareTopology(desired Topology) *StagedTopology {
+	h = h.orEmpty()
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &StagedTopology{from: h, cancel: cancel, done: make(chan struct{})}
+
+	weights := make(map[string]int, len(desired))
+	for node, weight := range desired {
+		weights[node] = weight
+	}
+	go func() {
+		defer cancel()
+		defer close(s.done)
+		s.next, s.err = h.buildTopology(ctx, weights)
+	}()
+	return s
+}
+
+// buildTopology returns the ring derived from h with weights, not yet
+// reported to the hooks of h, see derive.
+func (h *HashRing) buildTopology(ctx context.Context, weights map[string]int) (*HashRing, error) {
+	for node, weight := range weights {
+		if weight < 0 {
+			return nil, fmt.Errorf("hashring: node %q has weight %d", node, weight)
+		}
+	}
+	if err := checkLimits(nodesOf(weights), weights, h.config); err != nil {
+		return nil, err
+	}
+	return newHashRingFrom(ctx, h, nodesOf(weights), weights)
+}
+
+// Ready reports whether the ring is built, or failed to.
+func (s *StagedTopology) Ready() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Commit waits for the ring to be built and returns it, derived from the
+// ring PrepareTopology was called on. It returns the error of the build, or
+// one if s was aborted. A StagedTopology is committed at most once.
+func (s *StagedTopology) Commit() (*HashRing, error) {
+	<-s.done
+	if s.err != nil {
+		return nil, s.err
+	}
+	err := errors.New("hashring: staged topology already committed or aborted")
+	var next *HashRing
+	s.once.Do(func() {
+		next, err = s.from.derive(s.next), nil
+	})
+	return next, err
+}
+
+// Abort stops building the ring, if it is still being built, and drops it.
+func (s *StagedTopology) Abort() {
+	s.once.Do(func() {})
+	s.cancel()
+	<-s.done
+}
@@ -0,0 +1,50 @@
+package hashring
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrepareTopology(t *testing.T) {
+	var losses []string
+	hashRing := New([]string{"a", "b", "c"}, WithOnOwnershipLoss(func(l OwnershipLoss) {
+		losses = append(losses, l.Node)
+	}))
+	staged := hashRing.PrepareTopology(Topology{"a": 1, "b": 2, "d": 1})
+	assert.Equal(t, Topology{"a": 1, "b": 1, "c": 1}, hashRing.Topology())
+
+	next, err := staged.Commit()
+	assert.True(t, staged.Ready())
+	assert.NoError(t, err)
+	expectSameCircle(t, next, NewWithWeights(map[string]int{"a": 1, "b": 2, "d": 1}))
+	assert.Equal(t, pointsOf(hashRing), pointsOf(next.Previous()))
+	assert.Contains(t, losses, "c")
+
+	_, err = staged.Commit()
+	assert.Error(t, err)
+}
+
+func TestPrepareTopologyAbort(t *testing.T) {
+	hashRing := New([]string{"a", "b"})
+	staged := hashRing.PrepareTopology(Topology{"a": 1})
+	staged.Abort()
+	assert.True(t, staged.Ready())
+	_, err := staged.Commit()
+	assert.Error(t, err)
+
+	// Abort stops a build under way.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = hashRing.buildTopology(ctx, map[string]int{"a": 1, "c": 1})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestPrepareTopologyInvalid(t *testing.T) {
+	hashRing, _ := NewChecked([]string{"a"}, WithMaxNodes(2))
+	for _, desired := range []Topology{{"a": -1}, {"a": 1, "b": 1, "c": 1}} {
+		_, err := hashRing.PrepareTopology(desired).Commit()
+		assert.Error(t, err, desired)
+	}
+}
@@ -0,0 +1,35 @@
+package hashring
+
+import "time"
+
+// LastChange returns when h was derived from its previous ring, see
+// Previous, by the clock of h, see WithClock. It is zero for a ring that was
+// created rather than derived.
+func (h *HashRing) LastChange() time.Time {
+	if h == nil {
+		return time.Time{}
+	}
+	return h.changed
+}
+
+// GetNodeStale returns the node to read stringKey from when reads may lag
+// behind the last change by up to maxAge: within maxAge of the change, see
+// LastChange, the node stringKey belonged to before it, which still holds its
+// data while replicating it to the new owner; afterwards, its node on h.
+// Writes should always go to GetNode.
+//
+// A former node no longer on the ring is only returned while soft-removed,
+// see RemoveNodeSoft; otherwise the current node is returned.
+func (h *HashRing) GetNodeStale(stringKey string, maxAge time.Duration) (node string, ok bool) {
+	if h == nil || len(h.sortedKeys) == 0 {
+		return "", false
+	}
+	key := h.GenKey64(stringKey)
+	if h.prev != nil && h.config.clockOrSystem().Now().Sub(h.changed) < maxAge {
+		previous := h.prev.ownerOf(key)
+		if _, member := h.weights[previous]; member || h.tombstoned(previous) {
+			return previous, true
+		}
+	}
+	return h.ownerOf(key), true
+}
@@ -0,0 +1,61 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNodeStale(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	hashRing := New([]string{"a", "b", "c"}, WithClock(clock))
+	assert.True(t, hashRing.LastChange().IsZero())
+	clock.Advance(time.Minute)
+	added := hashRing.AddNode("d")
+	assert.Equal(t, time.Unix(60, 0), added.LastChange())
+
+	moved := 0
+	for i := 0; i < 300; i++ {
+		key := strconv.Itoa(i)
+		before, _ := hashRing.GetNode(key)
+		after, _ := added.GetNode(key)
+		if before != after {
+			moved++
+		}
+		node, ok := added.GetNodeStale(key, time.Second)
+		assert.True(t, ok)
+		assert.Equal(t, before, node, key)
+		node, _ = hashRing.GetNodeStale(key, time.Second)
+		assert.Equal(t, before, node, "a created ring has no previous owners")
+	}
+	assert.NotZero(t, moved)
+
+	clock.Advance(time.Second)
+	for i := 0; i < 300; i++ {
+		key := strconv.Itoa(i)
+		after, _ := added.GetNode(key)
+		node, _ := added.GetNodeStale(key, time.Second)
+		assert.Equal(t, after, node, key)
+	}
+
+	_, ok := New(nil).GetNodeStale("key", time.Hour)
+	assert.False(t, ok)
+}
+
+func TestGetNodeStaleRemoved(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	hashRing := New([]string{"a", "b", "c"}, WithClock(clock))
+	removed := hashRing.RemoveNode("b")
+	soft := hashRing.RemoveNodeSoft("b", time.Hour)
+	for i := 0; i < 300; i++ {
+		key := strconv.Itoa(i)
+		before, _ := hashRing.GetNode(key)
+		after, _ := removed.GetNode(key)
+		node, _ := removed.GetNodeStale(key, time.Minute)
+		assert.Equal(t, after, node, "b is gone")
+		node, _ = soft.GetNodeStale(key, time.Minute)
+		assert.Equal(t, before, node, "b is soft-removed")
+	}
+}
@@ -0,0 +1,31 @@
+package hashring
+
+// Nodes returns a copy of the nodes of h, sorted, standbys included, see
+// AddWeightedNode.
+func (h *HashRing) Nodes() []string {
+	h = h.orEmpty()
+	return sortedNodes(h.nodes)
+}
+
+// serving returns the number of nodes of h that own keys, standbys aside.
+func (h *HashRing) serving() int {
+	n := 0
+	for _, weight := range h.orEmpty().weights {
+		if weight > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// Standbys returns the nodes of h of weight 0, sorted.
+func (h *HashRing) Standbys() []string {
+	h = h.orEmpty()
+	var standbys []string
+	for _, node := range sortedNodes(h.nodes) {
+		if h.weights[node] == 0 {
+			standbys = append(standbys, node)
+		}
+	}
+	return standbys
+}
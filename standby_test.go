@@ -0,0 +1,98 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStandbyNode(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"})
+	standby := hashRing.AddWeightedNode("d", 0)
+
+	assert.Equal(t, []string{"a", "b", "c", "d"}, standby.Nodes())
+	assert.Equal(t, []string{"d"}, standby.Standbys())
+	assert.Equal(t, Topology{"a": 1, "b": 1, "c": 1, "d": 0}, standby.Topology())
+	assert.Equal(t, 4, standby.Size())
+	assert.NoError(t, standby.Validate())
+	assert.NoError(t, standby.CheckConsistency())
+	assert.Equal(t, pointsOf(hashRing), pointsOf(standby))
+	assert.Equal(t, hashRing.Imbalance(), standby.Imbalance())
+	for _, warning := range standby.HealthReport() {
+		assert.NotEqual(t, WarnNoPoints, warning.Kind)
+	}
+	node, ok := standby.GetNodeFromWeighted("test", []string{"d"})
+	assert.False(t, ok, node)
+
+	// Bringing the standby online moves keys to it alone.
+	online := standby.UpdateWeightedNode("d", 1)
+	assert.Empty(t, online.Standbys())
+	expectSameCircle(t, online, New([]string{"a", "b", "c", "d"}))
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		before, _ := standby.GetNode(key)
+		after, _ := online.GetNode(key)
+		assert.NotEqual(t, "d", before)
+		if after != before {
+			assert.Equal(t, "d", after)
+		}
+	}
+
+	// And back to standby.
+	assert.Equal(t, pointsOf(hashRing), pointsOf(online.UpdateWeightedNode("d", 0)))
+}
+
+func TestStandbyNodeWeights(t *testing.T) {
+	hashRing := NewWithWeights(map[string]int{"a": 1, "b": 0})
+	assert.Equal(t, []string{"b"}, hashRing.Standbys())
+	nodes, ok := hashRing.GetNodes("test", 2)
+	assert.False(t, ok, nodes)
+
+	_, err := hashRing.ApplyWeights(map[string]int{"a": 0, "b": 2}, false)
+	assert.NoError(t, err)
+	_, err = hashRing.ApplyWeights(map[string]int{"a": -1}, false)
+	assert.Error(t, err)
+
+	// A standby in a topology is present, not absent.
+	merged, conflicts := hashRing.ApplyTopology(Topology{"a": 1, "b": 0, "c": 0}, hashRing.Topology())
+	assert.Empty(t, conflicts)
+	assert.Equal(t, Topology{"a": 1, "b": 0, "c": 0}, merged.Topology())
+
+	assert.Equal(t, []string{"a", "b"}, hashRing.Apply(func(tx *RingTx) {
+		tx.AddWeightedNode("c", -1)
+	}).Nodes())
+}
+
+func TestStandbyLookups(t *testing.T) {
+	labels := map[string]Labels{"a": {"zone": "z1"}, "b": {"zone": "z2"}, "c": {"zone": "z1"}, "s": {"zone": "z3"}}
+	hashRing := New([]string{"a", "b", "c"}, WithNodeLabels(labels))
+	withStandby := hashRing.AddWeightedNode("s", 0)
+
+	// Standbys are not candidates, and do not count towards the size.
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		want, ok := hashRing.GetNodesWithConstraints(key, 2, MaxPerLabel("zone", 1))
+		assert.True(t, ok)
+		got, ok := withStandby.GetNodesWithConstraints(key, 2, MaxPerLabel("zone", 1))
+		assert.True(t, ok)
+		assert.Equal(t, want, got, key)
+	}
+	_, ok := withStandby.GetNodesWithConstraints("key", 3, MaxPerLabel("zone", 1))
+	assert.False(t, ok)
+
+	nodes, ok := withStandby.GetNodes("key", 4)
+	assert.False(t, ok)
+	assert.Nil(t, nodes)
+	_, err := withStandby.LookupNodes("key", 4)
+	assert.ErrorIs(t, err, ErrNotEnoughNodes)
+	assert.EqualError(t, err, "hashring: not enough nodes: 4 needed, ring has 3")
+
+	// One node and standbys still skip hashing.
+	single := New([]string{"a"}).AddWeightedNode("s", 0)
+	assert.True(t, single.single())
+	assert.False(t, withStandby.single())
+	node, ok := single.GetNode("key")
+	assert.True(t, ok)
+	assert.Equal(t, "a", node)
+}
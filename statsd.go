@@ -0,0 +1,305 @@
+package hashring
+
+import (
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxStatsdPacket keeps statsd datagrams below a typical MTU.
+const maxStatsdPacket = 1432
+
+// StatsdConfig configures a StatsdSink.
+type StatsdConfig struct {
+	// Addr is the UDP address of the statsd server, e.g. "127.0.0.1:8125".
+	Addr string
+	// Prefix is prepended to metric names, "hashring" if empty.
+	Prefix string
+	// DogStatsd tags metrics with the node ("#node:a") instead of putting the
+	// node into the metric name.
+	DogStatsd bool
+	// FlushInterval flushes lookup counts periodically, 10s if zero.
+	FlushInterval time.Duration
+}
+
+// StatsdSink is a MetricsSink emitting to statsd or dogstatsd:
+//
+//	<prefix>.lookups.<node>:<n>|c   lookups that selected node
+//	<prefix>.rebuild:<ms>|ms         duration of a rebuild
+//	<prefix>.points:<n>|g            points on the ring after a rebuild
+//	<prefix>.migration.scanned:<n>|c keys scanned by a Migrator to the ring
+//	<prefix>.migration.moved:<n>|c   keys it moved
+//
+// Lookups and migrated keys are counted in memory, see LookupCounter, and
+// flushed periodically, so neither waits on the network.
+//
+// For a named ring, see WithName, the name follows the prefix
+// ("<prefix>.<name>.lookups..."). With DogStatsd, the name and the ring's
+// labels are tags instead ("#ring:<name>,<label>:<value>").
+type StatsdSink struct {
+	conn   net.Conn
+	prefix string
+	dog    bool
+
+	lookups    *shardedCounter[statsdLookup]
+	migrations *shardedCounter[statsdMigration]
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewStatsdSink creates a StatsdSink sending to c.Addr.
+func NewStatsdSink(c StatsdConfig) (*StatsdSink, error) {
+	conn, err := net.Dial("udp", c.Addr)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := c.Prefix
+	if prefix == "" {
+		prefix = "hashring"
+	}
+	interval := c.FlushInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	s := &StatsdSink{
+		conn:       conn,
+		prefix:     prefix,
+		dog:        c.DogStatsd,
+		lookups:    newShardedCounter[statsdLookup](),
+		migrations: newShardedCounter[statsdMigration](),
+		done:       make(chan struct{}),
+	}
+	s.wg.Add(1)
+	go s.flushLoop(interval)
+	return s, nil
+}
+
+// statsdScope is the metric name and the tags of a ring's metrics.
+type statsdScope struct {
+	name, tags string
+}
+
+type statsdLookup struct {
+	scope statsdScope
+	node  string
+}
+
+type statsdMigration struct {
+	scope  statsdScope
+	metric string // "scanned" or "moved".
+}
+
+// less orders scopes by name, then tags.
+func (a statsdScope) less(b statsdScope) bool {
+	return a.name < b.name || a.name == b.name && a.tags < b.tags
+}
+
+// Lookup implements MetricsSink.
+func (s *StatsdSink) Lookup(node string) {
+	s.lookup(statsdScope{name: s.prefix}, node)
+}
+
+// Rebuild implements MetricsSink.
+func (s *StatsdSink) Rebuild(d time.Duration, points int) {
+	s.rebuild(statsdScope{name: s.prefix}, d, points)
+}
+
+// Migration implements MigrationMetricsSink.
+func (s *StatsdSink) Migration(scanned, moved int) {
+	s.migration(statsdScope{name: s.prefix}, scanned, moved)
+}
+
+// ForRing implements RingMetricsSink.
+func (s *StatsdSink) ForRing(name string, labels Labels) MetricsSink {
+	scope := statsdScope{name: s.prefix}
+	if !s.dog {
+		if name != "" {
+			scope.name += "." + statsdSanitize(name)
+		}
+		return &statsdRingSink{s, scope}
+	}
+
+	var tags []string
+	if name != "" {
+		tags = append(tags, "ring:"+statsdSanitize(name))
+	}
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		tags = append(tags, statsdSanitize(key)+":"+statsdSanitize(labels[key]))
+	}
+	scope.tags = strings.Join(tags, ",")
+	return &statsdRingSink{s, scope}
+}
+
+func (s *StatsdSink) lookup(scope statsdScope, node string) {
+	s.lookups.add(statsdLookup{scope, node}, 1)
+}
+
+func (s *StatsdSink) migration(scope statsdScope, scanned, moved int) {
+	s.migrations.add(statsdMigration{scope, "scanned"}, int64(scanned))
+	s.migrations.add(statsdMigration{scope, "moved"}, int64(moved))
+}
+
+func (s *StatsdSink) rebuild(scope statsdScope, d time.Duration, points int) {
+	tags := ""
+	if scope.tags != "" {
+		tags = "|#" + scope.tags
+	}
+	ms := strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+	s.send([]string{
+		scope.name + ".rebuild:" + ms + "|ms" + tags,
+		scope.name + ".points:" + strconv.Itoa(points) + "|g" + tags,
+	})
+}
+
+// statsdRingSink is the MetricsSink of a named ring, see StatsdSink.ForRing.
+type statsdRingSink struct {
+	s     *StatsdSink
+	scope statsdScope
+}
+
+func (r *statsdRingSink) Lookup(node string) {
+	r.s.lookup(r.scope, node)
+}
+
+func (r *statsdRingSink) Rebuild(d time.Duration, points int) {
+	r.s.rebuild(r.scope, d, points)
+}
+
+func (r *statsdRingSink) Migration(scanned, moved int) {
+	r.s.migration(r.scope, scanned, moved)
+}
+
+// Flush sends the lookup and migration counts gathered since the last flush.
+func (s *StatsdSink) Flush() error {
+	lookups := s.lookups.snapshot(true)
+
+	keys := make([]statsdLookup, 0, len(lookups))
+	for key := range lookups {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.scope != b.scope {
+			return a.scope.less(b.scope)
+		}
+		return a.node < b.node
+	})
+
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		count := strconv.FormatInt(lookups[key], 10)
+		node := statsdSanitize(key.node)
+		if s.dog {
+			tags := "node:" + node
+			if key.scope.tags != "" {
+				tags += "," + key.scope.tags
+			}
+			lines = append(lines, key.scope.name+".lookups:"+count+"|c|#"+tags)
+		} else {
+			lines = append(lines, key.scope.name+".lookups."+node+":"+count+"|c")
+		}
+	}
+	return s.send(append(lines, s.migrationLines()...))
+}
+
+// migrationLines returns the migration counts gathered since the last flush.
+func (s *StatsdSink) migrationLines() []string {
+	migrations := s.migrations.snapshot(true)
+
+	keys := make([]statsdMigration, 0, len(migrations))
+	for key := range migrations {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.scope != b.scope {
+			return a.scope.less(b.scope)
+		}
+		return a.metric > b.metric // scanned before moved.
+	})
+
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		line := key.scope.name + ".migration." + key.metric + ":" + strconv.FormatInt(migrations[key], 10) + "|c"
+		if key.scope.tags != "" {
+			line += "|#" + key.scope.tags
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Close flushes the remaining counts and closes the connection.
+func (s *StatsdSink) Close() error {
+	close(s.done)
+	s.wg.Wait()
+	err := s.Flush()
+	if cerr := s.conn.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (s *StatsdSink) flushLoop(interval time.Duration) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.Flush()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// send writes lines, packing as many as fit into each datagram.
+func (s *StatsdSink) send(lines []string) error {
+	var firstErr error
+	var packet strings.Builder
+	write := func() {
+		if packet.Len() == 0 {
+			return
+		}
+		if _, err := s.conn.Write([]byte(packet.String())); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		packet.Reset()
+	}
+
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+1+len(line) > maxStatsdPacket {
+			write()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	write()
+	return firstErr
+}
+
+// statsdSanitize replaces characters with a meaning in the statsd protocol,
+// such as the colon of "host:port" nodes.
+func statsdSanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '|', '@', '#', ',', '.', ' ', '\n':
+			return '_'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,155 @@
+package hashring
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func listenStatsd(t *testing.T) (*net.UDPConn, func() []string) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := func() []string {
+		buf := make([]byte, 65536)
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Split(string(buf[:n]), "\n")
+	}
+	return conn, read
+}
+
+func TestStatsdSink(t *testing.T) {
+	conn, read := listenStatsd(t)
+	defer conn.Close()
+
+	sink, err := NewStatsdSink(StatsdConfig{Addr: conn.LocalAddr().String(), Prefix: "cache"})
+	assert.NoError(t, err)
+	defer sink.Close()
+
+	hashRing := New([]string{"10.0.0.1:11211", "10.0.0.2:11211"}, WithMetrics(sink))
+	lines := read()
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "cache.rebuild:"))
+	assert.True(t, strings.HasSuffix(lines[0], "|ms"))
+	assert.Equal(t, "cache.points:240|g", lines[1])
+
+	sink.Lookup("10.0.0.1:11211")
+	sink.Lookup("10.0.0.1:11211")
+	sink.Lookup("10.0.0.2:11211")
+	assert.NoError(t, sink.Flush())
+	assert.Equal(t, []string{
+		"cache.lookups.10_0_0_1_11211:2|c",
+		"cache.lookups.10_0_0_2_11211:1|c",
+	}, read())
+
+	hashRing.GetNode("test")
+	assert.NoError(t, sink.Flush())
+	assert.Len(t, read(), 1)
+}
+
+func TestStatsdSinkDogStatsd(t *testing.T) {
+	conn, read := listenStatsd(t)
+	defer conn.Close()
+
+	sink, err := NewStatsdSink(StatsdConfig{Addr: conn.LocalAddr().String(), DogStatsd: true, FlushInterval: 10 * time.Millisecond})
+	assert.NoError(t, err)
+	defer sink.Close()
+
+	sink.Lookup("a")
+	assert.Equal(t, []string{"hashring.lookups:1|c|#node:a"}, read())
+}
+
+func TestStatsdSinkPackets(t *testing.T) {
+	conn, read := listenStatsd(t)
+	defer conn.Close()
+
+	sink, err := NewStatsdSink(StatsdConfig{Addr: conn.LocalAddr().String()})
+	assert.NoError(t, err)
+	defer sink.Close()
+
+	for i := 0; i < 200; i++ {
+		sink.Lookup(strings.Repeat("n", 10) + string(rune('a'+i%26)) + string(rune('a'+i/26)))
+	}
+	assert.NoError(t, sink.Flush())
+	total := 0
+	for total < 200 {
+		lines := read()
+		total += len(lines)
+	}
+	assert.Equal(t, 200, total)
+}
+
+func TestStatsdSinkNamedRing(t *testing.T) {
+	conn, read := listenStatsd(t)
+	defer conn.Close()
+
+	sink, err := NewStatsdSink(StatsdConfig{Addr: conn.LocalAddr().String()})
+	assert.NoError(t, err)
+	defer sink.Close()
+
+	hashRing := New([]string{"a"}, WithMetrics(sink), WithName("sessions"), WithRingLabels(Labels{"team": "storage"}))
+	lines := read()
+	assert.True(t, strings.HasPrefix(lines[0], "hashring.sessions.rebuild:"))
+	assert.Equal(t, "hashring.sessions.points:120|g", lines[1])
+
+	hashRing.GetNode("test")
+	sink.Lookup("a")
+	assert.NoError(t, sink.Flush())
+	assert.Equal(t, []string{
+		"hashring.lookups.a:1|c",
+		"hashring.sessions.lookups.a:1|c",
+	}, read())
+}
+
+func TestStatsdSinkDogStatsdNamedRing(t *testing.T) {
+	conn, read := listenStatsd(t)
+	defer conn.Close()
+
+	sink, err := NewStatsdSink(StatsdConfig{Addr: conn.LocalAddr().String(), DogStatsd: true})
+	assert.NoError(t, err)
+	defer sink.Close()
+
+	hashRing := New([]string{"a"}, WithMetrics(sink), WithName("sessions"), WithRingLabels(Labels{"team": "storage", "az": "1"}))
+	lines := read()
+	assert.True(t, strings.HasSuffix(lines[0], "|ms|#ring:sessions,az:1,team:storage"))
+	assert.Equal(t, "hashring.points:120|g|#ring:sessions,az:1,team:storage", lines[1])
+
+	hashRing.GetNode("test")
+	assert.NoError(t, sink.Flush())
+	assert.Equal(t, []string{"hashring.lookups:1|c|#node:a,ring:sessions,az:1,team:storage"}, read())
+}
+
+func TestStatsdSinkMigration(t *testing.T) {
+	conn, read := listenStatsd(t)
+	defer conn.Close()
+
+	sink, err := NewStatsdSink(StatsdConfig{Addr: conn.LocalAddr().String(), DogStatsd: true})
+	assert.NoError(t, err)
+	defer sink.Close()
+
+	from := New([]string{"a", "b"})
+	to := New([]string{"a", "b", "c"}, WithMetrics(sink), WithName("sessions"))
+	read()
+
+	var _ MigrationMetricsSink = sink
+	m := &Migrator{From: from, To: to, Move: func(context.Context, string, string, string) error { return nil }}
+	checkpoint, err := m.Run(context.Background(), keyStream(100), Checkpoint{})
+	assert.NoError(t, err)
+	sink.Lookup("a")
+	assert.NoError(t, sink.Flush())
+	lines := read()
+	assert.Equal(t, []string{
+		"hashring.migration.scanned:100|c|#ring:sessions",
+		"hashring.migration.moved:" + strconv.Itoa(checkpoint.Moved) + "|c|#ring:sessions",
+	}, lines[len(lines)-2:])
+}
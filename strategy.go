@@ -0,0 +1,202 @@
+package hashring
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// ConsistentHasher is the surface HashRing shares with the other algorithms
+// of the package, so applications can choose one by configuration, see
+// NewConsistentHasher. AddWeightedNode and RemoveNode leave the hasher they
+// are called on as is, and return the changed one.
+type ConsistentHasher interface {
+	NodeLocator
+	// GetNodes returns size distinct nodes for stringKey, the first being
+	// GetNode's, or false if there are fewer nodes.
+	GetNodes(stringKey string, size int) (nodes []string, ok bool)
+	// AddWeightedNode returns an error if the algorithm has no room for
+	// node, see NewConsistentHasher.
+	AddWeightedNode(node string, weight int) (ConsistentHasher, error)
+	RemoveNode(node string) ConsistentHasher
+	// Nodes returns the nodes, sorted.
+	Nodes() []string
+}
+
+// Algorithms are the algorithms of NewConsistentHasher.
+var Algorithms = []string{"ring", "rendezvous", "maglev", "jump", "anchor"}
+
+// NewConsistentHasher creates the ConsistentHasher of algorithm, one of
+// Algorithms, with the nodes and weights of weights:
+//
+//   - "ring" is a HashRing, see NewWithWeights;
+//   - "rendezvous" a Rendezvous;
+//   - "maglev" a Maglev;
+//   - "jump" a Jump over the nodes in name order;
+//   - "anchor" an Anchor with room for twice the nodes, 1024 at least.
+//
+// opts apply to the ring, and to how the others hash keys. The limits of
+// WithMaxNodes and WithMaxPoints are rejected with an error: the changes of
+// a ConsistentHasher could not report them. Jump and Anchor have no weights:
+// nodes of a positive weight are added, and standbys, of weight 0, are left
+// out as the other algorithms give them no keys. Removing a node of Jump
+// other than the last moves the keys of the nodes after it too. Adding a
+// node to a full Anchor returns an error.
+func NewConsistentHasher(algorithm string, weights map[string]int, opts ...Option) (ConsistentHasher, error) {
+	if newConfig(opts).limited() {
+		return nil, errors.New("hashring: NewConsistentHasher does not take WithMaxNodes or WithMaxPoints")
+	}
+	switch algorithm {
+	case "ring":
+		return ringHasher{NewWithWeights(weights, opts...)}, nil
+	case "rendezvous":
+		return rendezvousHasher{NewRendezvousWithWeights(weights, opts...)}, nil
+	case "maglev":
+		return maglevHasher{NewMaglevWithWeights(weights, opts...)}, nil
+	case "jump":
+		return jumpHasher{NewJump(unweighted(weights), opts...)}, nil
+	case "anchor":
+		nodes := unweighted(weights)
+		capacity := 2 * len(nodes)
+		if capacity < 1024 {
+			capacity = 1024
+		}
+		return anchorHasher{NewAnchor(capacity, nodes, opts...)}, nil
+	}
+	return nil, fmt.Errorf("hashring: unknown algorithm %q", algorithm)
+}
+
+// unweighted returns the nodes of weights of a positive weight, sorted.
+func unweighted(weights map[string]int) []string {
+	nodes := make([]string, 0, len(weights))
+	for node, weight := range weights {
+		if weight > 0 {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// saltedNodes returns size distinct nodes of the n of get for stringKey: the
+// node of stringKey, then of stringKey salted with 1, 2..., for algorithms
+// without an order of nodes of their own. It gives up after 64 lookups per
+// node asked for.
+func saltedNodes(get func(string) (string, bool), stringKey string, size, n int) ([]string, bool) {
+	if size <= 0 || size > n {
+		return nil, false
+	}
+	nodes := make([]string, 0, size)
+	seen := make(map[string]bool, size)
+	for salt := 0; len(nodes) < size && salt < 64*size; salt++ {
+		key := stringKey
+		if salt > 0 {
+			key += "\x00" + strconv.Itoa(salt)
+		}
+		node, ok := get(key)
+		if !ok {
+			return nil, false
+		}
+		if !seen[node] {
+			seen[node] = true
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, len(nodes) == size
+}
+
+type ringHasher struct{ *HashRing }
+
+func (r ringHasher) AddWeightedNode(node string, weight int) (ConsistentHasher, error) {
+	return ringHasher{r.HashRing.AddWeightedNode(node, weight)}, nil
+}
+
+func (r ringHasher) RemoveNode(node string) ConsistentHasher {
+	return ringHasher{r.HashRing.RemoveNode(node)}
+}
+
+type rendezvousHasher struct{ *Rendezvous }
+
+func (r rendezvousHasher) AddWeightedNode(node string, weight int) (ConsistentHasher, error) {
+	return rendezvousHasher{r.Rendezvous.AddWeightedNode(node, weight)}, nil
+}
+
+func (r rendezvousHasher) RemoveNode(node string) ConsistentHasher {
+	return rendezvousHasher{r.Rendezvous.RemoveNode(node)}
+}
+
+type maglevHasher struct{ *Maglev }
+
+func (m maglevHasher) GetNodes(stringKey string, size int) ([]string, bool) {
+	n := 0
+	for _, weight := range m.orEmpty().weights {
+		if weight > 0 {
+			n++
+		}
+	}
+	return saltedNodes(m.GetNode, stringKey, size, n)
+}
+
+func (m maglevHasher) AddWeightedNode(node string, weight int) (ConsistentHasher, error) {
+	return maglevHasher{m.Maglev.AddWeightedNode(node, weight)}, nil
+}
+
+func (m maglevHasher) RemoveNode(node string) ConsistentHasher {
+	return maglevHasher{m.Maglev.RemoveNode(node)}
+}
+
+type jumpHasher struct{ *Jump }
+
+func (j jumpHasher) GetNodes(stringKey string, size int) ([]string, bool) {
+	return saltedNodes(j.GetNode, stringKey, size, j.Size())
+}
+
+func (j jumpHasher) AddWeightedNode(node string, weight int) (ConsistentHasher, error) {
+	if weight <= 0 {
+		return j, nil
+	}
+	for _, n := range j.Jump.Nodes() {
+		if n == node {
+			return j, nil
+		}
+	}
+	return jumpHasher{j.Jump.AddNode(node)}, nil
+}
+
+func (j jumpHasher) RemoveNode(node string) ConsistentHasher {
+	nodes := j.Jump.Nodes()
+	for i, n := range nodes {
+		if n == node {
+			if i == len(nodes)-1 {
+				return jumpHasher{j.Jump.RemoveLastNode()}
+			}
+			return jumpHasher{j.Jump.with(append(nodes[:i:i], nodes[i+1:]...))}
+		}
+	}
+	return j
+}
+
+func (j jumpHasher) Nodes() []string {
+	return sortedNodes(j.Jump.Nodes())
+}
+
+type anchorHasher struct{ *Anchor }
+
+func (a anchorHasher) GetNodes(stringKey string, size int) ([]string, bool) {
+	return saltedNodes(a.GetNode, stringKey, size, a.Size())
+}
+
+func (a anchorHasher) AddWeightedNode(node string, weight int) (ConsistentHasher, error) {
+	if _, ok := a.Anchor.nodes[node]; ok || weight <= 0 {
+		return a, nil
+	}
+	if a.Anchor.Size() == a.Anchor.Capacity() {
+		return a, fmt.Errorf("hashring: anchor is full with %d nodes", a.Anchor.Capacity())
+	}
+	return anchorHasher{a.Anchor.AddNode(node)}, nil
+}
+
+func (a anchorHasher) RemoveNode(node string) ConsistentHasher {
+	return anchorHasher{a.Anchor.RemoveNode(node)}
+}
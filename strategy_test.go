@@ -0,0 +1,107 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewConsistentHasher(t *testing.T) {
+	weights := map[string]int{"a": 1, "b": 2, "c": 1}
+	for _, algorithm := range Algorithms {
+		c, err := NewConsistentHasher(algorithm, weights)
+		assert.NoError(t, err, algorithm)
+		assert.Equal(t, []string{"a", "b", "c"}, c.Nodes(), algorithm)
+
+		for i := 0; i < 100; i++ {
+			key := strconv.Itoa(i)
+			node, ok := c.GetNode(key)
+			assert.True(t, ok, algorithm)
+			nodes, ok := c.GetNodes(key, 3)
+			assert.True(t, ok, algorithm)
+			assert.Equal(t, node, nodes[0], algorithm)
+			assert.ElementsMatch(t, []string{"a", "b", "c"}, nodes, algorithm)
+		}
+		_, ok := c.GetNodes("test", 4)
+		assert.False(t, ok, algorithm)
+
+		added, err := c.AddWeightedNode("d", 1)
+		assert.NoError(t, err, algorithm)
+		assert.Equal(t, []string{"a", "b", "c", "d"}, added.Nodes(), algorithm)
+		assert.Equal(t, []string{"a", "b", "c"}, c.Nodes(), algorithm)
+		assert.Equal(t, []string{"a", "b", "c"}, added.RemoveNode("d").Nodes(), algorithm)
+		assert.Equal(t, []string{"a", "c"}, c.RemoveNode("b").Nodes(), algorithm)
+		same, _ := c.AddWeightedNode("a", 1)
+		assert.Equal(t, c.Nodes(), same.Nodes(), algorithm)
+		same, _ = c.AddWeightedNode("e", -1)
+		assert.Equal(t, c.Nodes(), same.Nodes(), algorithm)
+
+		// Adding a node moves keys to it only, except for the ring and
+		// Maglev, where the other nodes' points or entries shift a little.
+		if algorithm == "ring" || algorithm == "maglev" {
+			continue
+		}
+		for i := 0; i < 1000; i++ {
+			key := strconv.Itoa(i)
+			before, _ := c.GetNode(key)
+			if after, _ := added.GetNode(key); after != before {
+				assert.Equal(t, "d", after, algorithm)
+			}
+		}
+	}
+
+	_, err := NewConsistentHasher("unknown", weights)
+	assert.Error(t, err)
+}
+
+func TestConsistentHasherRing(t *testing.T) {
+	c, _ := NewConsistentHasher("ring", map[string]int{"a": 1, "b": 2}, WithHasher(XXHash64))
+	expectSameCircle(t, c.(ringHasher).HashRing, NewWithWeights(map[string]int{"a": 1, "b": 2}, WithHasher(XXHash64)))
+}
+
+func TestConsistentHasherStandbys(t *testing.T) {
+	for _, algorithm := range Algorithms {
+		c, err := NewConsistentHasher(algorithm, map[string]int{"a": 1, "b": 1, "s": 0})
+		assert.NoError(t, err, algorithm)
+		c, err = c.AddWeightedNode("t", 0)
+		assert.NoError(t, err, algorithm)
+		for i := 0; i < 1000; i++ {
+			node, _ := c.GetNode(strconv.Itoa(i))
+			assert.Contains(t, []string{"a", "b"}, node, algorithm)
+		}
+	}
+}
+
+func TestConsistentHasherJumpOptions(t *testing.T) {
+	nodes := map[string]int{"a": 1, "b": 1, "c": 1, "d": 1}
+	plain, _ := NewConsistentHasher("jump", nodes)
+	murmur, _ := NewConsistentHasher("jump", nodes, WithHasher(Murmur3))
+	added, _ := murmur.AddWeightedNode("e", 1)
+	differ := 0
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		want, _ := NewJump([]string{"a", "b", "c", "d"}, WithHasher(Murmur3)).GetNode(key)
+		node, _ := murmur.GetNode(key)
+		assert.Equal(t, want, node, key)
+		if other, _ := plain.GetNode(key); other != node {
+			differ++
+		}
+		if after, _ := added.GetNode(key); after != node {
+			assert.Equal(t, "e", after, "an added bucket hashes keys alike")
+		}
+	}
+	assert.NotZero(t, differ)
+}
+
+func TestConsistentHasherAnchorFull(t *testing.T) {
+	c, _ := NewConsistentHasher("anchor", map[string]int{"a": 1})
+	var err error
+	for i := 1; i < 1024; i++ {
+		c, err = c.AddWeightedNode(strconv.Itoa(i), 1)
+		assert.NoError(t, err)
+	}
+	full, err := c.AddWeightedNode("full", 1)
+	assert.EqualError(t, err, "hashring: anchor is full with 1024 nodes")
+	assert.Len(t, full.Nodes(), 1024)
+}
@@ -0,0 +1,134 @@
+package hashring
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"strconv"
+)
+
+// LookupTable is the continuum of a ring with how keys are hashed onto it, as
+// a standalone artifact: clients that cannot depend on this package route
+// keys like the ring by hashing them with Hasher and taking the first point
+// after, or at or after for Boundary "at-or-after", wrapping around.
+//
+// Its JSON encoding is the artifact of the hashring stub command.
+type LookupTable struct {
+	// Hasher is "md5" or "crc32", whose first 4 bytes, or 8 for KeyBits 64,
+	// are read little-endian, or "xxhash64" or "murmur3", see Audit.
+	Hasher   string `json:"hasher"`
+	KeyBits  int    `json:"key_bits"`
+	Boundary string `json:"boundary"`
+	// Points are sorted; Owners holds the index in Nodes of each of them.
+	Points []HashKey64 `json:"points"`
+	Owners []int32     `json:"owners"`
+	Nodes  []string    `json:"nodes"`
+}
+
+// LookupTable returns the lookup table of h.
+func (h *HashRing) LookupTable() LookupTable {
+	h = h.orEmpty()
+	a := h.Audit()
+	return LookupTable{
+		Hasher:   a.Hasher,
+		KeyBits:  a.KeyBits,
+		Boundary: a.Boundary,
+		Points:   append([]HashKey64{}, h.sortedKeys...),
+		Owners:   append([]int32{}, h.owners...),
+		Nodes:    append([]string{}, h.names...),
+	}
+}
+
+// GetNode returns the node stringKey belongs to by t, the reference of how
+// clients route by a lookup table. It returns false for a custom Hasher.
+func (t LookupTable) GetNode(stringKey string) (node string, ok bool) {
+	var c config
+	switch t.Hasher {
+	case "md5":
+	case "crc32":
+		c.hasher = crc32Hasher{}
+	case "xxhash64":
+		c.hasher = XXHash64
+	case "murmur3":
+		c.hasher = Murmur3
+	default:
+		return "", false
+	}
+	c.wide = t.KeyBits == 64
+	if t.Boundary == "at-or-after" {
+		c.boundary = BoundaryAtOrAfter
+	}
+	h := &HashRing{sortedKeys: t.Points, owners: t.Owners, names: t.Nodes, config: c}
+	return h.GetNode(stringKey)
+}
+
+// WriteGoStub writes Go source of package pkg with the lookup table of h and
+// a GetNode function routing keys like h, using the standard library only.
+// Only rings hashing keys with md5 or crc32, see WithNginxConsistent, can be
+// written.
+func (h *HashRing) WriteGoStub(w io.Writer, pkg string) error {
+	t := h.LookupTable()
+	var hash, hashImport string
+	switch t.Hasher {
+	case "md5":
+		hash, hashImport = "sum := md5.Sum([]byte(key))\n\tdigest := sum[:]", "crypto/md5"
+	case "crc32":
+		hash, hashImport = "digest := binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE([]byte(key)))", "hash/crc32"
+	default:
+		return fmt.Errorf("hashring: cannot write a Go stub of a ring hashing keys with %s", t.Hasher)
+	}
+	position := "uint64(binary.LittleEndian.Uint32(digest))"
+	if t.KeyBits == 64 {
+		position = "binary.LittleEndian.Uint64(digest)"
+	}
+	search := "points[i] > position"
+	if t.Boundary == "at-or-after" {
+		search = "points[i] >= position"
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by hashring stub; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "// Package %s routes keys like the ring it was generated from, fingerprint\n// %s.\n", pkg, h.Audit().Fingerprint())
+	fmt.Fprintf(&b, "package %s\n\nimport (\n\t%q\n\t\"encoding/binary\"\n\t\"sort\"\n)\n\n", pkg, hashImport)
+	fmt.Fprintf(&b, "// Nodes are the nodes of the ring.\nvar Nodes = []string{")
+	for _, node := range t.Nodes {
+		fmt.Fprintf(&b, "\n\t%s,", strconv.Quote(node))
+	}
+	fmt.Fprintf(&b, "\n}\n\nvar points = []uint64{")
+	for i, point := range t.Points {
+		if i%8 == 0 {
+			b.WriteString("\n\t")
+		}
+		fmt.Fprintf(&b, "%d, ", point)
+	}
+	fmt.Fprintf(&b, "\n}\n\nvar owners = []int32{")
+	for i, owner := range t.Owners {
+		if i%16 == 0 {
+			b.WriteString("\n\t")
+		}
+		fmt.Fprintf(&b, "%d, ", owner)
+	}
+	fmt.Fprintf(&b, "\n}\n\n")
+	fmt.Fprintf(&b, `// GetNode returns the node key belongs to, or false if the ring is empty.
+func GetNode(key string) (string, bool) {
+	if len(points) == 0 {
+		return "", false
+	}
+	%s
+	position := %s
+	i := sort.Search(len(points), func(i int) bool { return %s })
+	if i == len(points) {
+		i = 0
+	}
+	return Nodes[owners[i]], true
+}
+`, hash, position, search)
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return fmt.Errorf("hashring: generated invalid Go: %v", err)
+	}
+	_, err = w.Write(src)
+	return err
+}
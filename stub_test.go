@@ -0,0 +1,62 @@
+package hashring
+
+import (
+	"encoding/json"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupTable(t *testing.T) {
+	weights := map[string]int{"a": 1, "b": 2, "c": 1}
+	rings := map[string]*HashRing{
+		"md5":      NewWithWeights(weights),
+		"64-bit":   NewWithWeights(weights, With64BitKeys()),
+		"xxhash64": NewWithWeights(weights, WithHasher(XXHash64)),
+		"murmur3":  NewWithWeights(weights, WithHasher(Murmur3), With64BitKeys()),
+		"nginx":    NewWithWeights(weights, WithNginxConsistent()),
+	}
+	for name, hashRing := range rings {
+		data, err := json.Marshal(hashRing.LookupTable())
+		assert.NoError(t, err)
+		var table LookupTable
+		assert.NoError(t, json.Unmarshal(data, &table))
+		for i := 0; i < 1000; i++ {
+			key := strconv.Itoa(i)
+			node, ok := table.GetNode(key)
+			assert.True(t, ok)
+			expectNode(t, hashRing, key, node)
+		}
+		assert.Equal(t, hashRing.Audit().Boundary, table.Boundary, name)
+	}
+
+	custom := New([]string{"a"}, WithHasher(HasherFunc(func(key []byte) []byte { return key })))
+	_, ok := custom.LookupTable().GetNode("test")
+	assert.False(t, ok)
+	_, ok = New(nil).LookupTable().GetNode("test")
+	assert.False(t, ok)
+}
+
+func TestWriteGoStub(t *testing.T) {
+	for _, hashRing := range []*HashRing{
+		New([]string{"a", "b", "c"}),
+		New([]string{"a", "b", "c"}, WithNginxConsistent()),
+	} {
+		var b strings.Builder
+		assert.NoError(t, hashRing.WriteGoStub(&b, "routes"))
+		src := b.String()
+		_, err := parser.ParseFile(token.NewFileSet(), "routes.go", src, 0)
+		assert.NoError(t, err)
+		assert.Contains(t, src, "package routes\n")
+		assert.Contains(t, src, hashRing.Audit().Fingerprint())
+		assert.Contains(t, src, "func GetNode(key string) (string, bool) {")
+		assert.Equal(t, len(hashRing.sortedKeys), strings.Count(src[strings.Index(src, "var points"):strings.Index(src, "var owners")], ","))
+	}
+
+	var b strings.Builder
+	assert.Error(t, New([]string{"a"}, WithHasher(XXHash64)).WriteGoStub(&b, "routes"))
+}
@@ -0,0 +1,80 @@
+package hashring
+
+import "math"
+
+// WithTenantWeights gives tenant its own weights of the nodes, see
+// GetNodeForTenant, e.g. node "a" serves tenant "x" at weight 2 and tenant
+// "y" at weight 1. Nodes missing from weights do not serve tenant.
+func WithTenantWeights(tenant string, weights map[string]int) Option {
+	return func(c *config) {
+		c.tenants = withTenant(c.tenants, tenant, weights)
+	}
+}
+
+// withTenant returns a copy of tenants with the weights of tenant.
+func withTenant(tenants map[string]map[string]int, tenant string, weights map[string]int) map[string]map[string]int {
+	next := make(map[string]map[string]int, len(tenants)+1)
+	for t, w := range tenants {
+		next[t] = w
+	}
+	own := make(map[string]int, len(weights))
+	for node, weight := range weights {
+		if weight > 0 {
+			own[node] = weight
+		}
+	}
+	next[tenant] = own
+	return next
+}
+
+// UpdateTenantWeights sets the weights of tenant, see WithTenantWeights, and
+// returns the new HashRing. The points, and so GetNode, are left as is, and
+// the keys of the other tenants do not move. The keys of tenant only move to
+// or from the nodes whose weights changed.
+func (h *HashRing) UpdateTenantWeights(tenant string, weights map[string]int) *HashRing {
+	h = h.orEmpty()
+	next := *h
+	next.config.tenants = withTenant(h.config.tenants, tenant, weights)
+	return &next
+}
+
+// TenantWeights returns the weights of the nodes for tenant, see
+// WithTenantWeights, or nil if tenant has none.
+func (h *HashRing) TenantWeights(tenant string) map[string]int {
+	h = h.orEmpty()
+	own, ok := h.config.tenants[tenant]
+	if !ok {
+		return nil
+	}
+	weights := make(map[string]int, len(own))
+	for node, weight := range own {
+		weights[node] = weight
+	}
+	return weights
+}
+
+// GetNodeForTenant returns the node of tenant stringKey belongs to, choosing
+// among the nodes of the ring that serve tenant in proportion to the weights
+// of tenant, like GetNodeFromWeighted does with the weights of the ring. All
+// tenants share the ring, so a tenant costs a map of weights, not a ring of
+// its own.
+//
+// Standbys and nodes not on the ring do not serve tenant. It returns false
+// if no node serves tenant.
+func (h *HashRing) GetNodeForTenant(tenant, stringKey string) (node string, ok bool) {
+	if h == nil || len(h.sortedKeys) == 0 {
+		return "", false
+	}
+
+	best := math.Inf(-1)
+	for n, weight := range h.config.tenants[tenant] {
+		if h.weights[n] == 0 {
+			continue
+		}
+		score := h.rendezvousScore(stringKey, h.identity(n), weight)
+		if !ok || score > best || score == best && n < node {
+			node, best, ok = n, score, true
+		}
+	}
+	return node, ok
+}
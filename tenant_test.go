@@ -0,0 +1,61 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNodeForTenant(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"},
+		WithTenantWeights("x", map[string]int{"a": 2, "b": 1}),
+		WithTenantWeights("y", map[string]int{"b": 1, "c": 1, "z": 5}))
+
+	counts := map[string]map[string]int{"x": {}, "y": {}}
+	for i := 0; i < 3000; i++ {
+		key := strconv.Itoa(i)
+		for tenant := range counts {
+			node, ok := hashRing.GetNodeForTenant(tenant, key)
+			assert.True(t, ok)
+			counts[tenant][node]++
+		}
+	}
+	assert.Len(t, counts["x"], 2)
+	assert.InDelta(t, 2000, counts["x"]["a"], 150)
+	assert.Len(t, counts["y"], 2)
+	assert.InDelta(t, 1500, counts["y"]["b"], 150)
+
+	_, ok := hashRing.GetNodeForTenant("unknown", "test")
+	assert.False(t, ok)
+
+	// Derived rings keep the tenants; removed nodes no longer serve them.
+	removed := hashRing.RemoveNode("a")
+	for i := 0; i < 100; i++ {
+		node, _ := removed.GetNodeForTenant("x", strconv.Itoa(i))
+		assert.Equal(t, "b", node)
+	}
+}
+
+func TestUpdateTenantWeights(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"}, WithTenantWeights("x", map[string]int{"a": 1, "b": 1}))
+	updated := hashRing.UpdateTenantWeights("x", map[string]int{"a": 1, "b": 1, "c": 1}).
+		UpdateTenantWeights("y", map[string]int{"c": 1})
+
+	assert.Equal(t, map[string]int{"a": 1, "b": 1}, hashRing.TenantWeights("x"))
+	assert.Equal(t, map[string]int{"a": 1, "b": 1, "c": 1}, updated.TenantWeights("x"))
+	assert.Nil(t, hashRing.TenantWeights("y"))
+	assert.Equal(t, pointsOf(hashRing), pointsOf(updated))
+
+	// Only keys moving to the new node move.
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		before, _ := hashRing.GetNodeForTenant("x", key)
+		after, _ := updated.GetNodeForTenant("x", key)
+		if after != before {
+			assert.Equal(t, "c", after)
+		}
+		node, _ := updated.GetNodeForTenant("y", key)
+		assert.Equal(t, "c", node)
+	}
+}
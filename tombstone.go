@@ -0,0 +1,90 @@
+package hashring
+
+import (
+	"sort"
+	"time"
+)
+
+// tombstone is a soft-removed node, with the ring it was removed from.
+type tombstone struct {
+	node    string
+	ring    *HashRing
+	expires time.Time
+}
+
+// RemoveNodeSoft removes node from ring like RemoveNode, but keeps it as a
+// tombstone for gracePeriod, timed by the clock of WithClock: it no longer
+// owns keys, but GetPreviousOwner still returns it for the keys it owned,
+// even after further changes, so reads can fall back to it while its data is
+// moved away.
+//
+// The tombstone is kept by rings derived from the result, until it expires or
+// node is added again.
+func (h *HashRing) RemoveNodeSoft(node string, gracePeriod time.Duration) *HashRing {
+	h = h.orEmpty()
+	hashRing := h.RemoveNode(node)
+	if hashRing == h || gracePeriod <= 0 {
+		return hashRing
+	}
+
+	hashRing.tombstones = append(hashRing.tombstones, tombstone{
+		node:    node,
+		ring:    h.retained(),
+		expires: h.config.clockOrSystem().Now().Add(gracePeriod),
+	})
+	return hashRing
+}
+
+// Tombstones returns the soft-removed nodes whose grace period has not
+// expired, sorted.
+func (h *HashRing) Tombstones() []string {
+	nodes := make([]string, 0)
+	if h == nil {
+		return nodes
+	}
+	now := h.config.clockOrSystem().Now()
+	for _, t := range h.tombstones {
+		if now.Before(t.expires) {
+			nodes = append(nodes, t.node)
+		}
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// tombstoned returns whether node is a soft-removed node of h whose grace
+// period has not expired.
+func (h *HashRing) tombstoned(node string) bool {
+	now := h.config.clockOrSystem().Now()
+	for _, t := range h.tombstones {
+		if t.node == node && now.Before(t.expires) {
+			return true
+		}
+	}
+	return false
+}
+
+// tombstoneOwner returns the soft-removed node that key belonged to, latest
+// removals first, "" if none.
+func (h *HashRing) tombstoneOwner(key HashKey64) string {
+	now := h.config.clockOrSystem().Now()
+	for i := len(h.tombstones) - 1; i >= 0; i-- {
+		t := h.tombstones[i]
+		if now.Before(t.expires) && t.ring.ownerOf(key) == t.node {
+			return t.node
+		}
+	}
+	return ""
+}
+
+// liveTombstones returns the tombstones of h that still apply to next.
+func (h *HashRing) liveTombstones(next *HashRing) []tombstone {
+	now := h.config.clockOrSystem().Now()
+	var tombstones []tombstone
+	for _, t := range h.tombstones {
+		if _, readded := next.weights[t.node]; !readded && now.Before(t.expires) {
+			tombstones = append(tombstones, t)
+		}
+	}
+	return tombstones
+}
@@ -0,0 +1,70 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoveNodeSoft(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"})
+	removed := hashRing.RemoveNodeSoft("b", time.Hour)
+	expectSameCircle(t, removed, New([]string{"a", "c"}))
+	assert.Equal(t, []string{"b"}, removed.Tombstones())
+
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		owner, _ := hashRing.GetNode(key)
+		previous, ok := removed.GetPreviousOwner(key)
+		assert.True(t, ok)
+		assert.Equal(t, owner, previous)
+	}
+
+	// Tombstones survive further changes, until the node is added again.
+	// The latest removal wins.
+	added := removed.AddNode("d")
+	derived := added.RemoveNodeSoft("a", time.Hour)
+	assert.Equal(t, []string{"a", "b"}, derived.Tombstones())
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		original, _ := hashRing.GetNode(key)
+		owner, _ := added.GetNode(key)
+		previous, ok := derived.GetPreviousOwner(key)
+		assert.True(t, ok)
+		switch {
+		case owner == "a":
+			assert.Equal(t, "a", previous)
+		case original == "b":
+			assert.Equal(t, "b", previous)
+		default:
+			assert.Equal(t, owner, previous)
+		}
+	}
+	assert.Equal(t, []string{"a"}, derived.AddNode("b").Tombstones())
+}
+
+func TestRemoveNodeSoftExpired(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	removed := New([]string{"a", "b"}, WithClock(clock)).RemoveNodeSoft("b", time.Hour)
+	clock.Advance(time.Hour - time.Second)
+	assert.Equal(t, []string{"b"}, removed.Tombstones())
+	clock.Advance(time.Second)
+	assert.Empty(t, removed.Tombstones())
+	for i := 0; i < 100; i++ {
+		previous, _ := removed.GetPreviousOwner(strconv.Itoa(i))
+		assert.NotEqual(t, "b", previous)
+	}
+	assert.Empty(t, removed.AddNode("c").tombstones)
+
+	// No grace period, or an unknown node, is a plain RemoveNode.
+	assert.Empty(t, New([]string{"a", "b"}).RemoveNodeSoft("b", 0).Tombstones())
+	hashRing := New([]string{"a"})
+	assert.Equal(t, hashRing, hashRing.RemoveNodeSoft("z", time.Hour))
+
+	var nilRing *HashRing
+	_, ok := nilRing.GetPreviousOwner("test")
+	assert.False(t, ok)
+	assert.Empty(t, nilRing.Tombstones())
+}
@@ -0,0 +1,99 @@
+package hashring
+
+// Topology is the nodes of a ring with their weights.
+type Topology map[string]int
+
+// Topology returns the nodes of h with their weights.
+func (h *HashRing) Topology() Topology {
+	h = h.orEmpty()
+	t := make(Topology, len(h.weights))
+	for node, weight := range h.weights {
+		t[node] = weight
+	}
+	return t
+}
+
+// Weights returns a copy of the weights of the nodes of h, standbys
+// included with weight 0, e.g. to log the topology a ring holds.
+func (h *HashRing) Weights() map[string]int {
+	return map[string]int(h.Topology())
+}
+
+// Weight returns the weight of node, and whether node is on h.
+func (h *HashRing) Weight(node string) (weight int, ok bool) {
+	if h == nil {
+		return 0, false
+	}
+	weight, ok = h.weights[node]
+	return weight, ok
+}
+
+// TopologyConflict is a node that both the ring and a desired topology
+// changed since their base, to different weights. A weight of -1 means the
+// node is absent, 0 that it is a standby, see AddWeightedNode.
+type TopologyConflict struct {
+	Node                 string
+	Base, Local, Desired int
+}
+
+// ApplyTopology merges desired into h, and returns the new HashRing. base is
+// the topology desired was derived from, e.g. the result of Topology when a
+// controller last read the ring.
+//
+// A node changed by desired alone since base takes its desired weight, or is
+// added or removed. A node changed by h alone keeps its weight in h, so
+// changes made meanwhile, e.g. by an operator, are not undone. A node changed
+// by both to different weights keeps its weight in h and is reported as a
+// conflict. Conflicts are sorted by node.
+//
+// All changes are placed at once, see Apply. Like Apply, ApplyTopology
+// panics on a ring with limits, see ApplyTopologyChecked.
+func (h *HashRing) ApplyTopology(desired, base Topology) (*HashRing, []TopologyConflict) {
+	h.orEmpty().config.mustBeUnlimited("ApplyTopology")
+	next, conflicts, _ := h.ApplyTopologyChecked(desired, base)
+	return next, conflicts
+}
+
+// ApplyTopologyChecked merges desired into h like ApplyTopology, or returns
+// a LimitError with h and the conflicts if the merge takes h over its limits.
+func (h *HashRing) ApplyTopologyChecked(desired, base Topology) (*HashRing, []TopologyConflict, error) {
+	local := h.Topology()
+	nodes := make([]string, 0, len(local)+len(desired))
+	for _, t := range []Topology{local, desired, base} {
+		for node := range t {
+			nodes = append(nodes, node)
+		}
+	}
+
+	var conflicts []TopologyConflict
+	next, err := h.ApplyChecked(func(tx *RingTx) {
+		for _, node := range sortedNodes(nodes) {
+			b, l, d := base.weight(node), local.weight(node), desired.weight(node)
+			weight := d
+			if l != b {
+				weight = l
+				if d != b && d != l {
+					conflicts = append(conflicts, TopologyConflict{Node: node, Base: b, Local: l, Desired: d})
+				}
+			}
+
+			switch {
+			case weight < 0:
+				tx.RemoveNode(node)
+			case l < 0:
+				tx.AddWeightedNode(node, weight)
+			default:
+				tx.UpdateWeightedNode(node, weight)
+			}
+		}
+	})
+	return next, conflicts, err
+}
+
+// weight returns the weight of node in t, or -1 if node is absent.
+func (t Topology) weight(node string) int {
+	if weight, ok := t[node]; ok {
+		return weight
+	}
+	return -1
+}
@@ -0,0 +1,60 @@
+package hashring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyTopology(t *testing.T) {
+	base := Topology{"a": 1, "b": 1, "c": 1, "d": 1, "e": 1}
+	hashRing := NewWithWeights(base)
+	assert.Equal(t, base, hashRing.Topology())
+
+	// Meanwhile, an operator drains e and adds f.
+	local := hashRing.RemoveNode("e").AddWeightedNode("f", 2).UpdateWeightedNode("c", 3)
+	desired := Topology{"a": 2, "b": 1, "c": 4, "d": 1, "e": 1, "g": 1}
+
+	merged, conflicts := local.ApplyTopology(desired, base)
+	assert.Equal(t, Topology{"a": 2, "b": 1, "c": 3, "d": 1, "f": 2, "g": 1}, merged.Topology())
+	assert.Equal(t, []TopologyConflict{{Node: "c", Base: 1, Local: 3, Desired: 4}}, conflicts)
+	expectSameCircle(t, merged, NewWithWeights(map[string]int{"a": 2, "b": 1, "c": 3, "d": 1, "f": 2, "g": 1}))
+
+	// Both sides removing a node, or agreeing on a weight, is no conflict.
+	merged, conflicts = local.ApplyTopology(Topology{"a": 1, "b": 1, "c": 3, "d": 1}, base)
+	assert.Equal(t, Topology{"a": 1, "b": 1, "c": 3, "d": 1, "f": 2}, merged.Topology())
+	assert.Empty(t, conflicts)
+
+	// Adding the same node with different weights conflicts.
+	_, conflicts = local.ApplyTopology(Topology{"a": 1, "b": 1, "c": 1, "d": 1, "e": 1, "f": 1}, base)
+	assert.Equal(t, []TopologyConflict{{Node: "f", Base: -1, Local: 2, Desired: 1}}, conflicts)
+
+	// Without changes on either side, the ring is left as is.
+	merged, _ = hashRing.ApplyTopology(base, base)
+	assert.Same(t, hashRing, merged)
+}
+
+func TestWeights(t *testing.T) {
+	hashRing := New([]string{"a", "b", "a"}).AddWeightedNode("s", 0)
+	weights := hashRing.Weights()
+	assert.Equal(t, map[string]int{"a": 2, "b": 1, "s": 0}, weights)
+	nodes := hashRing.Nodes()
+	assert.Equal(t, []string{"a", "b", "s"}, nodes)
+
+	// The copies do not change the ring.
+	weights["a"], nodes[0] = 5, "z"
+	assert.Equal(t, map[string]int{"a": 2, "b": 1, "s": 0}, hashRing.Weights())
+	assert.Equal(t, []string{"a", "b", "s"}, hashRing.Nodes())
+
+	weight, ok := hashRing.Weight("a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, weight)
+	_, ok = hashRing.Weight("unknown")
+	assert.False(t, ok)
+
+	var empty *HashRing
+	assert.Empty(t, empty.Weights())
+	assert.Empty(t, empty.Nodes())
+	_, ok = empty.Weight("a")
+	assert.False(t, ok)
+}
@@ -0,0 +1,29 @@
+package hashring
+
+import "time"
+
+// GetUpToNodes returns size nodes like GetNodes, or all the nodes with points
+// if the ring has fewer, so a replication factor larger than a shrunken
+// cluster degrades to fewer replicas instead of none. ok is false only if
+// the ring is empty or size is not positive.
+func (h *HashRing) GetUpToNodes(stringKey string, size int) (nodes []string, ok bool) {
+	if size <= 0 {
+		return nil, false
+	}
+	if h.intercepted() {
+		defer h.afterLookups(stringKey, h.beforeLookup(stringKey), &nodes, &ok)
+	}
+	if h.sampled() {
+		defer h.observeLookup(time.Now())
+	}
+
+	pos, ok := h.GetNodePos(stringKey)
+	if !ok {
+		return nil, false
+	}
+	nodes, _ = h.walk(pos, size)
+	if h.config.metrics != nil {
+		h.config.metrics.Lookup(nodes[0])
+	}
+	return nodes, true
+}
@@ -0,0 +1,31 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUpToNodes(t *testing.T) {
+	hashRing := NewWithWeights(map[string]int{"a": 1, "b": 1, "s": 0})
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		all, _ := hashRing.GetNodes(key, 2)
+
+		nodes, ok := hashRing.GetUpToNodes(key, 1)
+		assert.True(t, ok)
+		assert.Equal(t, all[:1], nodes)
+		// More than the nodes with points gives them all.
+		for _, size := range []int{2, 3, 10} {
+			nodes, ok = hashRing.GetUpToNodes(key, size)
+			assert.True(t, ok)
+			assert.Equal(t, all, nodes)
+		}
+	}
+
+	_, ok := hashRing.GetUpToNodes("key", 0)
+	assert.False(t, ok)
+	_, ok = New(nil).GetUpToNodes("key", 3)
+	assert.False(t, ok)
+}
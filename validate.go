@@ -0,0 +1,51 @@
+package hashring
+
+import "fmt"
+
+// Validate checks the internal invariants of h: sortedKeys is sorted and
+// unique, every point has a node of h, no node has a negative weight, and
+// standbys own no points.
+//
+// Rings built by this package are always valid. Validate is meant for
+// wrappers and decoders asserting integrity after rebuilding a ring.
+func (h *HashRing) Validate() error {
+	h = h.orEmpty()
+	for i, key := range h.sortedKeys {
+		if i > 0 && key <= h.sortedKeys[i-1] {
+			return fmt.Errorf("hashring: sorted keys out of order at %d: %d after %d", i, key, h.sortedKeys[i-1])
+		}
+	}
+	if len(h.owners) != len(h.sortedKeys) {
+		return fmt.Errorf("hashring: %d owners for %d sorted keys", len(h.owners), len(h.sortedKeys))
+	}
+
+	nodes := make(map[string]bool, len(h.nodes))
+	for _, node := range h.nodes {
+		nodes[node] = true
+		weight, ok := h.weights[node]
+		if !ok {
+			return fmt.Errorf("hashring: node %q has no weight", node)
+		}
+		if weight < 0 {
+			return fmt.Errorf("hashring: node %q has weight %d", node, weight)
+		}
+	}
+	for node := range h.weights {
+		if !nodes[node] {
+			return fmt.Errorf("hashring: weight of unknown node %q", node)
+		}
+	}
+	for pos, key := range h.sortedKeys {
+		if owner := h.owners[pos]; owner < 0 || int(owner) >= len(h.names) {
+			return fmt.Errorf("hashring: point %d has no node", key)
+		}
+		node := h.ownerAt(pos)
+		if !nodes[node] {
+			return fmt.Errorf("hashring: point %d belongs to unknown node %q", key, node)
+		}
+		if h.weights[node] == 0 {
+			return fmt.Errorf("hashring: point %d belongs to standby %q", key, node)
+		}
+	}
+	return nil
+}
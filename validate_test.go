@@ -0,0 +1,56 @@
+package hashring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	hashRing := New([]string{"a", "b", "c"})
+	assert.NoError(t, hashRing.Validate())
+	assert.NoError(t, hashRing.AddWeightedNode("d", 3).RemoveNode("a").UpdateWeightedNode("b", 2).Validate())
+	assert.NoError(t, New([]string{"a", "a", "b"}).Validate())
+	assert.NoError(t, New([]string{}).Validate())
+	assert.NoError(t, New([]string{"a", "b"}, WithTargetImbalance(0.05)).Validate())
+
+	hashRing.UpdateWithWeights(map[string]int{"a": 1, "c": 4, "e": 2})
+	assert.NoError(t, hashRing.Validate())
+}
+
+func TestValidateCorrupted(t *testing.T) {
+	corrupt := map[string]func(h *HashRing){
+		"unsorted": func(h *HashRing) {
+			h.sortedKeys[0], h.sortedKeys[1] = h.sortedKeys[1], h.sortedKeys[0]
+		},
+		"duplicate key": func(h *HashRing) {
+			h.sortedKeys = append(h.sortedKeys, h.sortedKeys[len(h.sortedKeys)-1])
+		},
+		"owner out of range": func(h *HashRing) {
+			h.owners[0] = int32(len(h.names))
+		},
+		"missing owner": func(h *HashRing) {
+			h.owners = h.owners[1:]
+		},
+		"unknown owner": func(h *HashRing) {
+			h.names[h.owners[0]] = "z"
+		},
+		"missing weight": func(h *HashRing) {
+			delete(h.weights, "a")
+		},
+		"negative weight": func(h *HashRing) {
+			h.weights["a"] = -1
+		},
+		"standby with points": func(h *HashRing) {
+			h.weights["a"] = 0
+		},
+		"unknown weight": func(h *HashRing) {
+			h.weights["z"] = 1
+		},
+	}
+	for name, f := range corrupt {
+		hashRing := New([]string{"a", "b"})
+		f(hashRing)
+		assert.Error(t, hashRing.Validate(), name)
+	}
+}
@@ -0,0 +1,16 @@
+package hashring
+
+// With64BitKeys places keys and virtual nodes on a 64-bit ring instead of the
+// default 32-bit one, see HashKey64 and GenKey64.
+//
+// With many nodes and virtual nodes, 32-bit points start to collide and
+// leave uneven arcs; 64-bit points practically never collide. A virtual node
+// places a point per 8 bytes of its digest, up to 2, so md5 gives 2 points per
+// virtual node instead of 3, and custom hashers must return at least 8 bytes.
+//
+// Rings with 64-bit keys place keys differently from 32-bit ones.
+func With64BitKeys() Option {
+	return func(c *config) {
+		c.wide = true
+	}
+}
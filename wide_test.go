@@ -0,0 +1,68 @@
+package hashring
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWith64BitKeys(t *testing.T) {
+	nodes := []string{"a", "b", "c"}
+	hashRing := New(nodes, With64BitKeys())
+	assert.NoError(t, hashRing.Validate())
+	assert.Len(t, hashRing.sortedKeys, 3*defaultReplicas*2)
+	assert.True(t, hashRing.sortedKeys[len(hashRing.sortedKeys)-1] > math.MaxUint32)
+
+	sum := md5.Sum([]byte("test"))
+	assert.Equal(t, HashKey64(binary.LittleEndian.Uint64(sum[:8])), hashRing.GenKey64("test"))
+	assert.Equal(t, HashKey(hashRing.GenKey64("test")>>32), hashRing.GenKey("test"))
+
+	// A 32-bit ring's positions are its HashKeys.
+	narrow := New(nodes)
+	assert.Equal(t, HashKey64(narrow.GenKey("test")), narrow.GenKey64("test"))
+
+	// Every node owns keys, in proportion to its weight.
+	counts := make(map[string]int)
+	for i := 0; i < 3000; i++ {
+		node, ok := hashRing.GetNode(strconv.Itoa(i))
+		assert.True(t, ok)
+		counts[node]++
+	}
+	for _, node := range nodes {
+		assert.InDelta(t, 1000, counts[node], 250, node)
+	}
+	assert.Empty(t, hashRing.HealthReport())
+	assert.True(t, hashRing.Imbalance() < 0.3)
+}
+
+func TestWith64BitKeysDerived(t *testing.T) {
+	hashRing := NewWithWeights(map[string]int{"a": 1, "b": 2, "c": 1}, With64BitKeys())
+
+	updated := hashRing.UpdateWeightedNode("b", 3)
+	expectSameCircle(t, updated, NewWithWeights(map[string]int{"a": 1, "b": 3, "c": 1}, With64BitKeys()))
+
+	even := New([]string{"a", "b", "c"}, With64BitKeys())
+	added := even.AddNode("d")
+	d := Diff(even, added)
+	assert.InDelta(t, added.ownership()["d"], d.Churn, 1e-9)
+
+	nodes, ok := added.GetNodes("test", 4)
+	assert.True(t, ok)
+	assert.ElementsMatch(t, []string{"a", "b", "c", "d"}, nodes)
+
+	// Integer and Hashable keys land on the 64-bit ring as well.
+	_, ok = added.GetNodeUint64(42)
+	assert.True(t, ok)
+	node, _ := added.GetNodeFor(objectKey{tenant: "t", object: "test"})
+	expected, _ := added.GetNode("t/test@0")
+	assert.Equal(t, expected, node)
+
+	mixed := New([]string{"a", "b"}, With64BitKeys(), WithUint64Mixer(Mix64))
+	assert.Equal(t, Mix64(42), mixed.GenKeyUint64(42))
+	_, ok = mixed.GetNodeUint64(42)
+	assert.True(t, ok)
+}
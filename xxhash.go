@@ -0,0 +1,101 @@
+package hashring
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// XXHash64 is a Hasher using xxHash64 (seed 0), a non-cryptographic hash
+// several times faster than md5, for lookup-heavy uses such as cache
+// sharding:
+//
+//	ring := hashring.New(nodes, hashring.WithHasher(hashring.XXHash64))
+//
+// Its digest is the 8 little-endian bytes of the hash, so a virtual node
+// places 2 points, or 1 with 64-bit keys. Lookups hash keys without
+// allocating.
+var XXHash64 Hasher = xxHash64{}
+
+type xxHash64 struct{}
+
+func (xxHash64) Hash(key []byte) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, xxh64(key))
+	return b
+}
+
+const (
+	xxPrime1 uint64 = 11400714785074694791
+	xxPrime2 uint64 = 14029467366897019727
+	xxPrime3 uint64 = 1609587929392839161
+	xxPrime4 uint64 = 9650029242287828579
+	xxPrime5 uint64 = 2870177450012600261
+)
+
+// xxh64 returns the xxHash64 of s with seed 0.
+func xxh64[T string | []byte](s T) uint64 {
+	n := len(s)
+	var h uint64
+	if n >= 32 {
+		p1, p2 := xxPrime1, xxPrime2
+		v1 := p1 + p2
+		v2 := p2
+		v3 := uint64(0)
+		v4 := -p1
+		for ; len(s) >= 32; s = s[32:] {
+			v1 = xxRound(v1, xxRead64(s[0:8]))
+			v2 = xxRound(v2, xxRead64(s[8:16]))
+			v3 = xxRound(v3, xxRead64(s[16:24]))
+			v4 = xxRound(v4, xxRead64(s[24:32]))
+		}
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxMergeRound(h, v1)
+		h = xxMergeRound(h, v2)
+		h = xxMergeRound(h, v3)
+		h = xxMergeRound(h, v4)
+	} else {
+		h = xxPrime5
+	}
+	h += uint64(n)
+
+	for ; len(s) >= 8; s = s[8:] {
+		h ^= xxRound(0, xxRead64(s[0:8]))
+		h = bits.RotateLeft64(h, 27)*xxPrime1 + xxPrime4
+	}
+	if len(s) >= 4 {
+		h ^= uint64(xxRead32(s[0:4])) * xxPrime1
+		h = bits.RotateLeft64(h, 23)*xxPrime2 + xxPrime3
+		s = s[4:]
+	}
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i]) * xxPrime5
+		h = bits.RotateLeft64(h, 11) * xxPrime1
+	}
+
+	h ^= h >> 33
+	h *= xxPrime2
+	h ^= h >> 29
+	h *= xxPrime3
+	h ^= h >> 32
+	return h
+}
+
+func xxRound(acc, input uint64) uint64 {
+	acc += input * xxPrime2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * xxPrime1
+}
+
+func xxMergeRound(acc, val uint64) uint64 {
+	acc ^= xxRound(0, val)
+	return acc*xxPrime1 + xxPrime4
+}
+
+func xxRead64[T string | []byte](s T) uint64 {
+	return uint64(s[0]) | uint64(s[1])<<8 | uint64(s[2])<<16 | uint64(s[3])<<24 |
+		uint64(s[4])<<32 | uint64(s[5])<<40 | uint64(s[6])<<48 | uint64(s[7])<<56
+}
+
+func xxRead32[T string | []byte](s T) uint32 {
+	return uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16 | uint32(s[3])<<24
+}
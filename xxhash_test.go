@@ -0,0 +1,62 @@
+package hashring
+
+import (
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXXHash64(t *testing.T) {
+	tt := []struct {
+		key string
+		sum uint64
+	}{
+		{"", 0xef46db3751d8e999},
+		{"a", 0xd24ec4f1a98c6e5b},
+		{"abc", 0x44bc2cf5ad770999},
+		{"test", 0x4fdcca5ddb678139},
+		{"hello world!!", 0x66a0655cf0d0fc26},
+		{strings.Repeat("x", 31), 0x60dd0d01083b99f0},
+		{strings.Repeat("abcdefgh", 5) + "xyz", 0xaaf9ada2ae7f44d0},
+		{strings.Repeat("q", 100), 0x9a081e2d827f6447},
+	}
+	for _, tc := range tt {
+		assert.Equal(t, tc.sum, xxh64(tc.key), tc.key)
+		assert.Equal(t, tc.sum, binary.LittleEndian.Uint64(XXHash64.Hash([]byte(tc.key))), tc.key)
+	}
+}
+
+func TestWithHasherXXHash64(t *testing.T) {
+	nodes := []string{"a", "b", "c"}
+	hashRing := New(nodes, WithHasher(XXHash64))
+	assert.NoError(t, hashRing.Validate())
+	assert.Len(t, hashRing.sortedKeys, 3*defaultReplicas*2)
+	assert.Equal(t, HashKey(uint32(xxh64("test"))), hashRing.GenKey("test"))
+
+	// The string fast path places keys like the Hasher does.
+	generic := New(nodes, WithHasher(HasherFunc(XXHash64.Hash)))
+	expectSameCircle(t, hashRing, generic)
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		assert.Equal(t, generic.GenKey(key), hashRing.GenKey(key))
+	}
+
+	wide := New(nodes, WithHasher(XXHash64), With64BitKeys())
+	assert.Equal(t, HashKey64(xxh64("test")), wide.GenKey64("test"))
+	assert.Len(t, wide.sortedKeys, 3*defaultReplicas)
+
+	allocs := testing.AllocsPerRun(100, func() { hashRing.GetNode("test") })
+	assert.Equal(t, 0.0, allocs)
+}
+
+func BenchmarkHashesSingleXXHash64(b *testing.B) {
+	hashRing := New([]string{"a", "b", "c", "d", "e", "f", "g"}, WithHasher(XXHash64))
+	keys := []string{"test", "test1", "test2", "test3", "test4", "test5", "aaaa", "bbbb"}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hashRing.GetNode(keys[i%len(keys)])
+	}
+}
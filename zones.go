@@ -0,0 +1,39 @@
+package hashring
+
+// ZoneLabel is the label of the zone of a node, see WithNodeLabels.
+const ZoneLabel = "zone"
+
+// GetNodesInZones returns one node for each of zones, the first in ring
+// order from stringKey whose ZoneLabel is that zone, e.g. to place a replica
+// in each availability zone. nodes[i] is the node of zones[i]; a zone listed
+// twice gets the first two of its nodes. The ring is walked once. ok is false
+// if a zone has too few nodes.
+func (h *HashRing) GetNodesInZones(stringKey string, zones []string) (nodes []string, ok bool) {
+	if len(zones) == 0 {
+		return nil, false
+	}
+	pos, ok := h.GetNodePos(stringKey)
+	if !ok {
+		return nil, false
+	}
+
+	// Indexes of zones still without a node, by zone.
+	pending := make(map[string][]int, len(zones))
+	for i, zone := range zones {
+		pending[zone] = append(pending[zone], i)
+	}
+	nodes = make([]string, len(zones))
+	_, ok = h.walkWhere(pos, len(zones), func(node string) bool {
+		zone := h.nodeLabels(node)[ZoneLabel]
+		indexes := pending[zone]
+		if len(indexes) == 0 {
+			return false
+		}
+		nodes[indexes[0]], pending[zone] = node, indexes[1:]
+		return true
+	})
+	if !ok {
+		return nil, false
+	}
+	return nodes, true
+}
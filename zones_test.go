@@ -0,0 +1,43 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNodesInZones(t *testing.T) {
+	hashRing := newZonedRing()
+	zones := []string{"z3", "z1", "z2"}
+	for i := 0; i < 300; i++ {
+		key := strconv.Itoa(i)
+		nodes, ok := hashRing.GetNodesInZones(key, zones)
+		assert.True(t, ok)
+		assert.Len(t, nodes, 3)
+
+		// Each zone gets its first node in ring order.
+		walk, _ := hashRing.GetNodes(key, hashRing.Size())
+		for j, zone := range zones {
+			for _, node := range walk {
+				if hashRing.nodeLabels(node)[ZoneLabel] == zone {
+					assert.Equal(t, node, nodes[j], key)
+					break
+				}
+			}
+		}
+	}
+
+	nodes, ok := hashRing.GetNodesInZones("test", []string{"z1", "z1"})
+	assert.True(t, ok)
+	assert.ElementsMatch(t, []string{"a", "b"}, nodes)
+
+	_, ok = hashRing.GetNodesInZones("test", []string{"z1", "z1", "z1"})
+	assert.False(t, ok)
+	_, ok = hashRing.GetNodesInZones("test", []string{"z4"})
+	assert.False(t, ok)
+	_, ok = hashRing.GetNodesInZones("test", nil)
+	assert.False(t, ok)
+	_, ok = New(nil).GetNodesInZones("test", []string{"z1"})
+	assert.False(t, ok)
+}